@@ -0,0 +1,115 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
+
+	"github.com/spf13/cobra"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:     "check",
+	GroupID: "core",
+	Short:   "Validate node prerequisites for Cloud Native Stack",
+	Long: `Capture a snapshot of the node and validate it against the Cloud Native Stack
+prerequisites:
+  - Swap disabled and not configured in /etc/fstab
+  - cgroup v2 and the systemd cgroup driver
+  - overlay, br_netfilter and nvidia kernel modules loaded
+  - containerd or CRI-O running
+  - Kubernetes packages held
+
+The report contains the snapshot and one result per rule, with remediation for
+failed rules. Use -o html or -o markdown for a human-readable report with the
+failures highlighted, or -o prometheus to export the results as metrics for
+the node_exporter textfile collector.
+
+In CI pipelines, -o junit writes a JUnit XML report with one test case per rule
+and -o sarif a SARIF 2.1.0 log for code scanning, both of which GitLab, GitHub
+and Jenkins render natively.
+
+The command exits with an error if any error severity rule fails.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx := cmd.Context()
+		logger := GetLogger()
+
+		format, tmpl, err := parseOutputFormat(serializers.FormatJUnit, serializers.FormatSARIF)
+		if err != nil {
+			return err
+		}
+		// The report isn't a list of configurations, fail before collecting
+		if format == serializers.FormatTable || format == serializers.FormatNDJSON {
+			return fmt.Errorf("unsupported format %q for check results", format)
+		}
+
+		redactor, err := newRedactor()
+		if err != nil {
+			return err
+		}
+
+		factory, err := newCollectorFactory()
+		if err != nil {
+			return err
+		}
+
+		ns := snapshotter.NodeSnapshotter{
+			Factory:  factory,
+			Logger:   logger,
+			Redactor: redactor,
+		}
+		configs, err := ns.Collect(ctx)
+		if err != nil {
+			return err
+		}
+
+		hostname, _ := os.Hostname()
+		report := checks.Report{
+			Node:           hostname,
+			Configurations: configs,
+			Results:        checks.Run(checks.DefaultRules(), configs),
+		}
+
+		out, err := openOutputFile(format)
+		if err != nil {
+			return err
+		}
+		if err := serializers.NewWriter(format, out, serializers.WithTemplate(tmpl)).Serialize(report); err != nil {
+			_ = out.Abort()
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+
+		if report.Failed() {
+			failed, total := 0, 0
+			for _, res := range report.Results {
+				if res.Severity != checks.SeverityError {
+					continue
+				}
+				total++
+				if res.Status == checks.StatusFail {
+					failed++
+				}
+			}
+			return fmt.Errorf("validation failed: %d of %d error severity rules failed", failed, total)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	addOutputFlags(checkCmd, "json, yaml, html, markdown, prometheus, junit, sarif, go-template=..., template=<file>")
+	addCollectorFlags(checkCmd)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cluster"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/k8s"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	kubeconfig          string
+	kubeContext         string
+	clusterNamespace    string
+	clusterImage        string
+	clusterNodeSelector string
+	clusterConcurrency  int
+	clusterTimeout      time.Duration
+	clusterOutputDir    string
+	clusterOutputFormat string
+	clusterSnapshotArgs []string
+)
+
+// clusterCmd represents the cluster command
+var clusterCmd = &cobra.Command{
+	Use:     "cluster",
+	GroupID: "core",
+	Short:   "Run eidos on the nodes of a Kubernetes cluster",
+}
+
+var clusterSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Snapshot the nodes of a Kubernetes cluster",
+	Long: `Snapshot the nodes of a Kubernetes cluster by running eidos in a short-lived
+privileged job on each node, with the host root filesystem mounted read-only.
+The jobs tolerate all taints and are deleted when done. Nodes that aren't
+ready are skipped.
+
+Each node's snapshot is written to <output-dir>/<node>.json, or .yaml, labeled
+with the node name (` + cluster.NodeLabel + `). A summary of the nodes is
+printed when done, the command fails if any node failed.
+
+The cluster is the current context of the kubeconfig, see --kubeconfig and
+--context, or the cluster eidos runs in. The user needs to create jobs and
+read pods and their logs in the namespace, and to list nodes:
+  eidos cluster snapshot --image <registry>/eidos:<version> \
+    --node-selector nvidia.com/gpu.present=true --output-dir snapshots`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		format := serializers.Format(clusterOutputFormat)
+		if format != serializers.FormatJSON && format != serializers.FormatYAML {
+			return fmt.Errorf("unsupported output format %q, must be json or yaml", clusterOutputFormat)
+		}
+
+		cfg, err := k8s.LoadConfig(kubeconfig, kubeContext)
+		if err != nil {
+			return err
+		}
+		client, err := k8s.NewClient(cfg)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(clusterOutputDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		s := &cluster.Snapshotter{
+			Client:       client,
+			Namespace:    clusterNamespace,
+			Image:        clusterImage,
+			NodeSelector: clusterNodeSelector,
+			Concurrency:  clusterConcurrency,
+			Timeout:      clusterTimeout,
+			Logger:       GetLogger(),
+		}
+		if len(clusterSnapshotArgs) > 0 {
+			s.Args = append(append([]string{}, cluster.DefaultArgs...), clusterSnapshotArgs...)
+		}
+		results, err := s.Run(cmd.Context())
+		if results == nil {
+			return err
+		}
+
+		failed := 0
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NODE\tSTATUS\tITEMS\tERROR")
+		for _, r := range results {
+			if r.Err == nil {
+				path := filepath.Join(clusterOutputDir, r.Node+"."+string(format))
+				r.Err = writeSnapshot(path, format, r.Snapshot)
+			}
+			switch {
+			case r.Err == nil:
+				fmt.Fprintf(tw, "%s\tok\t%d\t\n", r.Node, len(r.Snapshot.Items))
+			case errors.Is(r.Err, cluster.ErrNodeNotReady):
+				fmt.Fprintf(tw, "%s\tskipped\t-\t%s\n", r.Node, r.Err)
+			default:
+				failed++
+				fmt.Fprintf(tw, "%s\tfailed\t-\t%s\n", r.Node, r.Err)
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d nodes failed", failed, len(results))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(clusterCmd)
+	clusterCmd.AddCommand(clusterSnapshotCmd)
+
+	clusterCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "",
+		"path of the kubeconfig file (default merges the files of $KUBECONFIG, or ~/.kube/config)")
+	clusterCmd.PersistentFlags().StringVar(&kubeContext, "context", "",
+		"kubeconfig context (default is the current context)")
+	clusterCmd.PersistentFlags().StringVarP(&clusterNamespace, "namespace", "n", "",
+		"namespace of the jobs (default is the context's namespace)")
+
+	clusterSnapshotCmd.Flags().StringVar(&clusterImage, "image", "",
+		"eidos container image run on the nodes")
+	clusterSnapshotCmd.Flags().StringVarP(&clusterNodeSelector, "node-selector", "l", "",
+		"label selector of the nodes to snapshot, e.g. nvidia.com/gpu.present=true")
+	clusterSnapshotCmd.Flags().IntVar(&clusterConcurrency, "concurrency", cluster.DefaultConcurrency,
+		"number of nodes snapshotted at a time")
+	clusterSnapshotCmd.Flags().DurationVar(&clusterTimeout, "timeout", cluster.DefaultTimeout,
+		"maximum time of a node snapshot, including the image pull")
+	clusterSnapshotCmd.Flags().StringVar(&clusterOutputDir, "output-dir", ".",
+		"directory the node snapshots are written to")
+	clusterSnapshotCmd.Flags().StringVarP(&clusterOutputFormat, "output", "o", "json",
+		"output format of the node snapshots (json, yaml)")
+	clusterSnapshotCmd.Flags().StringSliceVar(&clusterSnapshotArgs, "snapshot-args", nil,
+		"additional 'eidos snapshot' arguments, e.g. --snapshot-args=--packages=nvidia-driver-570")
+	_ = clusterSnapshotCmd.MarkFlagRequired("image")
+}
+
+// writeSnapshot writes a node snapshot to path.
+func writeSnapshot(path string, format serializers.Format, snap *snapshot.Snapshot) error {
+	out, err := serializers.OpenOutput(path, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := serializers.NewWriter(format, out).Serialize(snap); err != nil {
+		_ = out.Abort()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
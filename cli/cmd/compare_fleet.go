@@ -0,0 +1,81 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/fleet"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareFleetFormat       string
+	compareFleetIgnore       []string
+	compareFleetKeepIdentity bool
+)
+
+// compareFleetCmd represents the compare-fleet command
+var compareFleetCmd = &cobra.Command{
+	Use:     "compare-fleet <dir>",
+	GroupID: "core",
+	Short:   "Report the nodes deviating from the rest of a fleet",
+	Long: `Compare the snapshots of nodes that should be configured identically, such
+as the output of 'eidos cluster snapshot' or an extracted 'eidos remote
+snapshot' archive, and report the nodes deviating from the majority.
+
+The snapshots in the directory and its subdirectories are read, nodes are named
+by the node or host label of the snapshot, its hostname or its file name.
+Configurations are matched by type and ID like in 'eidos history diff' and
+compared field by field. For each configuration or field that isn't the same
+on all nodes, the majority value and the nodes deviating from it are listed,
+grouped by type, followed by a matrix of the number of deviations of each node
+by type.
+
+Volatile fields are ignored, and so are the values identifying each node: its
+hostname, boot and random UUIDs, interface MAC addresses and RDMA GUIDs, unless
+--keep-identity is set. Use --ignore to exclude other configurations by TYPE or
+TYPE:ID, with ID a glob pattern:
+  eidos compare-fleet snapshots
+  eidos compare-fleet snapshots -o json
+  eidos compare-fleet snapshots --ignore 'Sysctl:/proc/sys/kernel/sched_*'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		nodes, err := fleet.Load(args[0])
+		if err != nil {
+			return err
+		}
+		report, err := fleet.Compare(nodes, fleet.Options{
+			KeepIdentity: compareFleetKeepIdentity,
+			Ignore:       compareFleetIgnore,
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+
+		switch compareFleetFormat {
+		case "json", "yaml":
+			return serializers.NewWriter(serializers.Format(compareFleetFormat), os.Stdout).Serialize(report)
+		case "text":
+			return report.WriteText(os.Stdout)
+		default:
+			return fmt.Errorf("unsupported format %q, must be text, json or yaml", compareFleetFormat)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compareFleetCmd)
+
+	compareFleetCmd.Flags().StringVarP(&compareFleetFormat, "output", "o", "text",
+		"output format (text, json, yaml)")
+	compareFleetCmd.Flags().StringSliceVar(&compareFleetIgnore, "ignore", nil,
+		"configurations to ignore, TYPE or TYPE:ID with ID a glob pattern (repeatable)")
+	compareFleetCmd.Flags().BoolVar(&compareFleetKeepIdentity, "keep-identity", false,
+		"also compare hostnames, UUIDs, MAC addresses and GUIDs identifying each node")
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/values"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportSnapshot   string
+	exportOutputFile string
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:     "export",
+	GroupID: "core",
+	Short:   "Export the configuration of a node for other tools",
+}
+
+var exportAnsibleValuesCmd = &cobra.Command{
+	Use:   "ansible-values",
+	Short: "Generate the Ansible values file of the Cloud Native Stack playbooks",
+	Long: `Generate the cns_values.yaml of the Cloud Native Stack Ansible playbooks,
+following docs/playbooks/cns_values_16.0.yaml, from a snapshot of the node or
+of the --snapshot file.
+
+The values detected from the node are:
+  - the container runtime and Kubernetes installation
+  - the versions of containerd, runc, CRI-O, cri-dockerd, the CNI plugins,
+    the NVIDIA Container Toolkit, Kubernetes and Helm, from their packages or
+    the release binaries in /usr/local and /opt/cni/bin
+  - the driver version and whether it's the open kernel module flavor
+  - GPUDirect Storage, from the nvidia_fs kernel module
+  - MIG, enabled if any GPU is in MIG mode
+  - CDI, enabled if NVIDIA CDI specs are in /etc/cdi or /var/run/cdi
+  - Secure Boot
+
+Values that couldn't be determined, e.g. components installed neither from a
+package nor a release tarball or MIG without the NVIDIA driver loaded, keep the defaults of the
+release and are flagged with an "eidos:" comment. The settings that can't
+be detected on a node, such as the operators to install, are the defaults:
+  eidos export ansible-values --output-file cns_values.yaml
+  eidos export ansible-values --snapshot node.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		var configs []collectors.Configuration
+		source := "the node"
+		if exportSnapshot != "" {
+			in, err := serializers.OpenInput(exportSnapshot)
+			if err != nil {
+				return err
+			}
+			s, err := snapshot.Decode(in)
+			in.Close()
+			if err != nil {
+				return err
+			}
+			configs = s.Items
+			source = exportSnapshot
+		} else {
+			factory, err := newCollectorFactory()
+			if err != nil {
+				return err
+			}
+
+			ns := snapshotter.NodeSnapshotter{
+				Factory:    factory,
+				Logger:     GetLogger(),
+				Collectors: []string{"kmod", "systemd", "packages", "security", "gpu"},
+			}
+			if configs, err = ns.Collect(cmd.Context()); err != nil {
+				return err
+			}
+		}
+
+		out, err := serializers.OpenOutput(exportOutputFile, 0o600)
+		if err != nil {
+			return err
+		}
+		if err := values.Write(out, source, values.Detect(configs)); err != nil {
+			_ = out.Abort()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", exportOutputFile, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportAnsibleValuesCmd)
+
+	addHostFlags(exportAnsibleValuesCmd)
+
+	exportAnsibleValuesCmd.Flags().StringVar(&exportSnapshot, "snapshot", "",
+		"snapshot file to generate the values of instead of the node")
+	exportAnsibleValuesCmd.Flags().StringVar(&exportOutputFile, "output-file", serializers.StdoutPath,
+		"write the values to this file instead of stdout")
+}
@@ -0,0 +1,188 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/diff"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/history"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyListFormat string
+	historyDiffFormat string
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:     "history",
+	GroupID: "utility",
+	Short:   "Browse the snapshot history recorded by watch",
+	Long: `Browse the snapshots and configuration changes recorded by 'eidos watch'.
+
+Entries are referred to by their ID, a unique prefix of it, "latest" or
+"latest~N" for the Nth entry before the latest:
+  eidos history list
+  eidos history show latest -o yaml
+  eidos history diff                     # changes recorded by the latest entry
+  eidos history diff 20251019T12         # changes recorded by an entry
+  eidos history diff latest~5 latest     # changes between two entries`,
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the history entries",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		store, err := openHistoryReadOnly()
+		if err != nil {
+			return err
+		}
+		entries, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		switch historyListFormat {
+		case "json", "yaml":
+			return serializers.NewWriter(serializers.Format(historyListFormat), os.Stdout).Serialize(entries)
+		case "table":
+		default:
+			return fmt.Errorf("unsupported format %q, must be table, json or yaml", historyListFormat)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tCREATED\tITEMS\tCHANGES\tTRIGGER")
+		for _, e := range entries {
+			changes := strconv.Itoa(len(e.Changes))
+			if e.Previous == "" {
+				changes = "-"
+			}
+			trigger := e.Trigger
+			if trigger == "" {
+				trigger = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", e.ID, e.Created.Local().Format("2006-01-02 15:04:05"), e.Items, changes, trigger)
+		}
+		return tw.Flush()
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <entry>",
+	Short: "Show the snapshot of a history entry",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		format, tmpl, err := parseOutputFormat()
+		if err != nil {
+			return err
+		}
+
+		store, err := openHistoryReadOnly()
+		if err != nil {
+			return err
+		}
+		snap, err := store.Snapshot(args[0])
+		if err != nil {
+			return err
+		}
+
+		out, err := openOutputFile(format)
+		if err != nil {
+			return err
+		}
+		if err := serializers.NewWriter(format, out, serializers.WithTemplate(tmpl)).Serialize(snap); err != nil {
+			_ = out.Abort()
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+		return out.Close()
+	},
+}
+
+var historyDiffCmd = &cobra.Command{
+	Use:   "diff [<entry> [<entry>]]",
+	Short: "Show configuration changes",
+	Long: `Show the configuration changes recorded by an entry, the latest by default,
+or the changes between two entries.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(_ *cobra.Command, args []string) error {
+		store, err := openHistoryReadOnly()
+		if err != nil {
+			return err
+		}
+
+		var changes []diff.Change
+		switch len(args) {
+		case 2:
+			from, err := store.Snapshot(args[0])
+			if err != nil {
+				return err
+			}
+			to, err := store.Snapshot(args[1])
+			if err != nil {
+				return err
+			}
+			if changes, err = diff.Compare(from.Items, to.Items); err != nil {
+				return err
+			}
+		default:
+			ref := history.Latest
+			if len(args) == 1 {
+				ref = args[0]
+			}
+			entry, err := store.Get(ref)
+			if err != nil {
+				return err
+			}
+			changes = entry.Changes
+		}
+
+		switch historyDiffFormat {
+		case "text", "table":
+			return diff.WriteText(os.Stdout, changes)
+		case "json", "yaml":
+			if changes == nil {
+				changes = []diff.Change{}
+			}
+			return serializers.NewWriter(serializers.Format(historyDiffFormat), os.Stdout).Serialize(changes)
+		default:
+			return fmt.Errorf("unsupported format %q, must be text, json or yaml", historyDiffFormat)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyListCmd, historyShowCmd, historyDiffCmd)
+
+	for _, c := range []*cobra.Command{historyListCmd, historyShowCmd, historyDiffCmd} {
+		addHistoryFlags(c)
+	}
+
+	historyListCmd.Flags().StringVarP(&historyListFormat, "output", "o", "table",
+		"output format (table, json, yaml)")
+	historyDiffCmd.Flags().StringVarP(&historyDiffFormat, "output", "o", "text",
+		"output format (text, json, yaml)")
+	addOutputFlags(historyShowCmd, "json, yaml, table, ndjson, html, markdown, prometheus, go-template=..., template=<file>")
+}
+
+// openHistoryReadOnly opens an existing history store without creating it.
+func openHistoryReadOnly() (*history.Store, error) {
+	if _, err := os.Stat(historyDir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no history in %s, record one with 'eidos watch'", historyDir)
+		}
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	return history.Open(historyDir, history.Retention{})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/query"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:     "query <snapshot> <expression>",
+	GroupID: "core",
+	Short:   "Select configurations from a snapshot",
+	Long: `Select configurations from a snapshot file with a query expression and
+output them in the chosen format. The snapshot can be JSON, NDJSON or YAML,
+optionally compressed with gzip or zstd. Use - to read from stdin.
+
+Expressions compare fields of a configuration with values:
+  Type=Sysctl && Key=/proc/sys/vm/*
+  Type=SystemD && Properties.ActiveState!=active
+  Type=Network && (MTU>=9000 || Driver=~^mlx5)
+  Type=RDMA && Ports.State=ACTIVE
+
+Field paths are dotted and case-insensitive. Paths other than Type are looked
+up in the configuration data (Data. may be omitted) and lists match when any
+element matches. A path without an operator matches when the field is present
+and not empty.
+
+Operators:
+  = ==       glob match
+  !=         no glob match
+  =~ !~      regular expression match / no match
+  < <= > >=  numeric comparison
+
+Combine comparisons with && (or ,), || and !, and group them with parentheses.
+Quote values containing spaces or operator characters.
+
+The signature of a signed snapshot doesn't cover the selected configurations
+and is dropped, use --sign-key to sign the result again.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(_ *cobra.Command, args []string) error {
+		format, tmpl, err := parseOutputFormat()
+		if err != nil {
+			return err
+		}
+
+		q, err := query.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+
+		var signKey ed25519.PrivateKey
+		if signKeyFile != "" {
+			if format != serializers.FormatJSON {
+				return fmt.Errorf("signed snapshots must use the json format, not %s", format)
+			}
+			if signKey, err = snapshot.LoadPrivateKey(signKeyFile); err != nil {
+				return err
+			}
+		}
+
+		in, err := serializers.OpenInput(args[0])
+		if err != nil {
+			return err
+		}
+		s, err := snapshot.Decode(in)
+		in.Close()
+		if err != nil {
+			return err
+		}
+		s.SetItems(q.Filter(s.Items))
+		if signKey != nil {
+			if err := s.Sign(signKey); err != nil {
+				return err
+			}
+		}
+
+		out, err := openOutputFile(format)
+		if err != nil {
+			return err
+		}
+		if err := serializers.NewWriter(format, out, serializers.WithTemplate(tmpl)).Serialize(s); err != nil {
+			_ = out.Abort()
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+		return out.Close()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	addOutputFlags(queryCmd, "json, yaml, ndjson, html, markdown, prometheus, go-template=..., template=<file>")
+
+	queryCmd.Flags().StringVar(&signKeyFile, "sign-key", "",
+		"sign the result with this PEM encoded ed25519 private key (json format only)")
+}
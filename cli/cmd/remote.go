@@ -0,0 +1,155 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/inventory"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/remote"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	inventoryFile      string
+	remoteLimit        string
+	remoteBinary       string
+	remoteBecome       bool
+	remoteConcurrency  int
+	remoteTimeout      time.Duration
+	remoteDir          string
+	remoteArchive      string
+	remoteSnapshotArgs []string
+)
+
+// remoteCmd represents the remote command
+var remoteCmd = &cobra.Command{
+	Use:     "remote",
+	GroupID: "core",
+	Short:   "Run eidos on the hosts of an Ansible inventory over SSH",
+}
+
+var remoteSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Snapshot the hosts of an Ansible inventory over SSH",
+	Long: `Snapshot the hosts of an Ansible INI inventory, such as the hosts file of the
+Cloud Native Stack playbooks, over SSH. The eidos binary is streamed to each
+host, run with sudo and removed, on --concurrency hosts at a time.
+
+The ssh client of the system is used with the connection variables of the
+inventory: ansible_host, ansible_port, ansible_user,
+ansible_ssh_private_key_file, ansible_ssh_common_args and
+ansible_ssh_extra_args, ansible_become and ansible_become_password. Password
+authentication with ansible_password requires sshpass. Hosts with
+ansible_connection=local run eidos directly.
+
+The results are written to a tar archive, compressed based on the extension of
+--output-file, with a directory per host holding snapshot.json, labeled with
+the inventory name (` + remote.HostLabel + `), eidos.log and, for failed
+hosts, error. A summary of the hosts is printed when done, the command fails
+if any host failed:
+  eidos remote snapshot -i docs/playbooks/hosts --limit nodes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		inv, err := inventory.Load(inventoryFile)
+		if err != nil {
+			return err
+		}
+		hosts, err := inv.Select(remoteLimit)
+		if err != nil {
+			return err
+		}
+		if len(hosts) == 0 {
+			return fmt.Errorf("no hosts in %s match %q", inventoryFile, remoteLimit)
+		}
+
+		r := &remote.Runner{
+			Binary:      remoteBinary,
+			Become:      remoteBecome,
+			Concurrency: remoteConcurrency,
+			Timeout:     remoteTimeout,
+			Dir:         remoteDir,
+			Logger:      GetLogger(),
+		}
+		if len(remoteSnapshotArgs) > 0 {
+			r.Args = append(append([]string{}, remote.DefaultArgs...), remoteSnapshotArgs...)
+		}
+
+		out, err := serializers.OpenOutput(remoteArchive, 0o600)
+		if err != nil {
+			return err
+		}
+		results, err := r.Run(cmd.Context(), hosts)
+		if results == nil {
+			_ = out.Abort()
+			return err
+		}
+		if err := remote.WriteArchive(out, results); err != nil {
+			_ = out.Abort()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", remoteArchive, err)
+		}
+
+		var summary io.Writer = os.Stdout
+		if remoteArchive == serializers.StdoutPath {
+			summary = os.Stderr
+		}
+		failed := 0
+		tw := tabwriter.NewWriter(summary, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "HOST\tSTATUS\tITEMS\tERROR")
+		for _, r := range results {
+			if r.Err != nil {
+				failed++
+				fmt.Fprintf(tw, "%s\tfailed\t-\t%s\n", r.Host, r.Err)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\tok\t%d\t\n", r.Host, len(r.Snapshot.Items))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d hosts failed", failed, len(results))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remoteSnapshotCmd)
+
+	remoteCmd.PersistentFlags().StringVarP(&inventoryFile, "inventory", "i", "hosts",
+		"Ansible INI inventory file")
+	remoteCmd.PersistentFlags().StringVarP(&remoteLimit, "limit", "l", inventory.All,
+		"Ansible host pattern of the hosts, e.g. nodes or 'nodes:!gpu-01'")
+	remoteCmd.PersistentFlags().StringVar(&remoteBinary, "binary", "",
+		"eidos Linux binary for the hosts' architecture (default is this binary)")
+	remoteCmd.PersistentFlags().BoolVar(&remoteBecome, "become", true,
+		"run eidos with sudo on hosts that don't set ansible_become")
+	remoteCmd.PersistentFlags().IntVar(&remoteConcurrency, "concurrency", remote.DefaultConcurrency,
+		"number of hosts snapshotted at a time")
+	remoteCmd.PersistentFlags().DurationVar(&remoteTimeout, "timeout", remote.DefaultTimeout,
+		"maximum time of a host snapshot, including copying eidos")
+	remoteCmd.PersistentFlags().StringVar(&remoteDir, "remote-dir", "",
+		"directory eidos is copied to on the hosts, for hosts with /tmp mounted noexec (default $TMPDIR or /tmp)")
+
+	remoteSnapshotCmd.Flags().StringVar(&remoteArchive, "output-file", "eidos-snapshots.tar.gz",
+		"archive of the host snapshots, - for stdout")
+	remoteSnapshotCmd.Flags().StringSliceVar(&remoteSnapshotArgs, "snapshot-args", nil,
+		"additional 'eidos snapshot' arguments, e.g. --snapshot-args=--packages=nvidia-driver-570")
+}
@@ -47,7 +47,35 @@ Built:   %s
 Tooling to provide system optimization and verification capabilities: 
 
 snapshot - captures system configuration snapshots including kernel modules,
-           systemd services, GRUB parameters, and sysctl settings.`, version, commit, date),
+           systemd services, GRUB parameters, sysctl settings,
+           network/RDMA devices, installed packages, security posture,
+           and mounts, swap and cgroups.
+
+check    - validates a node snapshot against the Cloud Native Stack
+           prerequisites and reports failures with remediation.
+
+query    - selects configurations from a snapshot with a query expression.
+
+verify   - verifies the signature of a signed snapshot.
+
+serve    - runs an agent serving node snapshots over HTTP.
+
+cluster  - snapshots the nodes of a Kubernetes cluster with short-lived
+           privileged jobs.
+
+remote   - snapshots the hosts of an Ansible inventory over SSH.
+
+compare-fleet - reports the nodes of a fleet deviating from the majority.
+
+export   - generates the Ansible values file of the Cloud Native Stack
+           playbooks from the node.
+
+values   - validates the Ansible values files of the playbooks.
+
+upgrade  - plans the upgrade of a node between Cloud Native Stack releases.
+
+watch    - records configuration changes of the node over time, see
+           'eidos history' to browse them.`, version, commit, date),
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
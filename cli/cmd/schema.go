@@ -0,0 +1,37 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:     "schema",
+	GroupID: "utility",
+	Short:   "Print the JSON Schema of the snapshot format",
+	Long: `Print the JSON Schema (draft 2020-12) of JSON snapshots written by
+'eidos snapshot'. The schema is generated from the collector types and
+describes the data of every configuration type.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(snapshot.Schema()); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/server"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen  string
+	serveRate    float64
+	serveBurst   int
+	serveTimeout time.Duration
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:     "serve",
+	GroupID: "core",
+	Short:   "Serve node snapshots over HTTP",
+	Long: fmt.Sprintf(`Run a long-lived agent serving snapshots of the node over HTTP or a Unix
+socket, e.g. as a Kubernetes DaemonSet.
+
+Endpoints:
+  GET /snapshot              snapshot of all collectors
+  GET /snapshot/<collector>  snapshot of one collector:
+%s
+  GET /healthz               liveness probe
+  GET /metrics               agent metrics in the Prometheus text format
+
+Each request collects a fresh snapshot. The format is selected with the
+format query parameter or the Accept header (json, yaml, ndjson, html,
+markdown, table, prometheus), the filter query parameter takes a query
+expression, see 'eidos query --help':
+  curl 'localhost:8080/snapshot/sysctl?format=yaml&filter=Key=/proc/sys/vm/*'
+  curl -H 'Accept: text/html' localhost:8080/snapshot > node.html
+
+Node configuration metrics can be scraped from /snapshot?format=prometheus.
+
+Secrets are redacted as with 'eidos snapshot'. Snapshot requests are rate
+limited with --rate and --burst, excess requests get 429 Too Many Requests.
+The agent listens on localhost by default, use --listen :8080 to serve other
+hosts or --listen unix:///run/eidos.sock for a Unix socket.`, wrapList(snapshotter.CollectorNames(), 29, 80)),
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if serveRate < 0 {
+			return fmt.Errorf("invalid rate %v, must not be negative", serveRate)
+		}
+
+		// Each request creates its own redactor from the rules
+		rules, err := redactRules()
+		if err != nil {
+			return err
+		}
+
+		factory, err := newCollectorFactory()
+		if err != nil {
+			return err
+		}
+
+		srv := &server.Server{
+			Factory:       factory,
+			Logger:        GetLogger(),
+			RedactRules:   rules,
+			StripVolatile: stripVolatile,
+			Timeout:       serveTimeout,
+		}
+		if serveRate > 0 {
+			srv.Limiter = server.NewRateLimiter(serveRate, serveBurst)
+		}
+
+		l, err := server.Listen(serveListen)
+		if err != nil {
+			return err
+		}
+		return srv.Serve(cmd.Context(), l)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	addCollectorFlags(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveListen, "listen", "localhost:8080",
+		"address to listen on, host:port or unix:///path/to/socket")
+	serveCmd.Flags().Float64Var(&serveRate, "rate", 1,
+		"average snapshot requests per second, 0 for unlimited")
+	serveCmd.Flags().IntVar(&serveBurst, "burst", 5,
+		"maximum burst of snapshot requests")
+	serveCmd.Flags().DurationVar(&serveTimeout, "timeout", server.DefaultTimeout,
+		"maximum time to collect a snapshot")
+	serveCmd.Flags().BoolVar(&stripVolatile, "strip-volatile", false,
+		"remove timestamps, PIDs and counters from snapshots")
+}
+
+// wrapList joins the names with commas into lines of at most width
+// characters, indented by indent spaces.
+func wrapList(names []string, indent, width int) string {
+	var b strings.Builder
+	line := strings.Repeat(" ", indent)
+	for i, name := range names {
+		if i < len(names)-1 {
+			name += ","
+		}
+		if len(line) > indent && len(line)+1+len(name) > width {
+			b.WriteString(line + "\n")
+			line = strings.Repeat(" ", indent)
+		}
+		if len(line) > indent {
+			line += " "
+		}
+		line += name
+	}
+	return b.String() + line
+}
@@ -5,16 +5,37 @@ SPDX-License-Identifier: Apache-2.0
 package cmd
 
 import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
 	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/query"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/redact"
 	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
 	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
 	outputFormat    string
+	outputFile      string
+	outputFileMode  string
 	systemdServices []string
+	hostRoot        string
+	includeVirtual  bool
+	packageNames    []string
+	packageVersion  string
+	packageArch     string
+	snapshotFilter  string
+	stripVolatile   bool
+	noRedact        bool
+	signKeyFile     string
 )
 
 // snapshotCmd represents the snapshot command
@@ -25,45 +46,267 @@ var snapshotCmd = &cobra.Command{
 	Short:   "Capture system configuration snapshot",
 	Long: `Capture a comprehensive snapshot of system configuration including:
   - Loaded kernel modules
-  - SystemD service configurations
+  - SystemD service configurations (read from unit files when D-Bus is unavailable)
   - GRUB boot parameters
   - Sysctl kernel parameters
+  - Network interfaces and RDMA devices
+  - Installed packages (dpkg/rpm) with hold state, and the containerd, runc,
+    CNI plugins and helm binaries installed from release tarballs
+  - Security posture (Secure Boot, lockdown, SELinux, AppArmor, IOMMU)
+  - Mounts, swap and cgroup configuration
+  - GPU MIG mode and NVIDIA CDI specs
+
+The snapshot can be output in JSON, YAML, or table format, or as NDJSON which
+streams one configuration per line as collectors complete. The html and
+markdown formats render a self-contained, human-readable report grouped by
+collector. JSON and YAML snapshots are versioned documents with apiVersion,
+kind, metadata and items, see 'eidos schema'. Output goes to stdout or to a
+file. Files are written atomically and compressed when the name ends in .gz
+or .zst.
+
+The prometheus format exports numeric sysctls, loaded kernel modules, systemd
+unit states and boot parameters as metrics for the node_exporter textfile
+collector. The file is readable by all users (0644) unless --output-file-mode
+is set, as node_exporter runs as its own user:
+  -o prometheus --output-file /var/lib/node_exporter/textfile_collector/eidos.prom
+
+Configurations are sorted by type and key, so snapshots of the same node only
+differ where the node does. NDJSON is written as each collector completes, the
+lines of a collector are sorted but the collectors come in completion order;
+'eidos query' and the other commands reading snapshots sort them again. Use
+--strip-volatile to also drop timestamps, PIDs, counters and per-read sysctl
+values such as kernel/random/uuid for snapshots that can be committed to git
+and diffed.
+
+Values that look like secrets, e.g. passwords and tokens in service
+environments and command lines, are replaced with [REDACTED] before output.
+Additional rules can be defined in the config file:
+  redact:
+    rules:
+      - name: registry-password
+        key: '(?i)registry_pass'        # names of values to redact
+      - name: corp-token
+        pattern: 'corp_[a-z0-9]{32}'    # values to redact
 
-The snapshot can be output in JSON, YAML, or table format.`,
+Snapshots used as evidence, e.g. in node certification, can be signed with an
+ed25519 key so they can't be edited unnoticed before review. The signature is
+added to the JSON document and checked with 'eidos verify':
+  openssl genpkey -algorithm ed25519 -out eidos.key
+  openssl pkey -in eidos.key -pubout -out eidos.pub
+  eidos snapshot --sign-key eidos.key --output-file node.json
+
+Use --filter to only include matching configurations, see 'eidos query --help'
+for the expression syntax:
+  --filter 'Type=Sysctl && Key=/proc/sys/vm/*'
+
+Custom reports can be rendered with Go templates, as with kubectl:
+  -o go-template='{{ range byType "KMod" . }}{{ .Data.Name }}{{ "\n" }}{{ end }}'
+  -o template=report.tmpl
+
+Template helpers: byType, sysctl, sortBy, field, toJson, toYaml, join, upper, lower.`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		ctx := cmd.Context()
 		logger := GetLogger()
 
 		// Parse output format
-		format := serializers.Format(outputFormat)
-		if format != serializers.FormatJSON &&
-			format != serializers.FormatYAML &&
-			format != serializers.FormatTable {
-			format = serializers.FormatJSON
+		format, tmpl, err := parseOutputFormat()
+		if err != nil {
+			return err
+		}
+
+		var q *query.Query
+		if snapshotFilter != "" {
+			if q, err = query.Parse(snapshotFilter); err != nil {
+				return fmt.Errorf("invalid filter: %w", err)
+			}
 		}
 
-		// Create factory with configured services
-		factory := &collectors.DefaultCollectorFactory{
-			SystemDServices: systemdServices,
+		redactor, err := newRedactor()
+		if err != nil {
+			return err
+		}
+
+		var signKey ed25519.PrivateKey
+		if signKeyFile != "" {
+			if format != serializers.FormatJSON {
+				return fmt.Errorf("signed snapshots must use the json format, not %s", format)
+			}
+			if signKey, err = snapshot.LoadPrivateKey(signKeyFile); err != nil {
+				return err
+			}
+		}
+
+		factory, err := newCollectorFactory()
+		if err != nil {
+			return err
+		}
+
+		out, err := openOutputFile(format)
+		if err != nil {
+			return err
 		}
 
 		// Create and run snapshotter
 		ns := snapshotter.NodeSnapshotter{
-			Factory:    factory,
-			Serializer: serializers.NewWriter(format, nil),
-			Logger:     logger,
+			Factory:       factory,
+			Serializer:    serializers.NewWriter(format, out, serializers.WithTemplate(tmpl)),
+			Logger:        logger,
+			Redactor:      redactor,
+			StripVolatile: stripVolatile,
+			SignKey:       signKey,
+		}
+		if q != nil {
+			ns.Filter = q.Match
 		}
 
-		return ns.Run(ctx)
+		if err := ns.Run(ctx); err != nil {
+			_ = out.Abort()
+			return err
+		}
+
+		return out.Close()
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(snapshotCmd)
 
-	snapshotCmd.Flags().StringVarP(&outputFormat, "output", "o", "json",
-		"output format (json, yaml, table)")
-	snapshotCmd.Flags().StringSliceVar(&systemdServices, "systemd-services",
+	addOutputFlags(snapshotCmd, "json, yaml, table, ndjson, html, markdown, prometheus, go-template=..., template=<file>")
+	addCollectorFlags(snapshotCmd)
+
+	snapshotCmd.Flags().StringVar(&snapshotFilter, "filter", "",
+		"only include configurations matching this query expression")
+	snapshotCmd.Flags().BoolVar(&stripVolatile, "strip-volatile", false,
+		"remove timestamps, PIDs and counters for reproducible snapshots")
+	snapshotCmd.Flags().StringVar(&signKeyFile, "sign-key", "",
+		"sign the snapshot with this PEM encoded ed25519 private key (json format only)")
+}
+
+// addOutputFlags registers the output format and file flags on cmd, formats
+// lists the supported formats in the flag usage.
+func addOutputFlags(cmd *cobra.Command, formats string) {
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "json",
+		"output format ("+formats+")")
+	cmd.Flags().StringVar(&outputFile, "output-file", serializers.StdoutPath,
+		"write output to this file instead of stdout (.gz and .zst are compressed)")
+	cmd.Flags().StringVar(&outputFileMode, "output-file-mode", "",
+		"permissions of the output file (octal, default 0600, 0644 for prometheus so node_exporter can read it)")
+}
+
+// addCollectorFlags registers the flags configuring the collectors and the
+// redaction of their results on cmd.
+func addCollectorFlags(cmd *cobra.Command) {
+	addHostFlags(cmd)
+	cmd.Flags().BoolVar(&includeVirtual, "include-virtual-interfaces", false,
+		"include network interfaces not backed by a device (loopback, bridges, veth pairs, CNI interfaces)")
+	cmd.Flags().StringSliceVar(&packageNames, "packages", nil,
+		"package name patterns to snapshot, \"*\" for all (default: Kubernetes, container runtime and NVIDIA packages)")
+	cmd.Flags().StringVar(&packageVersion, "package-version", "",
+		"only snapshot packages whose version matches this pattern")
+	cmd.Flags().StringVar(&packageArch, "package-arch", "",
+		"only snapshot packages whose architecture matches this pattern")
+	cmd.Flags().BoolVar(&noRedact, "no-redact", false,
+		"do not redact secrets in the output")
+}
+
+// addHostFlags registers the flags locating the host and its services on cmd,
+// for commands running collectors with their defaults.
+func addHostFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&systemdServices, "systemd-services",
 		[]string{"containerd.service", "docker.service", "kubelet.service"},
 		"systemd services to snapshot")
+	cmd.Flags().StringVar(&hostRoot, "host-root", "",
+		"host root filesystem prefix (e.g. /host in a container) for the systemd unit file fallback and the network, packages, security, mounts and gpu collectors; kmod, grub and sysctl always read the kernel's /proc")
+}
+
+// newRedactor creates a redactor with the built-in rules and the rules of the
+// redact.rules config key, or returns nil if redaction is disabled.
+func newRedactor() (*redact.Redactor, error) {
+	rules, err := redactRules()
+	if err != nil || rules == nil {
+		return nil, err
+	}
+	return redact.New(rules...)
+}
+
+// redactRules returns the built-in rules and the validated rules of the
+// redact.rules config key, or nil if redaction is disabled.
+func redactRules() ([]redact.Rule, error) {
+	if noRedact {
+		return nil, nil
+	}
+
+	var rules []redact.Rule
+	if err := viper.UnmarshalKey("redact.rules", &rules); err != nil {
+		return nil, fmt.Errorf("invalid redact.rules config: %w", err)
+	}
+
+	rules = append(redact.DefaultRules(), rules...)
+	if _, err := redact.New(rules...); err != nil {
+		return nil, fmt.Errorf("invalid redact.rules config: %w", err)
+	}
+	return rules, nil
+}
+
+// parseOutputFormat parses the --output flag. Formats other than the common
+// ones and the command specific extra formats fall back to JSON.
+func parseOutputFormat(extra ...serializers.Format) (serializers.Format, string, error) {
+	format, tmpl, err := serializers.ParseFormat(outputFormat)
+	if err != nil {
+		return "", "", err
+	}
+	switch format {
+	case serializers.FormatJSON, serializers.FormatYAML, serializers.FormatTable,
+		serializers.FormatNDJSON, serializers.FormatTemplate,
+		serializers.FormatHTML, serializers.FormatMarkdown, serializers.FormatPrometheus:
+		return format, tmpl, nil
+	}
+	if slices.Contains(extra, format) {
+		return format, tmpl, nil
+	}
+	return serializers.FormatJSON, tmpl, nil
+}
+
+// openOutputFile opens the --output-file destination with --output-file-mode.
+// Files are private by default, except metrics for the node_exporter textfile
+// collector, which runs as its own user.
+func openOutputFile(format serializers.Format) (serializers.Output, error) {
+	mode := outputFileMode
+	if mode == "" {
+		mode = "0600"
+		if format == serializers.FormatPrometheus {
+			mode = "0644"
+		}
+	}
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output file mode %q: %w", mode, err)
+	}
+	if perm&^0o777 != 0 {
+		return nil, fmt.Errorf("invalid output file mode %q, only permission bits (0000-0777) are allowed", mode)
+	}
+	out, err := serializers.OpenOutput(outputFile, os.FileMode(perm))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output: %w", err)
+	}
+	return out, nil
+}
+
+// newCollectorFactory creates a collector factory from the collector flags.
+func newCollectorFactory() (*collectors.DefaultCollectorFactory, error) {
+	f := &collectors.DefaultCollectorFactory{
+		SystemDServices:          systemdServices,
+		HostRoot:                 hostRoot,
+		Logger:                   GetLogger(),
+		IncludeVirtualInterfaces: includeVirtual,
+		PackageFilter: collectors.PackageFilter{
+			Names:   packageNames,
+			Version: packageVersion,
+			Arch:    packageArch,
+		},
+	}
+	if err := f.PackageFilter.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
 }
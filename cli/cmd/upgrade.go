@@ -0,0 +1,110 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cns"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/upgrade"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeFrom   string
+	upgradeTo     string
+	upgradeFormat string
+)
+
+// upgradeCmd represents the upgrade command
+var upgradeCmd = &cobra.Command{
+	Use:     "upgrade",
+	GroupID: "core",
+	Short:   "Plan upgrades between Cloud Native Stack releases",
+}
+
+var upgradePlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Preview the changes of an upgrade between Cloud Native Stack releases",
+	Long: `Preview what an upgrade with the cns-upgrade.yaml playbook changes, from the
+release matrix (docs/cns.json):
+  - the components changing versions
+  - the Kubernetes minor versions to upgrade to in turn, as kubeadm only
+    upgrades one minor version at a time
+  - the operating systems supported by both releases
+
+--from is a release version or a snapshot of a node. The release of a snapshot
+is the one of its Kubernetes version, the installed component versions are
+the ones of its packages, its operating system is told from the distribution
+suffixes of the package versions, and the node prerequisites it doesn't meet,
+see 'eidos check', are listed:
+  eidos upgrade plan --from 14.2 --to 16.0
+  eidos upgrade plan --from node.json -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		to := upgradeTo
+		if to == "" {
+			releases, err := cns.Releases()
+			if err != nil {
+				return err
+			}
+			to = releases[0].Version
+		}
+
+		var plan *upgrade.Plan
+		if _, err := cns.Lookup(upgradeFrom); err == nil {
+			if plan, err = upgrade.New(upgradeFrom, to); err != nil {
+				return err
+			}
+		} else {
+			if _, statErr := os.Stat(upgradeFrom); statErr != nil {
+				return errors.Join(err, statErr)
+			}
+			in, err := serializers.OpenInput(upgradeFrom)
+			if err != nil {
+				return err
+			}
+			s, err := snapshot.Decode(in)
+			in.Close()
+			if err != nil {
+				return err
+			}
+			name := s.Metadata.Hostname
+			if name == "" {
+				name = upgradeFrom
+			}
+			if plan, err = upgrade.NewForNode(upgrade.Node{Name: name, Configs: s.Items}, to); err != nil {
+				return fmt.Errorf("%s: %w", upgradeFrom, err)
+			}
+		}
+
+		switch upgradeFormat {
+		case "json", "yaml":
+			return serializers.NewWriter(serializers.Format(upgradeFormat), os.Stdout).Serialize(plan)
+		case "text":
+			return plan.WriteText(os.Stdout)
+		default:
+			return fmt.Errorf("unsupported format %q, must be text, json or yaml", upgradeFormat)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+	upgradeCmd.AddCommand(upgradePlanCmd)
+
+	upgradePlanCmd.Flags().StringVar(&upgradeFrom, "from", "",
+		"current release version, e.g. 14.2, or snapshot of a node")
+	upgradePlanCmd.Flags().StringVar(&upgradeTo, "to", "",
+		"release version to upgrade to (default latest)")
+	upgradePlanCmd.Flags().StringVarP(&upgradeFormat, "output", "o", "text",
+		"output format (text, json, yaml)")
+	_ = upgradePlanCmd.MarkFlagRequired("from")
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/values"
+
+	"github.com/spf13/cobra"
+)
+
+var valuesLintFormat string
+
+// valuesCmd represents the values command
+var valuesCmd = &cobra.Command{
+	Use:     "values",
+	GroupID: "core",
+	Short:   "Work with the Ansible values files of the Cloud Native Stack playbooks",
+}
+
+var valuesLintCmd = &cobra.Command{
+	Use:   "lint <file>",
+	Short: "Validate a Cloud Native Stack values file",
+	Long: `Validate a cns_values.yaml of the Cloud Native Stack Ansible playbooks before
+running them, use - to read from stdin. The file is checked for:
+  - unknown keys, with the closest known key, and missing keys
+  - values of the wrong type, e.g. quoted "yes" or unquoted versions read as
+    numbers, the types being those of the values files of the playbooks
+  - unsupported values of container_runtime and mig_strategy
+  - settings requiring other settings, e.g. enable_gds requires
+    use_open_kernel_module
+  - component versions differing from the release matrix (docs/cns.json) for
+    its cns_version
+
+Problems are printed with their line, the command fails if any is an error:
+  eidos values lint cns_values.yaml
+  eidos values lint cns_values.yaml -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		in, err := serializers.OpenInput(args[0])
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(in)
+		in.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+
+		problems, err := values.Lint(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+
+		switch valuesLintFormat {
+		case "json", "yaml":
+			if problems == nil {
+				problems = []values.Problem{}
+			}
+			if err := serializers.NewWriter(serializers.Format(valuesLintFormat), os.Stdout).Serialize(problems); err != nil {
+				return err
+			}
+		case "text":
+			for _, p := range problems {
+				if p.Line > 0 {
+					fmt.Printf("%s:%d: %s\n", args[0], p.Line, p)
+				} else {
+					fmt.Printf("%s: %s\n", args[0], p)
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported format %q, must be text, json or yaml", valuesLintFormat)
+		}
+
+		errors := 0
+		for _, p := range problems {
+			if p.Severity == checks.SeverityError {
+				errors++
+			}
+		}
+		if errors > 0 {
+			return fmt.Errorf("%s: %d errors, %d warnings", args[0], errors, len(problems)-errors)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(valuesCmd)
+	valuesCmd.AddCommand(valuesLintCmd)
+
+	valuesLintCmd.Flags().StringVarP(&valuesLintFormat, "output", "o", "text",
+		"output format (text, json, yaml)")
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+
+	"github.com/spf13/cobra"
+)
+
+var verifyKeyFile string
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:     "verify <snapshot>",
+	GroupID: "core",
+	Short:   "Verify the signature of a snapshot",
+	Long: `Verify that a snapshot signed with 'eidos snapshot --sign-key' was not modified
+since it was taken. The snapshot can be compressed with gzip or zstd. Use - to
+read from stdin.
+
+The command exits with an error if the snapshot is not signed, was signed with
+another key, or was modified.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pub, err := snapshot.LoadPublicKey(verifyKeyFile)
+		if err != nil {
+			return err
+		}
+
+		in, err := serializers.OpenInput(args[0])
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(in)
+		in.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %w", err)
+		}
+
+		s, err := snapshot.Verify(data, pub)
+		if err != nil {
+			return err
+		}
+
+		taken := "at an unknown time"
+		if !s.Metadata.Created.IsZero() {
+			taken = s.Metadata.Created.Format(time.RFC3339)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Verified snapshot of %s taken %s (%d configurations), signed with %s\n",
+			s.Metadata.Hostname, taken, len(s.Items), s.Signature.KeyID)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVar(&verifyKeyFile, "key", "",
+		"PEM encoded ed25519 public key (or private key) to verify with")
+	_ = verifyCmd.MarkFlagRequired("key")
+}
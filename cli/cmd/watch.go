@@ -0,0 +1,136 @@
+/*
+Copyright © 2025 NVIDIA Corporation
+SPDX-License-Identifier: Apache-2.0
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/history"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/watch"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyDir       string
+	watchInterval    time.Duration
+	retentionEntries int
+	retentionAge     time.Duration
+	watchEvents      bool
+	watchDebounce    time.Duration
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:     "watch",
+	GroupID: "core",
+	Short:   "Record configuration changes over time",
+	Long: `Take a snapshot of the node on an interval and record it in a local history
+when the configuration changed since the previous snapshot, together with the
+changes. Volatile fields such as timestamps, PIDs and counters are ignored.
+
+With --events, configuration files and systemd units are watched as well and
+only the collectors affected by a change run again, so changes are recorded
+within seconds. The interval then serves as a periodic full resync, e.g.
+--interval 1h. Watched paths, including their subdirectories, and their
+collectors:
+  /etc/sysctl.conf, /etc/sysctl.d                  sysctl
+  /etc/modprobe.d, /etc/modules-load.d             kmod
+  /etc/default/grub, /etc/default/grub.d           grub
+  /etc/containerd, /etc/fstab                      mounts
+  /etc/systemd/system and --systemd-services       systemd
+  /etc/cdi                                         gpu
+Entries record what triggered them, see 'eidos history list'.
+
+Entries older than --retention-age or beyond --retention-entries are pruned,
+the latest entry is always kept. Use 'eidos history' to browse the history:
+  eidos history list
+  eidos history diff latest~1 latest`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		store, err := openHistory()
+		if err != nil {
+			return err
+		}
+
+		redactor, err := newRedactor()
+		if err != nil {
+			return err
+		}
+
+		factory, err := newCollectorFactory()
+		if err != nil {
+			return err
+		}
+
+		w := &watch.Watcher{
+			Snapshotter: &snapshotter.NodeSnapshotter{
+				Factory:  factory,
+				Logger:   GetLogger(),
+				Redactor: redactor,
+			},
+			Store:    store,
+			Logger:   GetLogger(),
+			Interval: watchInterval,
+			Debounce: watchDebounce,
+		}
+		if watchEvents {
+			w.Sources = []watch.Source{
+				&watch.FileSource{Watches: watch.DefaultFileWatches, HostRoot: hostRoot, Logger: GetLogger()},
+				&watch.UnitSource{Units: systemdServices, Logger: GetLogger()},
+			}
+		}
+		return w.Run(cmd.Context())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	addCollectorFlags(watchCmd)
+	addHistoryFlags(watchCmd)
+
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", watch.DefaultInterval,
+		"time between snapshots")
+	watchCmd.Flags().BoolVar(&watchEvents, "events", false,
+		"also snapshot on configuration file and systemd unit changes")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", watch.DefaultDebounce,
+		"time to collect change events before snapshotting")
+	watchCmd.Flags().IntVar(&retentionEntries, "retention-entries", 1000,
+		"maximum number of history entries to keep, 0 for unlimited")
+	watchCmd.Flags().DurationVar(&retentionAge, "retention-age", 30*24*time.Hour,
+		"maximum age of history entries to keep, 0 for unlimited")
+}
+
+// addHistoryFlags registers the history directory flag on cmd.
+func addHistoryFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&historyDir, "history-dir", defaultHistoryDir(),
+		"directory of the snapshot history")
+}
+
+// defaultHistoryDir returns /var/lib/eidos/history for root and the user's
+// state directory otherwise.
+func defaultHistoryDir() string {
+	if os.Geteuid() == 0 {
+		return "/var/lib/eidos/history"
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, name, "history")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", name, "history")
+	}
+	return filepath.Join("."+name, "history")
+}
+
+// openHistory opens the history store of the --history-dir flag.
+func openHistory() (*history.Store, error) {
+	return history.Open(historyDir, history.Retention{
+		MaxEntries: retentionEntries,
+		MaxAge:     retentionAge,
+	})
+}
@@ -0,0 +1,109 @@
+package checks
+
+import (
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// Status is the outcome of evaluating a rule against a snapshot
+type Status string
+
+const (
+	// StatusPass means the node satisfies the rule
+	StatusPass Status = "pass"
+	// StatusFail means the node violates the rule
+	StatusFail Status = "fail"
+	// StatusSkip means the snapshot doesn't contain the data the rule needs
+	StatusSkip Status = "skip"
+)
+
+// Severity describes how serious a rule violation is
+type Severity string
+
+const (
+	// SeverityError violations prevent a working CNS deployment
+	SeverityError Severity = "error"
+	// SeverityWarning violations are supported but not recommended
+	SeverityWarning Severity = "warning"
+)
+
+// Rule is a single validation performed against a snapshot.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+	Remediation string
+	// Check evaluates the rule and returns its status with a human readable message.
+	Check func(configs []collectors.Configuration) (Status, string)
+}
+
+// Result is the outcome of a single rule.
+type Result struct {
+	Rule        string
+	Description string
+	Severity    Severity
+	Status      Status
+	Message     string
+	Remediation string `json:",omitempty" yaml:",omitempty"`
+}
+
+// Report combines a snapshot with the validation results computed from it.
+// Node is the hostname of the validated node.
+type Report struct {
+	Node           string `json:",omitempty" yaml:",omitempty"`
+	Configurations []collectors.Configuration
+	Results        []Result
+}
+
+// Summary counts results by status.
+type Summary struct {
+	Pass int
+	Fail int
+	Skip int
+}
+
+// Run evaluates the rules against the snapshot. Remediation is only included
+// for failed rules.
+func Run(rules []Rule, configs []collectors.Configuration) []Result {
+	res := make([]Result, 0, len(rules))
+	for _, r := range rules {
+		status, msg := r.Check(configs)
+		result := Result{
+			Rule:        r.ID,
+			Description: r.Description,
+			Severity:    r.Severity,
+			Status:      status,
+			Message:     msg,
+		}
+		if status == StatusFail {
+			result.Remediation = r.Remediation
+		}
+		res = append(res, result)
+	}
+	return res
+}
+
+// Summary counts the report's results by status.
+func (r Report) Summary() Summary {
+	var s Summary
+	for _, res := range r.Results {
+		switch res.Status {
+		case StatusPass:
+			s.Pass++
+		case StatusFail:
+			s.Fail++
+		case StatusSkip:
+			s.Skip++
+		}
+	}
+	return s
+}
+
+// Failed reports whether any rule with error severity failed.
+func (r Report) Failed() bool {
+	for _, res := range r.Results {
+		if res.Status == StatusFail && res.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
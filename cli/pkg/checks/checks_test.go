@@ -0,0 +1,95 @@
+package checks_test
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+func resultsByRule(results []checks.Result) map[string]checks.Result {
+	res := make(map[string]checks.Result, len(results))
+	for _, r := range results {
+		res[r.Rule] = r
+	}
+	return res
+}
+
+func TestRun_DefaultRules(t *testing.T) {
+	configs := []collectors.Configuration{
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "overlay"}},
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+		{Type: collectors.SwapType, Data: collectors.SwapConfig{Source: collectors.SwapSourceProc, Device: "/swap.img"}},
+		{Type: collectors.CgroupType, Data: collectors.CgroupConfig{Key: collectors.CgroupKeyMode, Value: collectors.CgroupModeV2}},
+		{Type: collectors.CgroupType, Data: collectors.CgroupConfig{Key: collectors.CgroupKeyKubeletCgroupDriver, Value: "systemd"}},
+		{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+			Unit:       "containerd.service",
+			Properties: map[string]any{"ActiveState": "active"},
+		}},
+		{Type: collectors.PackageType, Data: collectors.PackageConfig{Name: "kubelet", Held: true}},
+		{Type: collectors.PackageType, Data: collectors.PackageConfig{Name: "kubeadm"}},
+	}
+
+	results := checks.Run(checks.DefaultRules(), configs)
+	if len(results) != len(checks.DefaultRules()) {
+		t.Fatalf("expected %d results, got %d", len(checks.DefaultRules()), len(results))
+	}
+
+	want := map[string]checks.Status{
+		"swap-disabled":            checks.StatusFail,
+		"swap-not-in-fstab":        checks.StatusPass,
+		"cgroup-v2":                checks.StatusPass,
+		"cgroup-driver-systemd":    checks.StatusPass,
+		"kernel-modules":           checks.StatusFail,
+		"nvidia-driver-loaded":     checks.StatusPass,
+		"container-runtime-active": checks.StatusPass,
+		"kubernetes-packages-held": checks.StatusFail,
+	}
+	got := resultsByRule(results)
+	for rule, status := range want {
+		r, ok := got[rule]
+		if !ok {
+			t.Errorf("missing result for %s", rule)
+			continue
+		}
+		if r.Status != status {
+			t.Errorf("%s: expected %s, got %s (%s)", rule, status, r.Status, r.Message)
+		}
+		if (r.Status == checks.StatusFail) != (r.Remediation != "") {
+			t.Errorf("%s: remediation should only be set on failure, got %q", rule, r.Remediation)
+		}
+	}
+
+	if msg := got["kernel-modules"].Message; msg != "modules not loaded: br_netfilter" {
+		t.Errorf("unexpected kernel-modules message: %q", msg)
+	}
+}
+
+func TestRun_SkipsWithoutData(t *testing.T) {
+	for _, r := range checks.Run(checks.DefaultRules(), nil) {
+		if r.Status != checks.StatusSkip {
+			t.Errorf("%s: expected skip on empty snapshot, got %s", r.Rule, r.Status)
+		}
+	}
+}
+
+func TestReport_SummaryAndFailed(t *testing.T) {
+	report := checks.Report{Results: []checks.Result{
+		{Rule: "a", Severity: checks.SeverityError, Status: checks.StatusPass},
+		{Rule: "b", Severity: checks.SeverityWarning, Status: checks.StatusFail},
+		{Rule: "c", Severity: checks.SeverityError, Status: checks.StatusSkip},
+	}}
+
+	s := report.Summary()
+	if s.Pass != 1 || s.Fail != 1 || s.Skip != 1 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if report.Failed() {
+		t.Error("warning failures should not fail the report")
+	}
+
+	report.Results = append(report.Results, checks.Result{Rule: "d", Severity: checks.SeverityError, Status: checks.StatusFail})
+	if !report.Failed() {
+		t.Error("error failures should fail the report")
+	}
+}
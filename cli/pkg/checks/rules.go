@@ -0,0 +1,245 @@
+package checks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// DefaultRules returns the built-in node prerequisite rules for CNS.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			ID:          "swap-disabled",
+			Description: "Swap must be disabled for the kubelet",
+			Severity:    SeverityError,
+			Remediation: "Run 'swapoff -a' and remove swap entries from /etc/fstab",
+			Check:       checkSwapDisabled,
+		},
+		{
+			ID:          "swap-not-in-fstab",
+			Description: "Swap should not be configured to turn on at boot",
+			Severity:    SeverityWarning,
+			Remediation: "Remove or comment out swap entries in /etc/fstab",
+			Check:       checkSwapNotInFstab,
+		},
+		{
+			ID:          "cgroup-v2",
+			Description: "The unified cgroup v2 hierarchy should be used",
+			Severity:    SeverityWarning,
+			Remediation: "Boot with systemd.unified_cgroup_hierarchy=1 or use a distribution defaulting to cgroup v2",
+			Check:       checkCgroupV2,
+		},
+		{
+			ID:          "cgroup-driver-systemd",
+			Description: "The kubelet and containerd must use the systemd cgroup driver",
+			Severity:    SeverityError,
+			Remediation: "Set cgroupDriver: systemd in the kubelet config and SystemdCgroup = true in /etc/containerd/config.toml",
+			Check:       checkCgroupDriver,
+		},
+		{
+			ID:          "kernel-modules",
+			Description: "The overlay and br_netfilter kernel modules must be loaded",
+			Severity:    SeverityError,
+			Remediation: "Run 'modprobe overlay br_netfilter' and add them to /etc/modules-load.d/k8s.conf",
+			Check:       checkKernelModules,
+		},
+		{
+			ID:          "nvidia-driver-loaded",
+			Description: "The NVIDIA kernel driver should be loaded",
+			Severity:    SeverityWarning,
+			Remediation: "Install the NVIDIA data center driver or deploy the GPU Operator with driver.enabled=true",
+			Check:       checkNvidiaDriver,
+		},
+		{
+			ID:          "container-runtime-active",
+			Description: "A container runtime (containerd or CRI-O) must be running",
+			Severity:    SeverityError,
+			Remediation: "Start the runtime with 'systemctl enable --now containerd' (or crio)",
+			Check:       checkContainerRuntime,
+		},
+		{
+			ID:          "kubernetes-packages-held",
+			Description: "Installed Kubernetes packages should be held to prevent unplanned upgrades",
+			Severity:    SeverityWarning,
+			Remediation: "Run 'apt-mark hold kubelet kubeadm kubectl' (or 'dnf versionlock add' on RHEL)",
+			Check:       checkPackagesHeld,
+		},
+	}
+}
+
+// dataOf returns the configuration data of type T.
+func dataOf[T any](configs []collectors.Configuration) []T {
+	var res []T
+	for _, c := range configs {
+		if d, ok := c.Data.(T); ok {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+// cgroupSettings returns the cgroup key/value settings, or nil if the mounts
+// collector didn't run.
+func cgroupSettings(configs []collectors.Configuration) map[string]string {
+	cgroups := dataOf[collectors.CgroupConfig](configs)
+	if len(cgroups) == 0 {
+		return nil
+	}
+	res := make(map[string]string, len(cgroups))
+	for _, c := range cgroups {
+		res[c.Key] = c.Value
+	}
+	return res
+}
+
+func checkSwapDisabled(configs []collectors.Configuration) (Status, string) {
+	if cgroupSettings(configs) == nil {
+		return StatusSkip, "no mount data in snapshot"
+	}
+
+	var active []string
+	for _, s := range dataOf[collectors.SwapConfig](configs) {
+		if s.Source == collectors.SwapSourceProc {
+			active = append(active, s.Device)
+		}
+	}
+	if len(active) > 0 {
+		return StatusFail, fmt.Sprintf("swap is active on %s", strings.Join(active, ", "))
+	}
+	return StatusPass, "no active swap"
+}
+
+func checkSwapNotInFstab(configs []collectors.Configuration) (Status, string) {
+	if cgroupSettings(configs) == nil {
+		return StatusSkip, "no mount data in snapshot"
+	}
+
+	var configured []string
+	for _, s := range dataOf[collectors.SwapConfig](configs) {
+		if s.Source == collectors.SwapSourceFstab {
+			configured = append(configured, s.Device)
+		}
+	}
+	if len(configured) > 0 {
+		return StatusFail, fmt.Sprintf("swap configured in /etc/fstab: %s", strings.Join(configured, ", "))
+	}
+	return StatusPass, "no swap in /etc/fstab"
+}
+
+func checkCgroupV2(configs []collectors.Configuration) (Status, string) {
+	settings := cgroupSettings(configs)
+	if settings == nil {
+		return StatusSkip, "no mount data in snapshot"
+	}
+
+	mode := settings[collectors.CgroupKeyMode]
+	if mode != collectors.CgroupModeV2 {
+		return StatusFail, fmt.Sprintf("cgroup hierarchy is %s", mode)
+	}
+	return StatusPass, "cgroup v2 is in use"
+}
+
+func checkCgroupDriver(configs []collectors.Configuration) (Status, string) {
+	settings := cgroupSettings(configs)
+	kubelet, hasKubelet := settings[collectors.CgroupKeyKubeletCgroupDriver]
+	containerd, hasContainerd := settings[collectors.CgroupKeyContainerdSystemdCgroup]
+	if !hasKubelet && !hasContainerd {
+		return StatusSkip, "no kubelet or containerd configuration found"
+	}
+
+	var problems []string
+	if hasKubelet && kubelet != "systemd" {
+		problems = append(problems, fmt.Sprintf("kubelet cgroupDriver is %q", kubelet))
+	}
+	if hasContainerd && containerd != "true" {
+		problems = append(problems, fmt.Sprintf("containerd SystemdCgroup is %s", containerd))
+	}
+	if len(problems) > 0 {
+		return StatusFail, strings.Join(problems, "; ")
+	}
+	return StatusPass, "systemd cgroup driver is configured"
+}
+
+// loadedModules returns the set of loaded kernel modules, or nil if the kmod
+// collector didn't run.
+func loadedModules(configs []collectors.Configuration) map[string]bool {
+	mods := dataOf[collectors.KModConfig](configs)
+	if len(mods) == 0 {
+		return nil
+	}
+	res := make(map[string]bool, len(mods))
+	for _, m := range mods {
+		res[m.Name] = true
+	}
+	return res
+}
+
+func checkKernelModules(configs []collectors.Configuration) (Status, string) {
+	loaded := loadedModules(configs)
+	if loaded == nil {
+		return StatusSkip, "no kernel module data in snapshot"
+	}
+
+	var missing []string
+	for _, m := range []string{"overlay", "br_netfilter"} {
+		if !loaded[m] {
+			missing = append(missing, m)
+		}
+	}
+	if len(missing) > 0 {
+		return StatusFail, fmt.Sprintf("modules not loaded: %s", strings.Join(missing, ", "))
+	}
+	return StatusPass, "overlay and br_netfilter are loaded"
+}
+
+func checkNvidiaDriver(configs []collectors.Configuration) (Status, string) {
+	loaded := loadedModules(configs)
+	if loaded == nil {
+		return StatusSkip, "no kernel module data in snapshot"
+	}
+	if !loaded["nvidia"] {
+		return StatusFail, "nvidia module not loaded"
+	}
+	return StatusPass, "nvidia module is loaded"
+}
+
+func checkContainerRuntime(configs []collectors.Configuration) (Status, string) {
+	units := dataOf[collectors.SystemDConfig](configs)
+	if len(units) == 0 {
+		return StatusSkip, "no systemd data in snapshot"
+	}
+
+	for _, u := range units {
+		if u.Unit != "containerd.service" && u.Unit != "crio.service" {
+			continue
+		}
+		if u.Properties["ActiveState"] == "active" {
+			return StatusPass, fmt.Sprintf("%s is active", u.Unit)
+		}
+	}
+	return StatusFail, "neither containerd.service nor crio.service is active"
+}
+
+func checkPackagesHeld(configs []collectors.Configuration) (Status, string) {
+	k8s := map[string]bool{"kubelet": true, "kubeadm": true, "kubectl": true}
+
+	var installed, unheld []string
+	for _, p := range dataOf[collectors.PackageConfig](configs) {
+		if !k8s[p.Name] {
+			continue
+		}
+		installed = append(installed, p.Name)
+		if !p.Held {
+			unheld = append(unheld, p.Name)
+		}
+	}
+	if len(installed) == 0 {
+		return StatusSkip, "no Kubernetes packages installed"
+	}
+	if len(unheld) > 0 {
+		return StatusFail, fmt.Sprintf("packages not held: %s", strings.Join(unheld, ", "))
+	}
+	return StatusPass, fmt.Sprintf("%s held", strings.Join(installed, ", "))
+}
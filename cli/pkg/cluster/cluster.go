@@ -0,0 +1,349 @@
+// Package cluster snapshots the nodes of a Kubernetes cluster by running eidos
+// in a short-lived privileged job on each node.
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/k8s"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// NodeLabel is the snapshot label set to the name of the node.
+	NodeLabel = "eidos.nvidia.com/node"
+
+	// RunLabel is the label of the jobs and pods of a cluster snapshot.
+	RunLabel = "eidos.nvidia.com/run"
+
+	// DefaultConcurrency is the number of nodes snapshotted at a time.
+	DefaultConcurrency = 10
+
+	// DefaultTimeout is the time a node snapshot may take, including pulling
+	// the image.
+	DefaultTimeout = 5 * time.Minute
+
+	// DefaultPollInterval is the time between job status checks.
+	DefaultPollInterval = 2 * time.Second
+
+	containerName = "eidos"
+	hostMount     = "/host"
+
+	// jobTTL removes finished jobs left behind when the deletion failed
+	jobTTL = 10 * 60
+
+	cleanupTimeout = 30 * time.Second
+)
+
+// ErrNodeNotReady is the error of nodes skipped because they aren't ready.
+var ErrNodeNotReady = errors.New("node is not ready")
+
+// DefaultArgs are the arguments of eidos in the job. Logs are limited to
+// errors, they are interleaved with the snapshot in the pod logs.
+var DefaultArgs = []string{"snapshot", "--host-root", hostMount, "--output", "json", "--log-level", "error"}
+
+// waitingFailures are the reasons of waiting containers that won't start
+// without intervention.
+var waitingFailures = []string{
+	"ErrImagePull",
+	"ImagePullBackOff",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+}
+
+// Result is the outcome of the snapshot of a node.
+type Result struct {
+	Node     string
+	Snapshot *snapshot.Snapshot
+	Err      error
+}
+
+// Snapshotter snapshots the nodes of a cluster.
+type Snapshotter struct {
+	Client *k8s.Client
+	// Namespace is the namespace of the jobs.
+	Namespace string
+	// Image is the eidos container image.
+	Image string
+	// NodeSelector is the label selector of the nodes, all nodes when empty.
+	NodeSelector string
+	// Concurrency is the number of nodes snapshotted at a time,
+	// DefaultConcurrency when zero.
+	Concurrency int
+	// Timeout is the time a node snapshot may take, DefaultTimeout when zero.
+	Timeout time.Duration
+	// PollInterval is the time between job status checks,
+	// DefaultPollInterval when zero.
+	PollInterval time.Duration
+	// Args are the arguments of eidos in the job, DefaultArgs when nil.
+	Args   []string
+	Logger *slog.Logger
+}
+
+// Run snapshots the selected nodes and returns the results sorted by node
+// name. Failed nodes don't stop the others, their results carry the error.
+// Nodes that aren't ready are skipped with ErrNodeNotReady.
+func (s *Snapshotter) Run(ctx context.Context) ([]Result, error) {
+	if s.Logger == nil {
+		s.Logger = slog.Default()
+	}
+	if s.Image == "" {
+		return nil, errors.New("image is required")
+	}
+
+	nodes, err := s.Client.ListNodes(ctx, s.NodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes match %q", s.NodeSelector)
+	}
+	slices.SortFunc(nodes, func(a, b k8s.Node) int { return strings.Compare(a.Metadata.Name, b.Metadata.Name) })
+
+	run, err := runID()
+	if err != nil {
+		return nil, err
+	}
+	s.Logger.Info("snapshotting cluster nodes",
+		slog.String("run", run),
+		slog.String("namespace", s.namespace()),
+		slog.Int("nodes", len(nodes)))
+
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+
+	results := make([]Result, len(nodes))
+	for i, node := range nodes {
+		name := node.Metadata.Name
+		results[i].Node = name
+		if !node.Ready() {
+			s.Logger.Warn("skipping node", slog.String("node", name), slog.String("reason", ErrNodeNotReady.Error()))
+			results[i].Err = ErrNodeNotReady
+			continue
+		}
+		g.Go(func() error {
+			start := time.Now()
+			snap, err := s.snapshotNode(ctx, fmt.Sprintf("eidos-snapshot-%s-%d", run, i), run, name)
+			if err != nil {
+				s.Logger.Error("failed to snapshot node", slog.String("node", name), slog.String("error", err.Error()))
+			} else {
+				s.Logger.Info("snapshotted node", slog.String("node", name),
+					slog.Int("items", len(snap.Items)), slog.Duration("duration", time.Since(start)))
+			}
+			results[i].Snapshot, results[i].Err = snap, err
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results, ctx.Err()
+}
+
+// snapshotNode runs the job on a node and decodes the snapshot from its logs.
+// The job is deleted when done, also on cancellation.
+func (s *Snapshotter) snapshotNode(ctx context.Context, name, run, node string) (*snapshot.Snapshot, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ns := s.namespace()
+	if _, err := s.Client.CreateJob(ctx, ns, s.job(name, run, node, timeout)); err != nil {
+		return nil, err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+		defer cancel()
+		if err := s.Client.DeleteJob(ctx, ns, name); err != nil && !k8s.IsNotFound(err) {
+			s.Logger.Warn("failed to clean up", slog.String("node", node), slog.String("job", name), slog.String("error", err.Error()))
+		}
+	}()
+
+	pod, failure, err := s.wait(ctx, ns, name)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.Client.PodLogs(ctx, ns, pod, containerName)
+	if err != nil {
+		return nil, err
+	}
+	if failure != nil {
+		msg := failure.Reason
+		if line := lastLine(logs); line != "" {
+			msg += ": " + line
+		}
+		return nil, fmt.Errorf("job %s failed: %s", name, msg)
+	}
+
+	snap, err := snapshotFromLogs(logs)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", name, err)
+	}
+	if snap.Metadata.Labels == nil {
+		snap.Metadata.Labels = make(map[string]string)
+	}
+	snap.Metadata.Labels[NodeLabel] = node
+	return snap, nil
+}
+
+// wait polls the job until it finished and returns its pod, with the failure
+// condition if it failed. Containers that can't start fail early.
+func (s *Snapshotter) wait(ctx context.Context, ns, name string) (string, *k8s.Condition, error) {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	selector := "job-name=" + name
+	for {
+		job, err := s.Client.GetJob(ctx, ns, name)
+		if err != nil {
+			return "", nil, err
+		}
+		done, failure := job.Status.Finished()
+
+		pods, err := s.Client.ListPods(ctx, ns, selector)
+		if err != nil {
+			return "", nil, err
+		}
+		if done {
+			if len(pods) == 0 {
+				return "", nil, fmt.Errorf("job %s finished without a pod", name)
+			}
+			return pods[0].Metadata.Name, failure, nil
+		}
+		for _, p := range pods {
+			for _, cs := range p.Status.ContainerStatuses {
+				if w := cs.State.Waiting; w != nil && slices.Contains(waitingFailures, w.Reason) {
+					return "", nil, fmt.Errorf("job %s: %s: %s", name, w.Reason, w.Message)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", nil, fmt.Errorf("job %s did not finish in time", name)
+			}
+			return "", nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// job returns the job snapshotting a node. It runs a privileged pod on the
+// node, in the host's PID and network namespaces with the host root mounted
+// read-only, tolerating all taints.
+func (s *Snapshotter) job(name, run, node string, timeout time.Duration) *k8s.Job {
+	args := s.Args
+	if args == nil {
+		args = DefaultArgs
+	}
+	labels := map[string]string{
+		"app.kubernetes.io/name":       "eidos",
+		"app.kubernetes.io/managed-by": "eidos",
+		RunLabel:                       run,
+	}
+	annotations := map[string]string{NodeLabel: node}
+
+	return &k8s.Job{
+		Metadata: k8s.ObjectMeta{Name: name, Labels: labels, Annotations: annotations},
+		Spec: k8s.JobSpec{
+			BackoffLimit:            ptr[int32](0),
+			ActiveDeadlineSeconds:   ptr(int64(timeout.Seconds())),
+			TTLSecondsAfterFinished: ptr[int32](jobTTL),
+			Template: k8s.PodTemplateSpec{
+				Metadata: k8s.ObjectMeta{Labels: labels, Annotations: annotations},
+				Spec: k8s.PodSpec{
+					NodeName:                      node,
+					RestartPolicy:                 "Never",
+					HostPID:                       true,
+					HostNetwork:                   true,
+					AutomountServiceAccountToken:  ptr(false),
+					TerminationGracePeriodSeconds: ptr[int64](0),
+					Tolerations:                   []k8s.Toleration{{Operator: "Exists"}},
+					Containers: []k8s.Container{{
+						Name:            containerName,
+						Image:           s.Image,
+						ImagePullPolicy: "IfNotPresent",
+						Args:            args,
+						SecurityContext: &k8s.SecurityContext{Privileged: ptr(true)},
+						VolumeMounts:    []k8s.VolumeMount{{Name: "host", MountPath: hostMount, ReadOnly: true}},
+					}},
+					Volumes: []k8s.Volume{{Name: "host", HostPath: &k8s.HostPathSource{Path: "/"}}},
+				},
+			},
+		},
+	}
+}
+
+func (s *Snapshotter) namespace() string {
+	if s.Namespace != "" {
+		return s.Namespace
+	}
+	return s.Client.Namespace()
+}
+
+// snapshotFromLogs extracts the indented JSON snapshot document from the pod
+// logs, where it may be surrounded by single line JSON log records.
+func snapshotFromLogs(logs []byte) (*snapshot.Snapshot, error) {
+	lines := bytes.Split(logs, []byte("\n"))
+	start, end := -1, -1
+	for i, line := range lines {
+		switch string(bytes.TrimRight(line, "\r")) {
+		case "{":
+			if start < 0 {
+				start = i
+			}
+		case "}":
+			end = i
+		}
+	}
+	if start < 0 || end < start {
+		msg := "no snapshot in pod logs"
+		if line := lastLine(logs); line != "" {
+			msg += ": " + line
+		}
+		return nil, errors.New(msg)
+	}
+	return snapshot.Decode(bytes.NewReader(bytes.Join(lines[start:end+1], []byte("\n"))))
+}
+
+// lastLine returns the last non-empty line of the logs, typically the error.
+func lastLine(logs []byte) string {
+	logs = bytes.TrimSpace(logs)
+	if i := bytes.LastIndexByte(logs, '\n'); i >= 0 {
+		logs = logs[i+1:]
+	}
+	return string(bytes.TrimSpace(logs))
+}
+
+// runID returns a random identifier of a cluster snapshot, used in the job
+// names.
+func runID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate run ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func ptr[T any](v T) *T { return &v }
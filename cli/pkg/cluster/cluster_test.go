@@ -0,0 +1,271 @@
+package cluster_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cluster"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/k8s"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+// fakeAPI is an API server running jobs instantly. The pod of a job on the
+// node named "broken" fails, the others log a snapshot of the node.
+type fakeAPI struct {
+	t     *testing.T
+	nodes []k8s.Node
+
+	mu      sync.Mutex
+	jobs    map[string]*k8s.Job
+	created []k8s.Job
+	deleted []string
+	running int
+	maxRun  int
+}
+
+func newNode(name string, ready bool, labels map[string]string) k8s.Node {
+	var n k8s.Node
+	n.Metadata = k8s.ObjectMeta{Name: name, Labels: labels}
+	status := "False"
+	if ready {
+		status = "True"
+	}
+	n.Status.Conditions = []k8s.Condition{{Type: "Ready", Status: status}}
+	return n
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const jobs = "/apis/batch/v1/namespaces/eidos/jobs"
+	const pods = "/api/v1/namespaces/eidos/pods"
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/nodes":
+		var list k8s.NodeList
+		key, value, _ := strings.Cut(r.URL.Query().Get("labelSelector"), "=")
+		for _, n := range f.nodes {
+			if key == "" || n.Metadata.Labels[key] == value {
+				list.Items = append(list.Items, n)
+			}
+		}
+		writeJSON(w, list)
+
+	case r.Method == http.MethodPost && r.URL.Path == jobs:
+		var job k8s.Job
+		if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.jobs[job.Metadata.Name] = &job
+		f.created = append(f.created, job)
+		f.running++
+		f.maxRun = max(f.maxRun, f.running)
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, job)
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, jobs+"/"):
+		job, ok := f.jobs[strings.TrimPrefix(r.URL.Path, jobs+"/")]
+		if !ok {
+			notFound(w)
+			return
+		}
+		status := *job
+		condition := "Complete"
+		if job.Spec.Template.Spec.NodeName == "broken" {
+			condition = "Failed"
+		}
+		status.Status.Conditions = []k8s.Condition{{Type: condition, Status: "True", Reason: "BackoffLimitExceeded"}}
+		writeJSON(w, status)
+
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, jobs+"/"):
+		name := strings.TrimPrefix(r.URL.Path, jobs+"/")
+		if _, ok := f.jobs[name]; !ok {
+			notFound(w)
+			return
+		}
+		if r.URL.Query().Get("propagationPolicy") != "Background" {
+			f.t.Errorf("delete of %s without background propagation", name)
+		}
+		delete(f.jobs, name)
+		f.deleted = append(f.deleted, name)
+		f.running--
+		writeJSON(w, k8s.Status{})
+
+	case r.Method == http.MethodGet && r.URL.Path == pods:
+		job := strings.TrimPrefix(r.URL.Query().Get("labelSelector"), "job-name=")
+		var list k8s.PodList
+		if _, ok := f.jobs[job]; ok {
+			var pod k8s.Pod
+			pod.Metadata.Name = job + "-x7k2p"
+			list.Items = append(list.Items, pod)
+		}
+		writeJSON(w, list)
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, pods+"/") && strings.HasSuffix(r.URL.Path, "/log"):
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, pods+"/"), "-x7k2p/log")
+		job, ok := f.jobs[name]
+		if !ok {
+			notFound(w)
+			return
+		}
+		node := job.Spec.Template.Spec.NodeName
+		io.WriteString(w, `{"time":"2025-01-01T00:00:00Z","level":"ERROR","msg":"failed to collect"}`+"\n")
+		if node == "broken" {
+			io.WriteString(w, "Error: permission denied\n")
+			return
+		}
+		snap := snapshot.New([]collectors.Configuration{
+			{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "60"}},
+		})
+		snap.Metadata.Hostname = node
+		b, _ := json.MarshalIndent(snap, "", "  ")
+		w.Write(append(b, '\n'))
+
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		notFound(w)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	writeJSON(w, k8s.Status{Code: http.StatusNotFound, Reason: "NotFound", Message: "not found"})
+}
+
+func newSnapshotter(t *testing.T, api *fakeAPI) *cluster.Snapshotter {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	client, err := k8s.NewClient(&k8s.Config{Server: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cluster.Snapshotter{
+		Client:       client,
+		Namespace:    "eidos",
+		Image:        "eidos:test",
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      5 * time.Second,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestSnapshotter_Run(t *testing.T) {
+	gpu := map[string]string{"nvidia.com/gpu.present": "true"}
+	api := &fakeAPI{
+		t: t,
+		nodes: []k8s.Node{
+			newNode("gpu-2", true, gpu),
+			newNode("gpu-1", true, gpu),
+			newNode("gpu-3", false, gpu),
+			newNode("broken", true, gpu),
+			newNode("cpu-1", true, nil),
+		},
+		jobs: make(map[string]*k8s.Job),
+	}
+	s := newSnapshotter(t, api)
+	s.NodeSelector = "nvidia.com/gpu.present=true"
+	s.Concurrency = 1
+
+	results, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var nodes []string
+	for _, r := range results {
+		nodes = append(nodes, r.Node)
+	}
+	if got := strings.Join(nodes, ","); got != "broken,gpu-1,gpu-2,gpu-3" {
+		t.Fatalf("nodes = %s", got)
+	}
+
+	if err := results[0].Err; err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("broken node error = %v", err)
+	}
+	for _, r := range results[1:3] {
+		if r.Err != nil {
+			t.Fatalf("node %s error = %v", r.Node, r.Err)
+		}
+		if r.Snapshot.Metadata.Labels[cluster.NodeLabel] != r.Node || r.Snapshot.Metadata.Hostname != r.Node {
+			t.Errorf("node %s snapshot metadata = %+v", r.Node, r.Snapshot.Metadata)
+		}
+		if len(r.Snapshot.Items) != 1 {
+			t.Errorf("node %s items = %+v", r.Node, r.Snapshot.Items)
+		}
+	}
+	if !errors.Is(results[3].Err, cluster.ErrNodeNotReady) {
+		t.Errorf("not ready node error = %v", results[3].Err)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.jobs) != 0 || len(api.deleted) != 3 {
+		t.Errorf("jobs left = %d, deleted = %v", len(api.jobs), api.deleted)
+	}
+	if api.maxRun != 1 {
+		t.Errorf("%d jobs ran at a time, want 1", api.maxRun)
+	}
+}
+
+func TestSnapshotter_Job(t *testing.T) {
+	api := &fakeAPI{t: t, nodes: []k8s.Node{newNode("gpu-1", true, nil)}, jobs: make(map[string]*k8s.Job)}
+	s := newSnapshotter(t, api)
+
+	if _, err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.created) != 1 {
+		t.Fatalf("created %d jobs, want 1", len(api.created))
+	}
+	job := api.created[0]
+
+	if job.Kind != "Job" || job.APIVersion != "batch/v1" || *job.Spec.BackoffLimit != 0 {
+		t.Errorf("job = %+v", job)
+	}
+	spec := job.Spec.Template.Spec
+	if spec.NodeName != "gpu-1" || spec.RestartPolicy != "Never" || !spec.HostPID || !spec.HostNetwork {
+		t.Errorf("pod spec = %+v", spec)
+	}
+	if len(spec.Tolerations) != 1 || spec.Tolerations[0].Operator != "Exists" {
+		t.Errorf("tolerations = %+v", spec.Tolerations)
+	}
+	c := spec.Containers[0]
+	if c.Image != "eidos:test" || !*c.SecurityContext.Privileged {
+		t.Errorf("container = %+v", c)
+	}
+	if strings.Join(c.Args, " ") != "snapshot --host-root /host --output json --log-level error" {
+		t.Errorf("args = %v", c.Args)
+	}
+	if spec.Volumes[0].HostPath.Path != "/" || c.VolumeMounts[0].MountPath != "/host" || !c.VolumeMounts[0].ReadOnly {
+		t.Errorf("volumes = %+v, mounts = %+v", spec.Volumes, c.VolumeMounts)
+	}
+}
+
+func TestSnapshotter_Run_NoNodes(t *testing.T) {
+	api := &fakeAPI{t: t, jobs: make(map[string]*k8s.Job)}
+	s := newSnapshotter(t, api)
+	s.NodeSelector = "missing=true"
+
+	if _, err := s.Run(context.Background()); err == nil {
+		t.Error("Run() without nodes succeeded")
+	}
+}
@@ -0,0 +1,143 @@
+// Package cns describes the Cloud Native Stack releases of the release matrix,
+// docs/cns.json.
+package cns
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:generate cp ../../../docs/cns.json cns.json
+
+// Matrix is the release matrix, docs/cns.json.
+//
+//go:embed cns.json
+var Matrix []byte
+
+// Component names of the release matrix
+const (
+	ComponentContainerd      = "containerd"
+	ComponentCRIO            = "cri-o"
+	ComponentKubernetes      = "k8s version"
+	ComponentCalico          = "Calico"
+	ComponentFlannel         = "Flannel"
+	ComponentHelm            = "helm version"
+	ComponentGPUOperator     = "NVIDIA GPU Operator"
+	ComponentNetworkOperator = "NVIDIA Network Operator"
+	ComponentDriver          = "NVIDIA DataCenter Driver"
+)
+
+// ServerPlatform is the name of the platform of GPU servers.
+const ServerPlatform = "NVIDIA Certified Server"
+
+// Platform is a platform supported by a release with the versions of its
+// components.
+type Platform struct {
+	Name       string            `json:"name" yaml:"name"`
+	Arch       string            `json:"CPU architecture,omitempty" yaml:"arch,omitempty"`
+	OS         string            `json:"os" yaml:"os"`
+	Components map[string]string `json:"components" yaml:"components"`
+}
+
+// Component returns the version of a component, without the v prefix of some
+// versions, or "" if the platform doesn't have it.
+func (p Platform) Component(name string) string {
+	v := strings.TrimPrefix(p.Components[name], "v")
+	if v == "N/A" {
+		return ""
+	}
+	return v
+}
+
+// OSes returns the operating systems of the platform, e.g. "Ubuntu 22.04 LTS".
+func (p Platform) OSes() []string {
+	var oses []string
+	for _, os := range strings.Split(p.OS, ",") {
+		if os = strings.TrimSpace(os); os != "" {
+			oses = append(oses, os)
+		}
+	}
+	return oses
+}
+
+// Release is a Cloud Native Stack release.
+type Release struct {
+	Version     string     `json:"version" yaml:"version"`
+	ReleaseDate string     `json:"release_date" yaml:"releaseDate"`
+	Platforms   []Platform `json:"platforms" yaml:"platforms"`
+}
+
+// Server returns the platform of GPU servers, nil if the release doesn't
+// support them.
+func (r *Release) Server() *Platform {
+	for i := range r.Platforms {
+		if r.Platforms[i].Name == ServerPlatform {
+			return &r.Platforms[i]
+		}
+	}
+	return nil
+}
+
+// Releases returns the releases of Matrix, newest first.
+func Releases() ([]Release, error) {
+	var m struct {
+		Versions []map[string]Release `json:"versions"`
+	}
+	if err := json.Unmarshal(Matrix, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse release matrix: %w", err)
+	}
+	var releases []Release
+	for _, versions := range m.Versions {
+		for v, r := range versions {
+			r.Version = v
+			releases = append(releases, r)
+		}
+	}
+	slices.SortFunc(releases, func(a, b Release) int { return CompareVersions(b.Version, a.Version) })
+	return releases, nil
+}
+
+// Lookup returns the release of a version, e.g. 16.0.
+func Lookup(version string) (*Release, error) {
+	releases, err := Releases()
+	if err != nil {
+		return nil, err
+	}
+	for i := range releases {
+		if releases[i].Version == version {
+			return &releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown Cloud Native Stack release %q", version)
+}
+
+// CompareVersions compares dotted numeric versions like 1.33.2, missing parts
+// are 0 and parts that aren't numbers are compared as strings. The result is
+// negative if a < b, zero if they're equal and positive if a > b.
+func CompareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		x, y := "0", "0"
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errx := strconv.Atoi(x)
+		ny, erry := strconv.Atoi(y)
+		switch {
+		case errx == nil && erry == nil:
+			if nx != ny {
+				return nx - ny
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
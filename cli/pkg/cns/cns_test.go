@@ -0,0 +1,69 @@
+package cns_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cns"
+)
+
+func TestMatrix(t *testing.T) {
+	b, err := os.ReadFile("../../../docs/cns.json")
+	if err != nil {
+		t.Skipf("release matrix not available: %v", err)
+	}
+	if !bytes.Equal(b, cns.Matrix) {
+		t.Error("cns.json is out of date, run go generate ./pkg/cns")
+	}
+}
+
+func TestReleases(t *testing.T) {
+	releases, err := cns.Releases()
+	if err != nil {
+		t.Fatalf("Releases() error = %v", err)
+	}
+	for i := 1; i < len(releases); i++ {
+		if cns.CompareVersions(releases[i-1].Version, releases[i].Version) <= 0 {
+			t.Errorf("release %s is before %s", releases[i-1].Version, releases[i].Version)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	r, err := cns.Lookup("14.2")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	server := r.Server()
+	if server == nil {
+		t.Fatal("14.2 has no server platform")
+	}
+	if got := server.Component(cns.ComponentCalico); got != "3.30.2" {
+		t.Errorf("Calico = %q", got)
+	}
+	if oses := server.OSes(); len(oses) != 1 || oses[0] != "Ubuntu 22.04 LTS" {
+		t.Errorf("OSes() = %q", oses)
+	}
+
+	if _, err := cns.Lookup("99.0"); err == nil {
+		t.Error("Lookup() of an unknown release succeeded")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.33.2", "1.33.2", 0},
+		{"1.9", "1.10", -1},
+		{"16.0", "15.1", 1},
+		{"1.33", "1.33.0", 0},
+	} {
+		got := cns.CompareVersions(tt.a, tt.b)
+		if (got < 0 && tt.want >= 0) || (got > 0 && tt.want <= 0) || (got == 0 && tt.want != 0) {
+			t.Errorf("CompareVersions(%q, %q) = %d, want sign %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
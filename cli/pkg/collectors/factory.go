@@ -1,5 +1,7 @@
 package collectors
 
+import "log/slog"
+
 // CollectorFactory creates collectors with their dependencies.
 // This interface enables dependency injection for testing.
 type CollectorFactory interface {
@@ -7,11 +9,28 @@ type CollectorFactory interface {
 	CreateSystemDCollector() Collector
 	CreateGrubCollector() Collector
 	CreateSysctlCollector() Collector
+	CreateNetworkCollector() Collector
+	CreatePackageCollector() Collector
+	CreateSecurityCollector() Collector
+	CreateMountCollector() Collector
+	CreateGPUCollector() Collector
 }
 
 // DefaultCollectorFactory creates collectors with production dependencies.
 type DefaultCollectorFactory struct {
 	SystemDServices []string
+	// HostRoot is the host root prefix for the systemd unit file fallback and
+	// the network, package, security, mount and GPU collectors. Empty means "/".
+	// The kmod, grub and sysctl collectors read kernel state from /proc, which
+	// isn't affected by the mount namespace.
+	HostRoot string
+	// IncludeVirtualInterfaces includes interfaces not backed by a device in
+	// the network collector.
+	IncludeVirtualInterfaces bool
+	// Logger is passed to collectors that report non-fatal problems.
+	Logger *slog.Logger
+	// PackageFilter selects the installed packages to report.
+	PackageFilter PackageFilter
 }
 
 // NewDefaultCollectorFactory creates a factory with default settings.
@@ -30,6 +49,8 @@ func (f *DefaultCollectorFactory) CreateKModCollector() Collector {
 func (f *DefaultCollectorFactory) CreateSystemDCollector() Collector {
 	return &SystemDCollector{
 		Services: f.SystemDServices,
+		HostRoot: f.HostRoot,
+		Logger:   f.Logger,
 	}
 }
 
@@ -42,3 +63,40 @@ func (f *DefaultCollectorFactory) CreateGrubCollector() Collector {
 func (f *DefaultCollectorFactory) CreateSysctlCollector() Collector {
 	return &SysctlCollector{}
 }
+
+// CreateNetworkCollector creates a network interface and RDMA collector.
+func (f *DefaultCollectorFactory) CreateNetworkCollector() Collector {
+	return &NetworkCollector{
+		HostRoot:       f.HostRoot,
+		IncludeVirtual: f.IncludeVirtualInterfaces,
+	}
+}
+
+// CreatePackageCollector creates an installed package collector.
+func (f *DefaultCollectorFactory) CreatePackageCollector() Collector {
+	return &PackageCollector{
+		HostRoot: f.HostRoot,
+		Filter:   f.PackageFilter,
+	}
+}
+
+// CreateSecurityCollector creates a security posture collector.
+func (f *DefaultCollectorFactory) CreateSecurityCollector() Collector {
+	return &SecurityCollector{
+		HostRoot: f.HostRoot,
+	}
+}
+
+// CreateMountCollector creates a mounts, swap and cgroup collector.
+func (f *DefaultCollectorFactory) CreateMountCollector() Collector {
+	return &MountCollector{
+		HostRoot: f.HostRoot,
+	}
+}
+
+// CreateGPUCollector creates a GPU settings collector.
+func (f *DefaultCollectorFactory) CreateGPUCollector() Collector {
+	return &GPUCollector{
+		HostRoot: f.HostRoot,
+	}
+}
@@ -82,6 +82,11 @@ func TestDefaultCollectorFactory_AllCollectors(t *testing.T) {
 		factory.CreateSystemDCollector,
 		factory.CreateGrubCollector,
 		factory.CreateSysctlCollector,
+		factory.CreateNetworkCollector,
+		factory.CreatePackageCollector,
+		factory.CreateSecurityCollector,
+		factory.CreateMountCollector,
+		factory.CreateGPUCollector,
 	}
 
 	for i, createFunc := range collectorFuncs {
@@ -0,0 +1,114 @@
+package collectors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GPUCollector collects the GPU settings configured outside of packages: the
+// MIG mode from /proc/driver/nvidia/capabilities and the NVIDIA CDI specs in
+// /etc/cdi and /var/run/cdi
+type GPUCollector struct {
+	// HostRoot is prepended to the paths read by the collector. Empty means "/".
+	HostRoot string
+}
+
+// GPUType is the type identifier for GPU configurations
+const GPUType string = "GPU"
+
+// GPUConfig represents a single GPU setting
+// with its key and value
+type GPUConfig struct {
+	Key   string
+	Value string
+}
+
+// ID returns the setting key, one of the GPUKey constants.
+func (c GPUConfig) ID() string {
+	return c.Key
+}
+
+// GPU keys reported by the GPUCollector
+const (
+	// GPUKeyMIGMode is enabled if MIG is enabled on any GPU, unknown without
+	// the NVIDIA driver.
+	GPUKeyMIGMode = "mig_mode"
+	// GPUKeyCDISpecs lists the NVIDIA CDI spec files, comma separated.
+	GPUKeyCDISpecs = "cdi_specs"
+)
+
+// MIG mode values
+const (
+	GPUMIGEnabled  = "enabled"
+	GPUMIGDisabled = "disabled"
+	GPUMIGUnknown  = "unknown"
+)
+
+// cdiSpecDirs are the directories CDI specs are read from, /etc/cdi holds
+// static specs and /var/run/cdi the ones generated at runtime, e.g. by the GPU
+// Operator.
+var cdiSpecDirs = []string{"/etc/cdi", "/var/run/cdi"}
+
+// Collect reads the GPU settings of the host and returns them
+// as a slice of GPUConfig key/value entries
+func (s *GPUCollector) Collect(ctx context.Context) ([]Configuration, error) {
+	// Check if context is canceled
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return []Configuration{
+		{Type: GPUType, Data: GPUConfig{Key: GPUKeyMIGMode, Value: s.migMode()}},
+		{Type: GPUType, Data: GPUConfig{Key: GPUKeyCDISpecs, Value: strings.Join(s.cdiSpecs(), ",")}},
+	}, nil
+}
+
+func (s *GPUCollector) path(p string) string {
+	return hostPath(s.HostRoot, p)
+}
+
+// migMode reports whether MIG is enabled on any GPU. The driver creates a
+// gpu<N>/mig capability directory for each GPU in MIG mode.
+func (s *GPUCollector) migMode() string {
+	root := s.path("/proc/driver/nvidia/capabilities")
+	if _, err := os.Stat(root); err != nil {
+		return GPUMIGUnknown
+	}
+	migs, _ := filepath.Glob(filepath.Join(root, "gpu*", "mig"))
+	if len(migs) > 0 {
+		return GPUMIGEnabled
+	}
+	return GPUMIGDisabled
+}
+
+// cdiSpecs returns the paths of the CDI specs describing NVIDIA devices,
+// sorted.
+func (s *GPUCollector) cdiSpecs() []string {
+	var specs []string
+	for _, dir := range cdiSpecDirs {
+		entries, err := os.ReadDir(s.path(dir))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			ext := filepath.Ext(e.Name())
+			if e.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			p := filepath.Join(dir, e.Name())
+			b, err := os.ReadFile(s.path(p))
+			if err != nil {
+				continue
+			}
+			// The kind of the spec, e.g. nvidia.com/gpu
+			if strings.Contains(string(b), "nvidia.com/") {
+				specs = append(specs, p)
+			}
+		}
+	}
+	sort.Strings(specs)
+	return specs
+}
@@ -0,0 +1,64 @@
+package collectors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+func collectGPU(t *testing.T, root string) map[string]string {
+	t.Helper()
+	collector := &collectors.GPUCollector{HostRoot: root}
+
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+
+	res := make(map[string]string, len(configs))
+	for _, cfg := range configs {
+		gc, ok := cfg.Data.(collectors.GPUConfig)
+		if !ok || cfg.Type != collectors.GPUType {
+			t.Fatalf("Expected GPUConfig, got %s %T", cfg.Type, cfg.Data)
+		}
+		res[gc.Key] = gc.Value
+	}
+	return res
+}
+
+func TestGPUCollector_MIGAndCDI(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "proc/driver/nvidia/capabilities/gpu0/mig/gi1/access", "DeviceFileMinor: 12\n")
+	writeFile(t, root, "proc/driver/nvidia/capabilities/gpu1/.keep", "")
+	writeFile(t, root, "var/run/cdi/nvidia-container-toolkit.json", `{"cdiVersion":"0.5.0","kind":"nvidia.com/gpu","devices":[]}`)
+	writeFile(t, root, "etc/cdi/nvidia.yaml", "cdiVersion: 0.5.0\nkind: nvidia.com/gpu\n")
+	writeFile(t, root, "etc/cdi/vendor.yaml", "cdiVersion: 0.5.0\nkind: vendor.com/device\n")
+	writeFile(t, root, "etc/cdi/README", "nvidia.com/gpu specs live here\n")
+
+	res := collectGPU(t, root)
+
+	if res[collectors.GPUKeyMIGMode] != collectors.GPUMIGEnabled {
+		t.Errorf("mig_mode = %q, want enabled", res[collectors.GPUKeyMIGMode])
+	}
+	if want := "/etc/cdi/nvidia.yaml,/var/run/cdi/nvidia-container-toolkit.json"; res[collectors.GPUKeyCDISpecs] != want {
+		t.Errorf("cdi_specs = %q, want %q", res[collectors.GPUKeyCDISpecs], want)
+	}
+}
+
+func TestGPUCollector_NoDriver(t *testing.T) {
+	res := collectGPU(t, t.TempDir())
+
+	if res[collectors.GPUKeyMIGMode] != collectors.GPUMIGUnknown {
+		t.Errorf("mig_mode = %q, want unknown", res[collectors.GPUKeyMIGMode])
+	}
+	if res[collectors.GPUKeyCDISpecs] != "" {
+		t.Errorf("cdi_specs = %q, want none", res[collectors.GPUKeyCDISpecs])
+	}
+
+	root := t.TempDir()
+	writeFile(t, root, "proc/driver/nvidia/capabilities/gpu0/.keep", "")
+	if res := collectGPU(t, root); res[collectors.GPUKeyMIGMode] != collectors.GPUMIGDisabled {
+		t.Errorf("mig_mode = %q, want disabled", res[collectors.GPUKeyMIGMode])
+	}
+}
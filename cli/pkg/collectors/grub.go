@@ -23,6 +23,11 @@ type GrubConfig struct {
 	Value string
 }
 
+// ID returns the kernel command line parameter name, e.g. iommu.
+func (c GrubConfig) ID() string {
+	return c.Key
+}
+
 // Collect retrieves the GRUB bootloader parameters from /proc/cmdline
 // and parses them into GrubConfig structures
 func (s *GrubCollector) Collect(ctx context.Context) ([]Configuration, error) {
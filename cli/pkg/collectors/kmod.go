@@ -21,6 +21,11 @@ type KModConfig struct {
 	Name string
 }
 
+// ID returns the module name as listed in /proc/modules.
+func (c KModConfig) ID() string {
+	return c.Name
+}
+
 // Collect retrieves the list of loaded kernel modules from /proc/modules
 // and parses them into KModConfig structures
 func (s *KModCollector) Collect(ctx context.Context) ([]Configuration, error) {
@@ -0,0 +1,377 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MountCollector collects mounted filesystems from /proc/mounts (the mounts of
+// the host's init process with a HostRoot), active and configured swap from
+// /proc/swaps and /etc/fstab, and the cgroup setup
+type MountCollector struct {
+	// HostRoot is prepended to the paths read by the collector. Empty means "/".
+	HostRoot string
+	// IncludeContainerMounts includes the per-pod and per-container mounts
+	// created by the kubelet and container runtimes.
+	IncludeContainerMounts bool
+}
+
+// MountType is the type identifier for mounted filesystem configurations
+const MountType string = "Mount"
+
+// SwapType is the type identifier for swap configurations
+const SwapType string = "Swap"
+
+// CgroupType is the type identifier for cgroup configurations
+const CgroupType string = "Cgroup"
+
+// MountConfig represents a mounted filesystem
+type MountConfig struct {
+	Device     string
+	MountPoint string
+	FSType     string
+	Options    string
+}
+
+// ID returns the mount point.
+func (c MountConfig) ID() string {
+	return c.MountPoint
+}
+
+// Swap sources reported in SwapConfig
+const (
+	SwapSourceProc  = "proc"
+	SwapSourceFstab = "fstab"
+)
+
+// SwapConfig represents an active swap area (from /proc/swaps) or a configured
+// one (from /etc/fstab). Sizes are in KiB and only set for active swap.
+type SwapConfig struct {
+	Source   string
+	Device   string
+	Type     string
+	SizeKB   int
+	UsedKB   int
+	Priority int
+	Options  string
+}
+
+// ID returns "source:device", as the same device can be both active and
+// configured in /etc/fstab.
+func (c SwapConfig) ID() string {
+	return c.Source + ":" + c.Device
+}
+
+// CgroupConfig represents a single cgroup setting
+// with its key and value
+type CgroupConfig struct {
+	Key   string
+	Value string
+}
+
+// ID returns the cgroup key, one of the CgroupKey constants.
+func (c CgroupConfig) ID() string {
+	return c.Key
+}
+
+// Cgroup keys reported by the MountCollector
+const (
+	CgroupKeyMode                    = "mode"
+	CgroupKeyControllers             = "controllers"
+	CgroupKeyKubeletCgroupDriver     = "kubelet_cgroup_driver"
+	CgroupKeyContainerdSystemdCgroup = "containerd_systemd_cgroup"
+)
+
+// Cgroup hierarchy modes
+const (
+	CgroupModeV1     = "v1"
+	CgroupModeV2     = "v2"
+	CgroupModeHybrid = "hybrid"
+)
+
+// containerMountPrefixes are mount point prefixes owned by the kubelet and
+// container runtimes, which change with every pod and container.
+var containerMountPrefixes = []string{
+	"/run/containerd/",
+	"/run/k3s/",
+	"/run/netns/",
+	"/run/docker/",
+	"/var/lib/kubelet/pods/",
+	"/var/lib/kubelet/plugins/",
+	"/var/lib/docker/",
+	"/var/lib/containers/storage/",
+	"/var/run/containerd/",
+}
+
+// Collect parses mounts, swap and cgroup configuration
+// and returns them as a slice of Configuration objects.
+func (s *MountCollector) Collect(ctx context.Context) ([]Configuration, error) {
+	// Check if context is canceled
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]Configuration, 0, 100)
+
+	mounts, err := s.readMounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mounts {
+		if !s.IncludeContainerMounts && isContainerMount(m.MountPoint) {
+			continue
+		}
+		res = append(res, Configuration{
+			Type: MountType,
+			Data: m,
+		})
+	}
+
+	swaps, err := s.readSwaps()
+	if err != nil {
+		return nil, err
+	}
+	for _, sw := range swaps {
+		res = append(res, Configuration{
+			Type: SwapType,
+			Data: sw,
+		})
+	}
+
+	for _, c := range s.readCgroups(mounts) {
+		res = append(res, Configuration{
+			Type: CgroupType,
+			Data: c,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *MountCollector) path(p string) string {
+	return hostPath(s.HostRoot, p)
+}
+
+// readMounts parses /proc/mounts. With a host root, /proc/mounts is the mount
+// namespace of the collector's container, so the mounts of the host's init
+// process are read instead.
+func (s *MountCollector) readMounts() ([]MountConfig, error) {
+	p := "/proc/mounts"
+	if s.HostRoot != "" && s.HostRoot != "/" {
+		p = "/proc/1/mounts"
+	}
+	b, err := os.ReadFile(s.path(p))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mounts: %w", err)
+	}
+
+	res := make([]MountConfig, 0, 50)
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		res = append(res, MountConfig{
+			Device:     unescapeMountField(fields[0]),
+			MountPoint: unescapeMountField(fields[1]),
+			FSType:     fields[2],
+			Options:    fields[3],
+		})
+	}
+	return res, nil
+}
+
+// readSwaps parses active swap from /proc/swaps and configured swap from /etc/fstab.
+// A missing /etc/fstab (e.g. minimal images) is not an error.
+func (s *MountCollector) readSwaps() ([]SwapConfig, error) {
+	res := make([]SwapConfig, 0, 2)
+
+	b, err := os.ReadFile(s.path("/proc/swaps"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read swaps: %w", err)
+	}
+	lines := strings.Split(string(b), "\n")
+	// First line is the header: Filename Type Size Used Priority
+	for _, line := range lines[min(1, len(lines)):] {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		size, _ := strconv.Atoi(fields[2])
+		used, _ := strconv.Atoi(fields[3])
+		prio, _ := strconv.Atoi(fields[4])
+		res = append(res, SwapConfig{
+			Source:   SwapSourceProc,
+			Device:   unescapeMountField(fields[0]),
+			Type:     fields[1],
+			SizeKB:   size,
+			UsedKB:   used,
+			Priority: prio,
+		})
+	}
+
+	fstab, err := os.ReadFile(s.path("/etc/fstab"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return res, nil
+		}
+		return nil, fmt.Errorf("failed to read fstab: %w", err)
+	}
+	for _, line := range strings.Split(string(fstab), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[2] != "swap" {
+			continue
+		}
+		sw := SwapConfig{
+			Source: SwapSourceFstab,
+			Device: unescapeMountField(fields[0]),
+		}
+		if len(fields) > 3 {
+			sw.Options = fields[3]
+		}
+		res = append(res, sw)
+	}
+
+	return res, nil
+}
+
+// readCgroups derives the cgroup hierarchy mode from the cgroup mounts, the
+// enabled controllers, and the cgroup driver configured for the kubelet and containerd.
+func (s *MountCollector) readCgroups(mounts []MountConfig) []CgroupConfig {
+	hasV1, v2Root := false, ""
+	for _, m := range mounts {
+		switch m.FSType {
+		case "cgroup":
+			hasV1 = true
+		case "cgroup2":
+			if v2Root == "" || m.MountPoint == "/sys/fs/cgroup" {
+				v2Root = m.MountPoint
+			}
+		}
+	}
+
+	var mode string
+	switch {
+	case v2Root != "" && hasV1:
+		mode = CgroupModeHybrid
+	case v2Root != "":
+		mode = CgroupModeV2
+	case hasV1:
+		mode = CgroupModeV1
+	default:
+		mode = "unknown"
+	}
+
+	res := []CgroupConfig{{Key: CgroupKeyMode, Value: mode}}
+
+	var controllers []string
+	if mode == CgroupModeV2 {
+		controllers = strings.Fields(readSysfs(s.path(v2Root + "/cgroup.controllers")))
+	} else {
+		controllers = s.v1Controllers()
+	}
+	res = append(res, CgroupConfig{Key: CgroupKeyControllers, Value: strings.Join(controllers, " ")})
+
+	if driver := s.kubeletCgroupDriver(); driver != "" {
+		res = append(res, CgroupConfig{Key: CgroupKeyKubeletCgroupDriver, Value: driver})
+	}
+	if systemd := s.containerdSystemdCgroup(); systemd != "" {
+		res = append(res, CgroupConfig{Key: CgroupKeyContainerdSystemdCgroup, Value: systemd})
+	}
+
+	return res
+}
+
+// v1Controllers returns the enabled controllers listed in /proc/cgroups.
+func (s *MountCollector) v1Controllers() []string {
+	b, err := os.ReadFile(s.path("/proc/cgroups"))
+	if err != nil {
+		return nil
+	}
+
+	var res []string
+	for _, line := range strings.Split(string(b), "\n") {
+		// #subsys_name hierarchy num_cgroups enabled
+		fields := strings.Fields(line)
+		if len(fields) < 4 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[3] == "1" {
+			res = append(res, fields[0])
+		}
+	}
+	return res
+}
+
+// kubeletCgroupDriver reads cgroupDriver from the kubelet configuration file.
+func (s *MountCollector) kubeletCgroupDriver() string {
+	b, err := os.ReadFile(s.path("/var/lib/kubelet/config.yaml"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "cgroupDriver:"); ok {
+			return strings.Trim(strings.TrimSpace(v), `"'`)
+		}
+	}
+	return ""
+}
+
+// containerdSystemdCgroup reads the SystemdCgroup option of the runc runtime
+// from the containerd configuration file.
+func (s *MountCollector) containerdSystemdCgroup() string {
+	b, err := os.ReadFile(s.path("/etc/containerd/config.toml"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, val, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) == "SystemdCgroup" {
+			return strings.TrimSpace(val)
+		}
+	}
+	return ""
+}
+
+// isContainerMount reports whether the mount point belongs to a pod or container.
+func isContainerMount(mountPoint string) bool {
+	for _, p := range containerMountPrefixes {
+		if strings.HasPrefix(mountPoint, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeMountField decodes the octal escapes (\040 for space, \011 for tab,
+// \012 for newline, \134 for backslash) used in /proc/mounts and /proc/swaps.
+func unescapeMountField(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
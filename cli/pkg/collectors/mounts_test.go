@@ -0,0 +1,178 @@
+package collectors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+type mountResult struct {
+	mounts  map[string]collectors.MountConfig
+	swaps   []collectors.SwapConfig
+	cgroups map[string]string
+}
+
+func collectMounts(t *testing.T, root string) mountResult {
+	t.Helper()
+	collector := &collectors.MountCollector{HostRoot: root}
+
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+
+	res := mountResult{
+		mounts:  make(map[string]collectors.MountConfig),
+		cgroups: make(map[string]string),
+	}
+	for _, cfg := range configs {
+		switch data := cfg.Data.(type) {
+		case collectors.MountConfig:
+			res.mounts[data.MountPoint] = data
+		case collectors.SwapConfig:
+			res.swaps = append(res.swaps, data)
+		case collectors.CgroupConfig:
+			res.cgroups[data.Key] = data.Value
+		default:
+			t.Errorf("Unexpected data type %T", cfg.Data)
+		}
+	}
+	return res
+}
+
+func TestMountCollector_CgroupV2(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "proc/1/mounts", `/dev/nvme0n1p2 / ext4 rw,relatime 0 0
+cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate 0 0
+/dev/sdb1 /mnt/my\040data xfs rw,relatime 0 0
+overlay /run/containerd/io.containerd.runtime.v2.task/k8s.io/abc/rootfs overlay rw 0 0
+tmpfs /var/lib/kubelet/pods/123/volumes/kubernetes.io~projected/kube-api-access tmpfs rw 0 0
+`)
+	writeFile(t, root, "proc/swaps", "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n")
+	writeFile(t, root, "etc/fstab", `# /etc/fstab
+UUID=1234 / ext4 defaults 0 1
+#/swap.img none swap sw 0 0
+`)
+	writeFile(t, root, "sys/fs/cgroup/cgroup.controllers", "cpuset cpu io memory hugetlb pids rdma misc\n")
+	writeFile(t, root, "var/lib/kubelet/config.yaml", "apiVersion: kubelet.config.k8s.io/v1beta1\ncgroupDriver: systemd\n")
+	writeFile(t, root, "etc/containerd/config.toml", `version = 2
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+  SystemdCgroup = true
+`)
+
+	res := collectMounts(t, root)
+
+	if len(res.mounts) != 3 {
+		t.Errorf("Expected 3 mounts without container mounts, got %v", res.mounts)
+	}
+	if m, ok := res.mounts["/mnt/my data"]; !ok || m.Device != "/dev/sdb1" || m.FSType != "xfs" {
+		t.Errorf("Expected unescaped mount point, got %v", res.mounts)
+	}
+
+	if len(res.swaps) != 0 {
+		t.Errorf("Expected no swap, got %v", res.swaps)
+	}
+
+	want := map[string]string{
+		collectors.CgroupKeyMode:                    collectors.CgroupModeV2,
+		collectors.CgroupKeyControllers:             "cpuset cpu io memory hugetlb pids rdma misc",
+		collectors.CgroupKeyKubeletCgroupDriver:     "systemd",
+		collectors.CgroupKeyContainerdSystemdCgroup: "true",
+	}
+	for key, val := range want {
+		if res.cgroups[key] != val {
+			t.Errorf("%s = %q, want %q", key, res.cgroups[key], val)
+		}
+	}
+}
+
+func TestMountCollector_CgroupV1WithSwap(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "proc/1/mounts", `/dev/sda1 / ext4 rw 0 0
+tmpfs /sys/fs/cgroup tmpfs ro,mode=755 0 0
+cgroup /sys/fs/cgroup/memory cgroup rw,memory 0 0
+cgroup /sys/fs/cgroup/cpu,cpuacct cgroup rw,cpu,cpuacct 0 0
+`)
+	writeFile(t, root, "proc/swaps", `Filename				Type		Size		Used		Priority
+/swap.img                               file		2097148		1024		-2
+`)
+	writeFile(t, root, "etc/fstab", "/swap.img none swap sw 0 0\n")
+	writeFile(t, root, "proc/cgroups", `#subsys_name	hierarchy	num_cgroups	enabled
+cpu	2	100	1
+memory	3	120	1
+rdma	0	1	0
+`)
+
+	res := collectMounts(t, root)
+
+	if res.cgroups[collectors.CgroupKeyMode] != collectors.CgroupModeV1 {
+		t.Errorf("Expected cgroup v1, got %q", res.cgroups[collectors.CgroupKeyMode])
+	}
+	if res.cgroups[collectors.CgroupKeyControllers] != "cpu memory" {
+		t.Errorf("Unexpected controllers: %q", res.cgroups[collectors.CgroupKeyControllers])
+	}
+	if _, ok := res.cgroups[collectors.CgroupKeyKubeletCgroupDriver]; ok {
+		t.Error("Expected no kubelet cgroup driver without kubelet config")
+	}
+
+	if len(res.swaps) != 2 {
+		t.Fatalf("Expected active and configured swap, got %v", res.swaps)
+	}
+	active := res.swaps[0]
+	if active.Source != collectors.SwapSourceProc || active.Device != "/swap.img" ||
+		active.SizeKB != 2097148 || active.UsedKB != 1024 || active.Priority != -2 {
+		t.Errorf("Unexpected active swap: %+v", active)
+	}
+	if res.swaps[1].Source != collectors.SwapSourceFstab || res.swaps[1].Options != "sw" {
+		t.Errorf("Unexpected fstab swap: %+v", res.swaps[1])
+	}
+}
+
+func TestMountCollector_CgroupHybrid(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "proc/1/mounts", `cgroup2 /sys/fs/cgroup/unified cgroup2 rw 0 0
+cgroup /sys/fs/cgroup/memory cgroup rw,memory 0 0
+`)
+	writeFile(t, root, "proc/swaps", "Filename Type Size Used Priority\n")
+
+	res := collectMounts(t, root)
+
+	if res.cgroups[collectors.CgroupKeyMode] != collectors.CgroupModeHybrid {
+		t.Errorf("Expected hybrid cgroups, got %q", res.cgroups[collectors.CgroupKeyMode])
+	}
+}
+
+func TestMountCollector_HostRootReadsInitMounts(t *testing.T) {
+	root := t.TempDir()
+	// The collector's own mount namespace, which must be ignored
+	writeFile(t, root, "proc/mounts", `overlay / overlay rw 0 0
+`)
+	writeFile(t, root, "proc/1/mounts", `/dev/nvme0n1p2 / ext4 rw,relatime 0 0
+/dev/sdb1 /data xfs rw 0 0
+`)
+	writeFile(t, root, "proc/swaps", "Filename Type Size Used Priority\n")
+
+	res := collectMounts(t, root)
+
+	if m, ok := res.mounts["/"]; !ok || m.FSType != "ext4" {
+		t.Errorf("Expected the host root filesystem, got %v", res.mounts)
+	}
+	if _, ok := res.mounts["/data"]; !ok {
+		t.Errorf("Expected /data from /proc/1/mounts, got %v", res.mounts)
+	}
+}
+
+func TestMountCollector_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	collector := &collectors.MountCollector{}
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Skipf("/proc not available on this system: %v", err)
+	}
+
+	t.Logf("Found %d mount, swap and cgroup entries", len(configs))
+}
@@ -0,0 +1,298 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NetworkCollector collects network interface information from /sys/class/net
+// and RDMA device information from /sys/class/infiniband
+type NetworkCollector struct {
+	// HostRoot is prepended to the sysfs paths read by the collector. Empty means "/".
+	HostRoot string
+	// IncludeVirtual includes interfaces that are not backed by a device
+	// (loopback, bridges, veth pairs, CNI interfaces).
+	IncludeVirtual bool
+}
+
+// NetworkType is the type identifier for network interface configurations
+const NetworkType string = "Network"
+
+// RDMAType is the type identifier for RDMA device configurations
+const RDMAType string = "RDMA"
+
+// Link layer values reported for interfaces and RDMA ports
+const (
+	LinkLayerEthernet   = "Ethernet"
+	LinkLayerInfiniBand = "InfiniBand"
+	LinkLayerLoopback   = "Loopback"
+)
+
+// NetworkInterfaceConfig represents the configuration of a network interface.
+// Speed is in Mb/s and is -1 when the kernel doesn't report it (e.g. link down).
+type NetworkInterfaceConfig struct {
+	Name            string
+	LinkLayer       string
+	Address         string
+	MTU             int
+	Speed           int
+	OperState       string
+	Driver          string
+	FirmwareVersion string
+	PCIAddress      string
+	SRIOVTotalVFs   int
+	SRIOVNumVFs     int
+	RDMADevice      string
+}
+
+// ID returns the interface name, e.g. eth0.
+func (c NetworkInterfaceConfig) ID() string {
+	return c.Name
+}
+
+// RDMADeviceConfig represents the configuration of an RDMA device (HCA)
+type RDMADeviceConfig struct {
+	Name            string
+	FirmwareVersion string
+	NodeGUID        string
+	SysImageGUID    string
+	BoardID         string
+	Driver          string
+	PCIAddress      string
+	Ports           []RDMAPortConfig
+}
+
+// ID returns the RDMA device name, e.g. mlx5_0.
+func (c RDMADeviceConfig) ID() string {
+	return c.Name
+}
+
+// RDMAPortConfig represents the state of a single RDMA device port
+type RDMAPortConfig struct {
+	Port      int
+	LinkLayer string
+	State     string
+	PhysState string
+	Rate      string
+	PortGUID  string
+}
+
+// Collect retrieves network interfaces and RDMA devices from sysfs
+// and parses them into NetworkInterfaceConfig and RDMADeviceConfig structures
+func (s *NetworkCollector) Collect(ctx context.Context) ([]Configuration, error) {
+	// Check if context is canceled
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]Configuration, 0, 20)
+
+	ifaces, err := s.collectInterfaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect network interfaces: %w", err)
+	}
+	res = append(res, ifaces...)
+
+	devices, err := s.collectRDMADevices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect RDMA devices: %w", err)
+	}
+	res = append(res, devices...)
+
+	return res, nil
+}
+
+func (s *NetworkCollector) collectInterfaces(ctx context.Context) ([]Configuration, error) {
+	root := hostPath(s.HostRoot, "/sys/class/net")
+
+	names, err := listDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Configuration, 0, len(names))
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		dir := filepath.Join(root, name)
+		device := filepath.Join(dir, "device")
+		pciAddr := linkBase(device)
+
+		if pciAddr == "" && !s.IncludeVirtual {
+			continue
+		}
+
+		cfg := NetworkInterfaceConfig{
+			Name:          name,
+			LinkLayer:     linkLayerFromType(readSysfs(filepath.Join(dir, "type"))),
+			Address:       readSysfs(filepath.Join(dir, "address")),
+			MTU:           readSysfsInt(filepath.Join(dir, "mtu"), 0),
+			Speed:         readSysfsInt(filepath.Join(dir, "speed"), -1),
+			OperState:     readSysfs(filepath.Join(dir, "operstate")),
+			Driver:        linkBase(filepath.Join(device, "driver")),
+			PCIAddress:    pciAddr,
+			SRIOVTotalVFs: readSysfsInt(filepath.Join(device, "sriov_totalvfs"), 0),
+			SRIOVNumVFs:   readSysfsInt(filepath.Join(device, "sriov_numvfs"), 0),
+		}
+
+		// Mellanox/NVIDIA NICs expose their RDMA device and firmware under the PCI device
+		if ibDevs, err := listDir(filepath.Join(device, "infiniband")); err == nil && len(ibDevs) > 0 {
+			cfg.RDMADevice = ibDevs[0]
+			cfg.FirmwareVersion = readSysfs(filepath.Join(device, "infiniband", ibDevs[0], "fw_ver"))
+		}
+
+		res = append(res, Configuration{
+			Type: NetworkType,
+			Data: cfg,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *NetworkCollector) collectRDMADevices(ctx context.Context) ([]Configuration, error) {
+	root := hostPath(s.HostRoot, "/sys/class/infiniband")
+
+	names, err := listDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Configuration, 0, len(names))
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		dir := filepath.Join(root, name)
+		device := filepath.Join(dir, "device")
+
+		cfg := RDMADeviceConfig{
+			Name:            name,
+			FirmwareVersion: readSysfs(filepath.Join(dir, "fw_ver")),
+			NodeGUID:        readSysfs(filepath.Join(dir, "node_guid")),
+			SysImageGUID:    readSysfs(filepath.Join(dir, "sys_image_guid")),
+			BoardID:         readSysfs(filepath.Join(dir, "board_id")),
+			Driver:          linkBase(filepath.Join(device, "driver")),
+			PCIAddress:      linkBase(device),
+		}
+
+		ports, err := listDir(filepath.Join(dir, "ports"))
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ports {
+			num, err := strconv.Atoi(p)
+			if err != nil {
+				continue
+			}
+			portDir := filepath.Join(dir, "ports", p)
+			cfg.Ports = append(cfg.Ports, RDMAPortConfig{
+				Port:      num,
+				LinkLayer: readSysfs(filepath.Join(portDir, "link_layer")),
+				State:     sysfsState(readSysfs(filepath.Join(portDir, "state"))),
+				PhysState: sysfsState(readSysfs(filepath.Join(portDir, "phys_state"))),
+				Rate:      readSysfs(filepath.Join(portDir, "rate")),
+				PortGUID:  portGUID(readSysfs(filepath.Join(portDir, "gids", "0"))),
+			})
+		}
+		sort.Slice(cfg.Ports, func(i, j int) bool { return cfg.Ports[i].Port < cfg.Ports[j].Port })
+
+		res = append(res, Configuration{
+			Type: RDMAType,
+			Data: cfg,
+		})
+	}
+
+	return res, nil
+}
+
+// listDir returns the sorted entry names of a directory.
+// A missing directory yields no entries rather than an error.
+func listDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// readSysfs returns the trimmed content of a sysfs attribute, or an empty string
+// if the attribute doesn't exist or can't be read.
+func readSysfs(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// readSysfsInt returns a sysfs attribute parsed as an integer, or def if the
+// attribute is missing or not numeric.
+func readSysfsInt(path string, def int) int {
+	v, err := strconv.Atoi(readSysfs(path))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// linkBase returns the base name of a symlink target, e.g. the PCI address for
+// a device link or the driver name for a driver link.
+func linkBase(path string) string {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// linkLayerFromType maps the ARPHRD value in /sys/class/net/<if>/type to a link layer name.
+func linkLayerFromType(t string) string {
+	switch t {
+	case "1":
+		return LinkLayerEthernet
+	case "32":
+		return LinkLayerInfiniBand
+	case "772":
+		return LinkLayerLoopback
+	default:
+		return t
+	}
+}
+
+// sysfsState strips the numeric prefix from IB port states, e.g. "4: ACTIVE" -> "ACTIVE".
+func sysfsState(s string) string {
+	if _, state, ok := strings.Cut(s, ":"); ok {
+		return strings.TrimSpace(state)
+	}
+	return s
+}
+
+// portGUID extracts the port GUID (the interface ID in the lower 64 bits)
+// from the port's default GID, e.g. "fe80:0000:0000:0000:0c42:a103:0004:5678".
+func portGUID(gid string) string {
+	parts := strings.Split(gid, ":")
+	if len(parts) != 8 {
+		return ""
+	}
+	return strings.Join(parts[4:], ":")
+}
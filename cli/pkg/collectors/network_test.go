@@ -0,0 +1,166 @@
+package collectors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// newSysfsFixture builds a minimal sysfs tree with one ConnectX NIC in Ethernet
+// mode, one in InfiniBand mode and a loopback interface.
+func newSysfsFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	pci := "sys/devices/pci0000:00/"
+
+	// Ethernet port with SR-IOV enabled
+	writeFile(t, root, pci+"0000:3b:00.0/sriov_totalvfs", "8\n")
+	writeFile(t, root, pci+"0000:3b:00.0/sriov_numvfs", "4\n")
+	writeFile(t, root, pci+"0000:3b:00.0/infiniband/mlx5_0/fw_ver", "28.39.1002\n")
+	symlink(t, root, "../../../bus/pci/drivers/mlx5_core", pci+"0000:3b:00.0/driver")
+	writeFile(t, root, "sys/class/net/ens1f0/type", "1\n")
+	writeFile(t, root, "sys/class/net/ens1f0/mtu", "9000\n")
+	writeFile(t, root, "sys/class/net/ens1f0/speed", "100000\n")
+	writeFile(t, root, "sys/class/net/ens1f0/operstate", "up\n")
+	writeFile(t, root, "sys/class/net/ens1f0/address", "0c:42:a1:00:00:01\n")
+	symlink(t, root, "../../../../"+pci+"0000:3b:00.0", "sys/class/net/ens1f0/device")
+
+	// InfiniBand port, link down
+	writeFile(t, root, pci+"0000:86:00.0/infiniband/mlx5_1/fw_ver", "20.39.1002\n")
+	symlink(t, root, "../../../bus/pci/drivers/mlx5_core", pci+"0000:86:00.0/driver")
+	writeFile(t, root, "sys/class/net/ibp134s0/type", "32\n")
+	writeFile(t, root, "sys/class/net/ibp134s0/mtu", "4092\n")
+	writeFile(t, root, "sys/class/net/ibp134s0/operstate", "down\n")
+	symlink(t, root, "../../../../"+pci+"0000:86:00.0", "sys/class/net/ibp134s0/device")
+
+	// Loopback has no backing device
+	writeFile(t, root, "sys/class/net/lo/type", "772\n")
+	writeFile(t, root, "sys/class/net/lo/mtu", "65536\n")
+
+	// RDMA device for the InfiniBand port
+	ib := "sys/class/infiniband/mlx5_1/"
+	writeFile(t, root, ib+"fw_ver", "20.39.1002\n")
+	writeFile(t, root, ib+"node_guid", "0c42:a103:0004:5678\n")
+	writeFile(t, root, ib+"sys_image_guid", "0c42:a103:0004:5678\n")
+	writeFile(t, root, ib+"board_id", "MT_0000000223\n")
+	writeFile(t, root, ib+"ports/1/link_layer", "InfiniBand\n")
+	writeFile(t, root, ib+"ports/1/state", "4: ACTIVE\n")
+	writeFile(t, root, ib+"ports/1/phys_state", "5: LinkUp\n")
+	writeFile(t, root, ib+"ports/1/rate", "200 Gb/sec (4X HDR)\n")
+	writeFile(t, root, ib+"ports/1/gids/0", "fe80:0000:0000:0000:0c42:a103:0004:5679\n")
+	symlink(t, root, "../../../../"+pci+"0000:86:00.0", ib+"device")
+
+	return root
+}
+
+func TestNetworkCollector_Collect(t *testing.T) {
+	collector := &collectors.NetworkCollector{
+		HostRoot: newSysfsFixture(t),
+	}
+
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+
+	ifaces := make(map[string]collectors.NetworkInterfaceConfig)
+	var devices []collectors.RDMADeviceConfig
+	for _, cfg := range configs {
+		switch data := cfg.Data.(type) {
+		case collectors.NetworkInterfaceConfig:
+			if cfg.Type != collectors.NetworkType {
+				t.Errorf("Expected type %s, got %s", collectors.NetworkType, cfg.Type)
+			}
+			ifaces[data.Name] = data
+		case collectors.RDMADeviceConfig:
+			if cfg.Type != collectors.RDMAType {
+				t.Errorf("Expected type %s, got %s", collectors.RDMAType, cfg.Type)
+			}
+			devices = append(devices, data)
+		default:
+			t.Errorf("Unexpected data type %T", cfg.Data)
+		}
+	}
+
+	if _, ok := ifaces["lo"]; ok {
+		t.Error("Expected virtual interface lo to be skipped")
+	}
+
+	eth := ifaces["ens1f0"]
+	if eth.LinkLayer != collectors.LinkLayerEthernet || eth.MTU != 9000 || eth.Speed != 100000 {
+		t.Errorf("Unexpected ens1f0 link settings: %+v", eth)
+	}
+	if eth.Driver != "mlx5_core" || eth.PCIAddress != "0000:3b:00.0" {
+		t.Errorf("Unexpected ens1f0 device: %+v", eth)
+	}
+	if eth.SRIOVTotalVFs != 8 || eth.SRIOVNumVFs != 4 {
+		t.Errorf("Unexpected ens1f0 SR-IOV: total=%d num=%d", eth.SRIOVTotalVFs, eth.SRIOVNumVFs)
+	}
+	if eth.RDMADevice != "mlx5_0" || eth.FirmwareVersion != "28.39.1002" {
+		t.Errorf("Unexpected ens1f0 RDMA device: %+v", eth)
+	}
+
+	ib := ifaces["ibp134s0"]
+	if ib.LinkLayer != collectors.LinkLayerInfiniBand || ib.Speed != -1 || ib.OperState != "down" {
+		t.Errorf("Unexpected ibp134s0: %+v", ib)
+	}
+
+	if len(devices) != 1 {
+		t.Fatalf("Expected 1 RDMA device, got %d", len(devices))
+	}
+	dev := devices[0]
+	if dev.Name != "mlx5_1" || dev.PCIAddress != "0000:86:00.0" || dev.Driver != "mlx5_core" {
+		t.Errorf("Unexpected RDMA device: %+v", dev)
+	}
+	if dev.NodeGUID != "0c42:a103:0004:5678" || dev.FirmwareVersion != "20.39.1002" {
+		t.Errorf("Unexpected RDMA device identity: %+v", dev)
+	}
+	if len(dev.Ports) != 1 {
+		t.Fatalf("Expected 1 port, got %d", len(dev.Ports))
+	}
+	port := dev.Ports[0]
+	if port.State != "ACTIVE" || port.PhysState != "LinkUp" || port.LinkLayer != collectors.LinkLayerInfiniBand {
+		t.Errorf("Unexpected port state: %+v", port)
+	}
+	if port.PortGUID != "0c42:a103:0004:5679" {
+		t.Errorf("Unexpected port GUID: %s", port.PortGUID)
+	}
+}
+
+func TestNetworkCollector_IncludeVirtual(t *testing.T) {
+	collector := &collectors.NetworkCollector{
+		HostRoot:       newSysfsFixture(t),
+		IncludeVirtual: true,
+	}
+
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+
+	for _, cfg := range configs {
+		if iface, ok := cfg.Data.(collectors.NetworkInterfaceConfig); ok && iface.Name == "lo" {
+			if iface.LinkLayer != collectors.LinkLayerLoopback {
+				t.Errorf("Expected Loopback link layer, got %s", iface.LinkLayer)
+			}
+			return
+		}
+	}
+	t.Error("Expected lo to be included")
+}
+
+func TestNetworkCollector_MissingSysfs(t *testing.T) {
+	collector := &collectors.NetworkCollector{
+		HostRoot: t.TempDir(),
+	}
+
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+	if len(configs) != 0 {
+		t.Errorf("Expected no configs, got %d", len(configs))
+	}
+}
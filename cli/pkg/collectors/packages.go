@@ -0,0 +1,320 @@
+package collectors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// PackageCollector collects installed packages from the dpkg status database
+// on Debian/Ubuntu and from the RPM database on RHEL, including hold state,
+// and the containerd, runc, CNI plugins and helm binaries CNS installs from
+// release tarballs.
+type PackageCollector struct {
+	// HostRoot is the host root prefix for the package databases. Empty means "/".
+	HostRoot string
+	// Filter selects the packages to report. A zero Filter reports DefaultPackageNames.
+	Filter PackageFilter
+	// RPMCommand is the rpm binary used to query the RPM database. Defaults to "rpm".
+	RPMCommand string
+}
+
+// PackageType is the type identifier for installed package configurations
+const PackageType string = "Package"
+
+// Package managers reported in PackageConfig
+const (
+	PackageManagerDpkg = "dpkg"
+	PackageManagerRPM  = "rpm"
+	// PackageManagerBinary marks binaries installed from release tarballs,
+	// their version is read from the Go build information.
+	PackageManagerBinary = "binary"
+)
+
+// DefaultPackageNames are the package name patterns reported when no name filter
+// is set: the Kubernetes, container runtime and NVIDIA packages installed by CNS.
+var DefaultPackageNames = []string{
+	"kubelet", "kubeadm", "kubectl", "kubernetes-cni", "cri-tools",
+	"containerd*", "cri-o*", "cri-dockerd", "runc", "cni-plugins", "docker-ce*", "helm",
+	"nvidia-*", "libnvidia-*", "kmod-nvidia-*", "cuda-*", "datacenter-gpu-manager*",
+	"mlnx-*", "doca-*",
+}
+
+// PackageFilter selects packages by shell-style patterns (see path.Match).
+// Empty fields match everything, except Names which defaults to DefaultPackageNames.
+type PackageFilter struct {
+	Names   []string
+	Version string
+	Arch    string
+}
+
+// PackageConfig represents an installed package
+type PackageConfig struct {
+	Name    string
+	Version string
+	Arch    string
+	Manager string
+	Held    bool
+}
+
+// ID returns "name:arch", as multiarch systems can have a package installed
+// for several architectures.
+func (c PackageConfig) ID() string {
+	return c.Name + ":" + c.Arch
+}
+
+// Collect retrieves installed packages matching the filter from the dpkg
+// and RPM databases and parses them into PackageConfig structures
+func (s *PackageCollector) Collect(ctx context.Context) ([]Configuration, error) {
+	// Check if context is canceled
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]Configuration, 0, 50)
+
+	dpkg, err := s.collectDpkg()
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect dpkg packages: %w", err)
+	}
+
+	rpm, err := s.collectRPM(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rpm packages: %w", err)
+	}
+
+	binaries := s.collectBinaries()
+
+	for _, p := range append(append(dpkg, rpm...), binaries...) {
+		if !s.Filter.Match(p) {
+			continue
+		}
+		res = append(res, Configuration{
+			Type: PackageType,
+			Data: p,
+		})
+	}
+
+	return res, nil
+}
+
+// Validate checks that the patterns of the filter are well-formed.
+func (f PackageFilter) Validate() error {
+	for _, n := range f.Names {
+		if _, err := path.Match(n, ""); err != nil {
+			return fmt.Errorf("invalid package name pattern %q: %w", n, err)
+		}
+	}
+	if _, err := path.Match(f.Version, ""); err != nil {
+		return fmt.Errorf("invalid package version pattern %q: %w", f.Version, err)
+	}
+	if _, err := path.Match(f.Arch, ""); err != nil {
+		return fmt.Errorf("invalid package architecture pattern %q: %w", f.Arch, err)
+	}
+	return nil
+}
+
+// Match reports whether the package is selected by the filter. Malformed
+// patterns match nothing, see Validate.
+func (f PackageFilter) Match(p PackageConfig) bool {
+	names := f.Names
+	if len(names) == 0 {
+		names = DefaultPackageNames
+	}
+
+	nameMatch := false
+	for _, n := range names {
+		if ok, _ := path.Match(n, p.Name); ok {
+			nameMatch = true
+			break
+		}
+	}
+	if !nameMatch {
+		return false
+	}
+
+	if f.Version != "" {
+		if ok, _ := path.Match(f.Version, p.Version); !ok {
+			return false
+		}
+	}
+	if f.Arch != "" {
+		if ok, _ := path.Match(f.Arch, p.Arch); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// collectDpkg parses /var/lib/dpkg/status. A missing database yields no packages.
+func (s *PackageCollector) collectDpkg() ([]PackageConfig, error) {
+	f, err := os.Open(hostPath(s.HostRoot, "/var/lib/dpkg/status"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open dpkg status: %w", err)
+	}
+	defer f.Close()
+
+	return parseDpkgStatus(f)
+}
+
+// parseDpkgStatus parses dpkg status paragraphs and returns installed packages.
+// The want field of the Status line ("hold ok installed") carries the hold state.
+func parseDpkgStatus(r io.Reader) ([]PackageConfig, error) {
+	res := make([]PackageConfig, 0, 1000)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var cur PackageConfig
+	var status []string
+	flush := func() {
+		if cur.Name != "" && len(status) == 3 && status[2] == "installed" {
+			cur.Manager = PackageManagerDpkg
+			cur.Held = status[0] == "hold"
+			res = append(res, cur)
+		}
+		cur = PackageConfig{}
+		status = nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		// Continuation lines of multi-line fields (Description, Conffiles)
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "Package":
+			cur.Name = val
+		case "Version":
+			cur.Version = val
+		case "Architecture":
+			cur.Arch = val
+		case "Status":
+			status = strings.Fields(val)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse dpkg status: %w", err)
+	}
+	flush()
+
+	return res, nil
+}
+
+// collectRPM queries the RPM database with the rpm binary. Hosts without an RPM
+// database or without the rpm binary yield no packages.
+func (s *PackageCollector) collectRPM(ctx context.Context) ([]PackageConfig, error) {
+	dbPath := hostPath(s.HostRoot, "/var/lib/rpm")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, nil
+	}
+
+	bin := s.RPMCommand
+	if bin == "" {
+		bin = "rpm"
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		return nil, nil
+	}
+
+	// Only print the epoch when it's set, matching how rpm displays versions
+	args := []string{"-qa", "--queryformat", "%{NAME}\\t%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\t%{ARCH}\\n"}
+	if s.HostRoot != "" && s.HostRoot != "/" {
+		args = append(args, "--root", s.HostRoot)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rpm database: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	locks := s.readVersionLocks()
+
+	res := make([]PackageConfig, 0, 1000)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+
+		res = append(res, PackageConfig{
+			Name:    fields[0],
+			Version: fields[1],
+			Arch:    fields[2],
+			Manager: PackageManagerRPM,
+			Held:    isVersionLocked(locks, fields[0]),
+		})
+	}
+
+	return res, nil
+}
+
+// readVersionLocks reads the dnf/yum versionlock plugin lists, which is how
+// packages are held on RHEL.
+func (s *PackageCollector) readVersionLocks() []string {
+	files := []string{
+		"/etc/dnf/plugins/versionlock.list",
+		"/etc/yum/pluginconf.d/versionlock.list",
+	}
+
+	var locks []string
+	for _, f := range files {
+		b, err := os.ReadFile(hostPath(s.HostRoot, f))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			// Excluded versions are prefixed with "!" and don't hold the package
+			if strings.HasPrefix(line, "!") {
+				continue
+			}
+			locks = append(locks, line)
+		}
+	}
+	return locks
+}
+
+// isVersionLocked reports whether a versionlock entry ("name-[epoch:]version-release.arch")
+// refers to the named package.
+func isVersionLocked(locks []string, name string) bool {
+	for _, l := range locks {
+		rest, ok := strings.CutPrefix(l, name+"-")
+		if !ok || rest == "" {
+			continue
+		}
+		// The remainder must start with the version (or epoch), not another name segment
+		if (rest[0] >= '0' && rest[0] <= '9') || rest[0] == '*' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package collectors
+
+import (
+	"debug/buildinfo"
+	"strings"
+)
+
+// releaseBinaries are the components CNS installs from upstream release
+// tarballs instead of packages, with the paths of their binaries. The first
+// existing path is read.
+var releaseBinaries = []struct {
+	name  string
+	paths []string
+}{
+	{"containerd", []string{"/usr/local/bin/containerd"}},
+	{"runc", []string{"/usr/local/sbin/runc"}},
+	{"cni-plugins", []string{"/opt/cni/bin/bridge", "/opt/cni/bin/loopback"}},
+	{"helm", []string{"/usr/local/bin/helm"}},
+}
+
+// collectBinaries returns the release binaries installed on the host with
+// the version they were built with. Binaries that are missing or don't carry
+// a version are skipped.
+func (s *PackageCollector) collectBinaries() []PackageConfig {
+	var res []PackageConfig
+	for _, b := range releaseBinaries {
+		for _, p := range b.paths {
+			info, err := buildinfo.ReadFile(hostPath(s.HostRoot, p))
+			if err != nil {
+				continue
+			}
+			if version := binaryVersion(info); version != "" {
+				res = append(res, PackageConfig{
+					Name:    b.name,
+					Version: version,
+					Arch:    buildSetting(info, "GOARCH"),
+					Manager: PackageManagerBinary,
+				})
+			}
+			break
+		}
+	}
+	return res
+}
+
+// binaryVersion returns the version of a Go binary without the "v" prefix.
+// Release builds set it with -ldflags "-X <package>.version=<version>", the
+// variable is Version in containerd and BuildVersion in the CNI plugins. The
+// version of the main module is used when the flags aren't recorded, e.g. in
+// binaries built with -trimpath.
+func binaryVersion(info *buildinfo.BuildInfo) string {
+	flags := strings.Fields(buildSetting(info, "-ldflags"))
+	for i, f := range flags {
+		var def string
+		switch {
+		case f == "-X" && i+1 < len(flags):
+			def = flags[i+1]
+		case strings.HasPrefix(f, "-X="):
+			def = strings.TrimPrefix(f, "-X=")
+		default:
+			continue
+		}
+		name, value, ok := strings.Cut(strings.Trim(def, `"'`), "=")
+		if !ok || value == "" {
+			continue
+		}
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if strings.EqualFold(name, "version") || name == "BuildVersion" {
+			return strings.TrimPrefix(value, "v")
+		}
+	}
+
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return strings.TrimPrefix(v, "v")
+	}
+	return ""
+}
+
+// buildSetting returns the value of a build setting of a Go binary.
+func buildSetting(info *buildinfo.BuildInfo, key string) string {
+	for _, s := range info.Settings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
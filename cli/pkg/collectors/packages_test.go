@@ -0,0 +1,225 @@
+package collectors_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+const dpkgStatusFixture = `Package: kubelet
+Status: hold ok installed
+Priority: optional
+Architecture: amd64
+Version: 1.33.2-1.1
+Description: Node agent for Kubernetes clusters
+ The node agent of Kubernetes, the container cluster manager.
+
+Package: kubectl
+Status: install ok installed
+Architecture: amd64
+Version: 1.33.2-1.1
+
+Package: nvidia-driver-580-open
+Status: hold ok installed
+Architecture: amd64
+Version: 580.82.07-0ubuntu1
+
+Package: containerd
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.7.12-0ubuntu4
+
+Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.2.21-2ubuntu4
+`
+
+func collectPackages(t *testing.T, collector *collectors.PackageCollector) map[string]collectors.PackageConfig {
+	t.Helper()
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+
+	res := make(map[string]collectors.PackageConfig, len(configs))
+	for _, cfg := range configs {
+		if cfg.Type != collectors.PackageType {
+			t.Errorf("Expected type %s, got %s", collectors.PackageType, cfg.Type)
+		}
+		p, ok := cfg.Data.(collectors.PackageConfig)
+		if !ok {
+			t.Fatalf("Expected PackageConfig, got %T", cfg.Data)
+		}
+		res[p.Name] = p
+	}
+	return res
+}
+
+func TestPackageCollector_Dpkg(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "var/lib/dpkg/status", dpkgStatusFixture)
+
+	pkgs := collectPackages(t, &collectors.PackageCollector{HostRoot: root})
+
+	// bash isn't in the default filter, containerd is only config-files
+	if len(pkgs) != 3 {
+		t.Errorf("Expected 3 packages, got %d: %v", len(pkgs), pkgs)
+	}
+
+	kubelet := pkgs["kubelet"]
+	if !kubelet.Held || kubelet.Version != "1.33.2-1.1" || kubelet.Arch != "amd64" {
+		t.Errorf("Unexpected kubelet: %+v", kubelet)
+	}
+	if kubelet.Manager != collectors.PackageManagerDpkg {
+		t.Errorf("Expected manager %s, got %s", collectors.PackageManagerDpkg, kubelet.Manager)
+	}
+	if pkgs["kubectl"].Held {
+		t.Error("Expected kubectl not to be held")
+	}
+	if !pkgs["nvidia-driver-580-open"].Held {
+		t.Error("Expected nvidia driver to be held")
+	}
+}
+
+func TestPackageCollector_Filter(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "var/lib/dpkg/status", dpkgStatusFixture)
+
+	tests := []struct {
+		name   string
+		filter collectors.PackageFilter
+		want   []string
+	}{
+		{"all", collectors.PackageFilter{Names: []string{"*"}}, []string{"kubelet", "kubectl", "nvidia-driver-580-open", "bash"}},
+		{"name", collectors.PackageFilter{Names: []string{"kube*"}}, []string{"kubelet", "kubectl"}},
+		{"version", collectors.PackageFilter{Version: "580.*"}, []string{"nvidia-driver-580-open"}},
+		{"arch", collectors.PackageFilter{Arch: "arm64"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs := collectPackages(t, &collectors.PackageCollector{HostRoot: root, Filter: tt.filter})
+			if len(pkgs) != len(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, pkgs)
+			}
+			for _, n := range tt.want {
+				if _, ok := pkgs[n]; !ok {
+					t.Errorf("Expected package %s", n)
+				}
+			}
+		})
+	}
+}
+
+func TestPackageFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  collectors.PackageFilter
+		wantErr bool
+	}{
+		{"default", collectors.PackageFilter{}, false},
+		{"globs", collectors.PackageFilter{Names: []string{"kube*", "nvidia-driver-[0-9]*"}, Version: "580.*", Arch: "amd64"}, false},
+		{"name", collectors.PackageFilter{Names: []string{"kube*", "nvidia-["}}, true},
+		{"version", collectors.PackageFilter{Version: "580.\\"}, true},
+		{"arch", collectors.PackageFilter{Arch: "[amd64"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, path.ErrBadPattern) {
+				t.Errorf("Expected path.ErrBadPattern, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPackageCollector_RPM(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "var/lib/rpm/rpmdb.sqlite", "")
+	writeFile(t, root, "etc/dnf/plugins/versionlock.list", `# Added locks
+kubelet-0:1.33.2-150500.1.1.*
+!kubectl-0:1.32.0-150500.1.1.*
+`)
+
+	// Fake rpm binary printing the query format output
+	rpm := filepath.Join(t.TempDir(), "rpm")
+	writeFile(t, filepath.Dir(rpm), "rpm", "#!/bin/sh\nprintf 'kubelet\\t1.33.2-150500.1.1\\tx86_64\\nkubectl\\t1.33.2-150500.1.1\\tx86_64\\nkubelet-devel\\t1.0-1\\tx86_64\\n'\n")
+	if err := os.Chmod(rpm, 0o755); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+
+	pkgs := collectPackages(t, &collectors.PackageCollector{
+		HostRoot:   root,
+		RPMCommand: rpm,
+		Filter:     collectors.PackageFilter{Names: []string{"kube*"}},
+	})
+
+	if len(pkgs) != 3 {
+		t.Fatalf("Expected 3 packages, got %v", pkgs)
+	}
+	if p := pkgs["kubelet"]; !p.Held || p.Manager != collectors.PackageManagerRPM || p.Arch != "x86_64" {
+		t.Errorf("Unexpected kubelet: %+v", p)
+	}
+	if pkgs["kubectl"].Held {
+		t.Error("Expected excluded kubectl version not to hold the package")
+	}
+	if pkgs["kubelet-devel"].Held {
+		t.Error("Expected kubelet lock not to hold kubelet-devel")
+	}
+}
+
+func TestPackageCollector_ReleaseBinaries(t *testing.T) {
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+	root := t.TempDir()
+
+	// runc sets its version with -ldflags "-X main.version=..."
+	src := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(src, []byte("package main\n\nvar version string\n\nfunc main() { println(version) }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runc := filepath.Join(root, "usr/local/sbin/runc")
+	cmd := exec.Command(gobin, "build", "-o", runc, "-ldflags", "-s -w -X main.gitCommit=abc -X main.version=1.2.6", src)
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off", "GO111MODULE=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build failed: %v: %s", err, out)
+	}
+
+	// A binary without a version is skipped
+	self, err := os.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "usr/local/bin/helm", string(self))
+
+	got := collectPackages(t, &collectors.PackageCollector{HostRoot: root})
+
+	r, ok := got["runc"]
+	if !ok || r.Version != "1.2.6" || r.Manager != collectors.PackageManagerBinary || r.Arch != runtime.GOARCH {
+		t.Errorf("runc = %+v, want version 1.2.6 from the release binary", r)
+	}
+	if h, ok := got["helm"]; ok {
+		t.Errorf("expected binary without a version to be skipped, got %+v", h)
+	}
+}
+
+func TestPackageCollector_NoDatabase(t *testing.T) {
+	pkgs := collectPackages(t, &collectors.PackageCollector{HostRoot: t.TempDir()})
+	if len(pkgs) != 0 {
+		t.Errorf("Expected no packages, got %v", pkgs)
+	}
+}
@@ -0,0 +1,205 @@
+package collectors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SecurityCollector collects host security posture from sysfs and securityfs:
+// Secure Boot, kernel lockdown, module signature enforcement, SELinux, AppArmor and IOMMU
+type SecurityCollector struct {
+	// HostRoot is prepended to the paths read by the collector. Empty means "/".
+	HostRoot string
+}
+
+// SecurityType is the type identifier for security posture configurations
+const SecurityType string = "Security"
+
+// SecurityConfig represents a single security posture setting
+// with its key and value
+type SecurityConfig struct {
+	Key   string
+	Value string
+}
+
+// ID returns the posture key, one of the SecurityKey constants.
+func (c SecurityConfig) ID() string {
+	return c.Key
+}
+
+// Security posture keys reported by the SecurityCollector
+const (
+	SecurityKeySecureBoot         = "secure_boot"
+	SecurityKeySetupMode          = "secure_boot_setup_mode"
+	SecurityKeyLockdown           = "lockdown"
+	SecurityKeyModuleSigEnforce   = "module_sig_enforce"
+	SecurityKeySELinux            = "selinux"
+	SecurityKeyAppArmor           = "apparmor"
+	SecurityKeyAppArmorEnforce    = "apparmor_profiles_enforce"
+	SecurityKeyAppArmorComplain   = "apparmor_profiles_complain"
+	SecurityKeyIOMMU              = "iommu"
+	SecurityKeyIOMMUDefaultDomain = "iommu_default_domain"
+	SecurityKeyIOMMUGroups        = "iommu_groups"
+)
+
+// Common security posture values
+const (
+	SecurityEnabled     = "enabled"
+	SecurityDisabled    = "disabled"
+	SecurityUnsupported = "unsupported"
+	SecurityUnknown     = "unknown"
+)
+
+// efiGlobalVariableGUID is the vendor GUID of the UEFI global variables
+const efiGlobalVariableGUID = "8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// Collect reads the security posture of the host and returns it
+// as a slice of SecurityConfig key/value entries
+func (s *SecurityCollector) Collect(ctx context.Context) ([]Configuration, error) {
+	// Check if context is canceled
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]Configuration, 0, 11)
+	add := func(key, val string) {
+		res = append(res, Configuration{
+			Type: SecurityType,
+			Data: SecurityConfig{
+				Key:   key,
+				Value: val,
+			},
+		})
+	}
+
+	add(SecurityKeySecureBoot, s.efiBool("SecureBoot"))
+	add(SecurityKeySetupMode, s.efiBool("SetupMode"))
+	add(SecurityKeyLockdown, s.lockdown())
+	add(SecurityKeyModuleSigEnforce, s.sysfsBool("/sys/module/module/parameters/sig_enforce"))
+	add(SecurityKeySELinux, s.selinux())
+
+	apparmor := s.sysfsBool("/sys/module/apparmor/parameters/enabled")
+	add(SecurityKeyAppArmor, apparmor)
+	if apparmor == SecurityEnabled {
+		if enforce, complain, ok := s.appArmorProfiles(); ok {
+			add(SecurityKeyAppArmorEnforce, strconv.Itoa(enforce))
+			add(SecurityKeyAppArmorComplain, strconv.Itoa(complain))
+		}
+	}
+
+	groups, domain := s.iommuGroups()
+	iommu := SecurityDisabled
+	if groups > 0 {
+		iommu = SecurityEnabled
+	}
+	add(SecurityKeyIOMMU, iommu)
+	add(SecurityKeyIOMMUGroups, strconv.Itoa(groups))
+	if domain != "" {
+		add(SecurityKeyIOMMUDefaultDomain, domain)
+	}
+
+	return res, nil
+}
+
+func (s *SecurityCollector) path(p string) string {
+	return hostPath(s.HostRoot, p)
+}
+
+// efiBool reads a boolean UEFI global variable from efivarfs. The first four bytes
+// of an efivarfs file are the variable attributes, followed by the value.
+func (s *SecurityCollector) efiBool(name string) string {
+	if _, err := os.Stat(s.path("/sys/firmware/efi")); err != nil {
+		// Legacy BIOS boot
+		return SecurityUnsupported
+	}
+
+	b, err := os.ReadFile(s.path(filepath.Join("/sys/firmware/efi/efivars", name+"-"+efiGlobalVariableGUID)))
+	if err != nil || len(b) < 5 {
+		return SecurityUnknown
+	}
+	if b[4] == 1 {
+		return SecurityEnabled
+	}
+	return SecurityDisabled
+}
+
+// lockdown returns the active kernel lockdown mode, which securityfs marks with
+// brackets, e.g. "none [integrity] confidentiality".
+func (s *SecurityCollector) lockdown() string {
+	v := readSysfs(s.path("/sys/kernel/security/lockdown"))
+	if v == "" {
+		return SecurityUnsupported
+	}
+	for _, f := range strings.Fields(v) {
+		if strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") {
+			return strings.Trim(f, "[]")
+		}
+	}
+	return SecurityUnknown
+}
+
+// sysfsBool maps a Y/N (or 1/0) module parameter to enabled/disabled.
+func (s *SecurityCollector) sysfsBool(p string) string {
+	switch readSysfs(s.path(p)) {
+	case "Y", "1":
+		return SecurityEnabled
+	case "N", "0":
+		return SecurityDisabled
+	case "":
+		return SecurityUnsupported
+	default:
+		return SecurityUnknown
+	}
+}
+
+// selinux returns the SELinux mode from selinuxfs.
+func (s *SecurityCollector) selinux() string {
+	switch readSysfs(s.path("/sys/fs/selinux/enforce")) {
+	case "1":
+		return "enforcing"
+	case "0":
+		return "permissive"
+	default:
+		return SecurityDisabled
+	}
+}
+
+// appArmorProfiles counts loaded AppArmor profiles by mode. Reading the profile
+// list requires root, ok is false when it can't be read.
+func (s *SecurityCollector) appArmorProfiles() (enforce, complain int, ok bool) {
+	b, err := os.ReadFile(s.path("/sys/kernel/security/apparmor/profiles"))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		switch {
+		case strings.HasSuffix(line, "(enforce)"):
+			enforce++
+		case strings.HasSuffix(line, "(complain)"):
+			complain++
+		}
+	}
+	return enforce, complain, true
+}
+
+// iommuGroups returns the number of IOMMU groups and the default domain type of
+// the first group: "identity" for passthrough, "DMA" or "DMA-FQ" for translation.
+func (s *SecurityCollector) iommuGroups() (int, string) {
+	groups, err := listDir(s.path("/sys/kernel/iommu_groups"))
+	if err != nil || len(groups) == 0 {
+		return 0, ""
+	}
+
+	domain := ""
+	for _, g := range groups {
+		if t := readSysfs(s.path(filepath.Join("/sys/kernel/iommu_groups", g, "type"))); t != "" {
+			domain = t
+			break
+		}
+	}
+	return len(groups), domain
+}
@@ -0,0 +1,103 @@
+package collectors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+func collectSecurity(t *testing.T, root string) map[string]string {
+	t.Helper()
+	collector := &collectors.SecurityCollector{HostRoot: root}
+
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+
+	res := make(map[string]string, len(configs))
+	for _, cfg := range configs {
+		if cfg.Type != collectors.SecurityType {
+			t.Errorf("Expected type %s, got %s", collectors.SecurityType, cfg.Type)
+		}
+		sc, ok := cfg.Data.(collectors.SecurityConfig)
+		if !ok {
+			t.Fatalf("Expected SecurityConfig, got %T", cfg.Data)
+		}
+		res[sc.Key] = sc.Value
+	}
+	return res
+}
+
+func TestSecurityCollector_Hardened(t *testing.T) {
+	root := t.TempDir()
+	efivars := "sys/firmware/efi/efivars/"
+	writeFile(t, root, efivars+"SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c", "\x06\x00\x00\x00\x01")
+	writeFile(t, root, efivars+"SetupMode-8be4df61-93ca-11d2-aa0d-00e098032b8c", "\x06\x00\x00\x00\x00")
+	writeFile(t, root, "sys/kernel/security/lockdown", "none [integrity] confidentiality\n")
+	writeFile(t, root, "sys/module/module/parameters/sig_enforce", "Y\n")
+	writeFile(t, root, "sys/module/apparmor/parameters/enabled", "Y\n")
+	writeFile(t, root, "sys/kernel/security/apparmor/profiles",
+		"/usr/bin/man (enforce)\nnvidia_modprobe (enforce)\nunprivileged_userns (complain)\n")
+	writeFile(t, root, "sys/kernel/iommu_groups/0/type", "DMA-FQ\n")
+	writeFile(t, root, "sys/kernel/iommu_groups/1/type", "DMA-FQ\n")
+
+	got := collectSecurity(t, root)
+
+	want := map[string]string{
+		collectors.SecurityKeySecureBoot:         collectors.SecurityEnabled,
+		collectors.SecurityKeySetupMode:          collectors.SecurityDisabled,
+		collectors.SecurityKeyLockdown:           "integrity",
+		collectors.SecurityKeyModuleSigEnforce:   collectors.SecurityEnabled,
+		collectors.SecurityKeySELinux:            collectors.SecurityDisabled,
+		collectors.SecurityKeyAppArmor:           collectors.SecurityEnabled,
+		collectors.SecurityKeyAppArmorEnforce:    "2",
+		collectors.SecurityKeyAppArmorComplain:   "1",
+		collectors.SecurityKeyIOMMU:              collectors.SecurityEnabled,
+		collectors.SecurityKeyIOMMUGroups:        "2",
+		collectors.SecurityKeyIOMMUDefaultDomain: "DMA-FQ",
+	}
+	for key, val := range want {
+		if got[key] != val {
+			t.Errorf("%s = %q, want %q", key, got[key], val)
+		}
+	}
+}
+
+func TestSecurityCollector_LegacyBIOS(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "sys/fs/selinux/enforce", "0\n")
+	writeFile(t, root, "sys/module/module/parameters/sig_enforce", "N\n")
+
+	got := collectSecurity(t, root)
+
+	want := map[string]string{
+		collectors.SecurityKeySecureBoot:       collectors.SecurityUnsupported,
+		collectors.SecurityKeyLockdown:         collectors.SecurityUnsupported,
+		collectors.SecurityKeyModuleSigEnforce: collectors.SecurityDisabled,
+		collectors.SecurityKeySELinux:          "permissive",
+		collectors.SecurityKeyAppArmor:         collectors.SecurityUnsupported,
+		collectors.SecurityKeyIOMMU:            collectors.SecurityDisabled,
+		collectors.SecurityKeyIOMMUGroups:      "0",
+	}
+	for key, val := range want {
+		if got[key] != val {
+			t.Errorf("%s = %q, want %q", key, got[key], val)
+		}
+	}
+
+	if _, ok := got[collectors.SecurityKeyIOMMUDefaultDomain]; ok {
+		t.Error("Expected no IOMMU default domain without groups")
+	}
+}
+
+func TestSecurityCollector_Collect_ContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // Cancel immediately
+
+	collector := &collectors.SecurityCollector{}
+	if _, err := collector.Collect(ctx); err == nil {
+		t.Error("Expected error for canceled context")
+	}
+}
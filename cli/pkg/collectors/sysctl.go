@@ -24,6 +24,11 @@ type SysctlConfig struct {
 	Value string
 }
 
+// ID returns the sysctl path, e.g. /proc/sys/vm/swappiness.
+func (c SysctlConfig) ID() string {
+	return c.Key
+}
+
 // Collect gathers sysctl configurations from /proc/sys, excluding /proc/sys/net
 // and returns them as a slice of Configuration objects.
 func (s *SysctlCollector) Collect(ctx context.Context) ([]Configuration, error) {
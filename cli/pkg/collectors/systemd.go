@@ -3,24 +3,44 @@ package collectors
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sort"
 
 	"github.com/coreos/go-systemd/v22/dbus"
 )
 
 // SystemDCollector is a collector that gathers configuration data from systemd services.
+// When the system bus is unavailable it falls back to reading unit files from disk.
 type SystemDCollector struct {
 	Services []string
+	// HostRoot is the host root prefix used by the file-based fallback. Empty means "/".
+	HostRoot string
+	// Logger reports the fallback to unit files. Nil means slog.Default().
+	Logger *slog.Logger
 }
 
 // SystemDType is the type identifier for systemd configurations.
 const SystemDType string = "SystemD"
 
+const (
+	// SystemDBackendDBus indicates properties were read from systemd over D-Bus.
+	SystemDBackendDBus = "dbus"
+	// SystemDBackendFile indicates properties were parsed from unit files on disk.
+	SystemDBackendFile = "file"
+)
+
 // SystemDConfig represents the configuration data collected from a systemd service.
 type SystemDConfig struct {
 	Unit       string
+	Backend    string
 	Properties map[string]any
 }
 
+// ID returns the unit name, e.g. containerd.service.
+func (c SystemDConfig) ID() string {
+	return c.Unit
+}
+
 // Collect gathers configuration data from specified systemd services.
 // It implements the Collector interface.
 func (s *SystemDCollector) Collect(ctx context.Context) ([]Configuration, error) {
@@ -32,7 +52,23 @@ func (s *SystemDCollector) Collect(ctx context.Context) ([]Configuration, error)
 
 	conn, err := dbus.NewSystemdConnectionContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to systemd: %w", err)
+		// Check if context is canceled before falling back
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		// No system bus (minimal containers, chroots), read unit files instead
+		logger := s.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Warn("systemd D-Bus unavailable, reading unit files",
+			slog.String("error", err.Error()))
+		fc := &SystemDFileCollector{
+			Services: services,
+			HostRoot: s.HostRoot,
+		}
+		return fc.Collect(ctx)
 	}
 	defer conn.Close()
 
@@ -42,10 +78,13 @@ func (s *SystemDCollector) Collect(ctx context.Context) ([]Configuration, error)
 			return nil, fmt.Errorf("failed to get unit properties: %w", err)
 		}
 
+		sortDependencies(data)
+
 		res = append(res, Configuration{
 			Type: SystemDType,
 			Data: SystemDConfig{
 				Unit:       service,
+				Backend:    SystemDBackendDBus,
 				Properties: data,
 			},
 		})
@@ -53,3 +92,24 @@ func (s *SystemDCollector) Collect(ctx context.Context) ([]Configuration, error)
 
 	return res, nil
 }
+
+// dependencyProperties are unit properties holding sets of unit names, which
+// systemd returns in hash order.
+var dependencyProperties = []string{
+	"Names", "Requires", "Requisite", "Wants", "BindsTo", "PartOf", "Upholds",
+	"RequiredBy", "RequisiteOf", "WantedBy", "BoundBy", "UpheldBy", "ConsistsOf",
+	"Conflicts", "ConflictedBy", "Before", "After", "OnSuccess", "OnSuccessOf",
+	"OnFailure", "OnFailureOf", "Triggers", "TriggeredBy", "PropagatesReloadTo",
+	"ReloadPropagatedFrom", "PropagatesStopTo", "StopPropagatedFrom",
+	"JoinsNamespaceOf", "SliceOf", "RequiresMountsFor", "WantsMountsFor",
+}
+
+// sortDependencies sorts the unit name sets of the properties in place, so
+// snapshots of the same unit are identical.
+func sortDependencies(props map[string]any) {
+	for _, name := range dependencyProperties {
+		if units, ok := props[name].([]string); ok {
+			sort.Strings(units)
+		}
+	}
+}
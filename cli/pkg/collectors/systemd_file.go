@@ -0,0 +1,302 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// systemdUnitDirs lists the unit search paths in systemd's precedence order,
+// relative to the host root.
+var systemdUnitDirs = []string{
+	"etc/systemd/system",
+	"run/systemd/system",
+	"usr/local/lib/systemd/system",
+	"usr/lib/systemd/system",
+	"lib/systemd/system",
+}
+
+// SystemDFileCollector gathers systemd unit configuration directly from unit files
+// and /run/systemd state. It is used when no system bus is available, e.g. inside
+// minimal containers or chroots.
+type SystemDFileCollector struct {
+	Services []string
+	// HostRoot is prepended to all paths read by the collector. Empty means "/".
+	HostRoot string
+}
+
+// Collect parses the unit files and drop-ins of the specified services.
+// It implements the Collector interface.
+func (s *SystemDFileCollector) Collect(ctx context.Context) ([]Configuration, error) {
+	services := s.Services
+	if len(services) == 0 {
+		services = []string{"containerd.service"}
+	}
+	res := make([]Configuration, 0, len(services))
+
+	for _, service := range services {
+		// Check if context is canceled
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		props, err := s.unitProperties(service)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read unit %s: %w", service, err)
+		}
+
+		res = append(res, Configuration{
+			Type: SystemDType,
+			Data: SystemDConfig{
+				Unit:       service,
+				Backend:    SystemDBackendFile,
+				Properties: props,
+			},
+		})
+	}
+
+	return res, nil
+}
+
+// unitProperties builds a D-Bus like property map for a unit from its fragment,
+// drop-ins, enablement symlinks and runtime state.
+func (s *SystemDFileCollector) unitProperties(unit string) (map[string]any, error) {
+	props := map[string]any{
+		"Id": unit,
+	}
+
+	fragment, err := s.findFragment(unit)
+	if err != nil {
+		return nil, err
+	}
+	if fragment == "" {
+		props["LoadState"] = "not-found"
+		props["ActiveState"] = s.activeState(unit, props)
+		return props, nil
+	}
+	props["FragmentPath"] = fragment
+
+	// A unit linked to /dev/null is masked and has no configuration
+	if target, err := os.Readlink(s.path(fragment)); err == nil && target == os.DevNull {
+		props["LoadState"] = "masked"
+		props["UnitFileState"] = "masked"
+		props["ActiveState"] = s.activeState(unit, props)
+		return props, nil
+	}
+	props["LoadState"] = "loaded"
+
+	files := []string{fragment}
+	dropIns, err := s.findDropIns(unit)
+	if err != nil {
+		return nil, err
+	}
+	if len(dropIns) > 0 {
+		props["DropInPaths"] = dropIns
+		files = append(files, dropIns...)
+	}
+
+	values := make(map[string][]string)
+	hasInstall := false
+	for _, f := range files {
+		p, err := s.resolve(f)
+		if err != nil {
+			return nil, err
+		}
+		sections, err := parseUnitFile(p, values)
+		if err != nil {
+			return nil, err
+		}
+		if sections["Install"] {
+			hasInstall = true
+		}
+	}
+
+	for k, v := range values {
+		switch len(v) {
+		case 0:
+			continue
+		case 1:
+			props[k] = v[0]
+		default:
+			props[k] = v
+		}
+	}
+
+	props["UnitFileState"] = s.unitFileState(unit, hasInstall)
+	props["ActiveState"] = s.activeState(unit, props)
+
+	return props, nil
+}
+
+// findFragment returns the host path of the first unit file found in the search
+// path, or an empty string if the unit does not exist.
+func (s *SystemDFileCollector) findFragment(unit string) (string, error) {
+	for _, dir := range systemdUnitDirs {
+		p := "/" + filepath.Join(dir, unit)
+		resolved, err := s.resolve("/" + dir)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Lstat(filepath.Join(resolved, unit)); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return "", fmt.Errorf("failed to stat unit file: %w", err)
+		}
+		return p, nil
+	}
+	return "", nil
+}
+
+// findDropIns returns the host paths of the unit's drop-in files ordered by file
+// name. A drop-in in a higher precedence directory hides one with the same name
+// in a lower precedence directory.
+func (s *SystemDFileCollector) findDropIns(unit string) ([]string, error) {
+	byName := make(map[string]string)
+	for i := len(systemdUnitDirs) - 1; i >= 0; i-- {
+		dir := "/" + filepath.Join(systemdUnitDirs[i], unit+".d")
+		resolved, err := s.resolve(dir)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(resolved)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read drop-in dir: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".conf") {
+				continue
+			}
+			byName[e.Name()] = filepath.Join(dir, e.Name())
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for n := range byName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		res = append(res, byName[n])
+	}
+	return res, nil
+}
+
+// unitFileState reports whether the unit is enabled by looking for symlinks in
+// the .wants and .requires directories under /etc/systemd/system.
+func (s *SystemDFileCollector) unitFileState(unit string, hasInstall bool) string {
+	patterns := []string{
+		"/etc/systemd/system/*.wants/" + unit,
+		"/etc/systemd/system/*.requires/" + unit,
+	}
+	for _, p := range patterns {
+		matches, err := filepath.Glob(s.path(p))
+		if err == nil && len(matches) > 0 {
+			return "enabled"
+		}
+	}
+	if !hasInstall {
+		return "static"
+	}
+	return "disabled"
+}
+
+// activeState derives the unit state from the invocation records systemd keeps
+// in /run/systemd/units. Without a running systemd the state is unknown.
+func (s *SystemDFileCollector) activeState(unit string, props map[string]any) string {
+	unitsDir := s.path("/run/systemd/units")
+	if _, err := os.Stat(unitsDir); err != nil {
+		return "unknown"
+	}
+
+	id, err := os.Readlink(filepath.Join(unitsDir, "invocation:"+unit))
+	if err != nil {
+		return "inactive"
+	}
+	props["InvocationID"] = id
+	return "active"
+}
+
+// path resolves a host absolute path against the configured host root.
+func (s *SystemDFileCollector) path(p string) string {
+	return hostPath(s.HostRoot, p)
+}
+
+// resolve is path with the symlinks in p followed within the host root, for
+// unit files and drop-ins that link to other unit directories.
+func (s *SystemDFileCollector) resolve(p string) (string, error) {
+	res, err := resolveHostPath(s.HostRoot, p)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", p, err)
+	}
+	return res, nil
+}
+
+// parseUnitFile reads a systemd unit file and merges its assignments into values.
+// Repeated keys accumulate, and an empty assignment resets the list as systemd does.
+// It returns the set of sections found in the file.
+func parseUnitFile(path string, values map[string][]string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open unit file: %w", err)
+	}
+	defer f.Close()
+
+	sections := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	var cont strings.Builder
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Join continuation lines
+		if strings.HasSuffix(line, "\\") {
+			cont.WriteString(strings.TrimSpace(strings.TrimSuffix(line, "\\")))
+			cont.WriteString(" ")
+			continue
+		}
+		if cont.Len() > 0 {
+			cont.WriteString(line)
+			line = cont.String()
+			cont.Reset()
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			sections[strings.Trim(line, "[]")] = true
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		val = strings.TrimSpace(val)
+
+		if val == "" {
+			delete(values, key)
+			continue
+		}
+		values[key] = append(values[key], val)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse unit file: %w", err)
+	}
+
+	return sections, nil
+}
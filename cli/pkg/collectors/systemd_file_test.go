@@ -0,0 +1,214 @@
+package collectors_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// writeFile creates a file under root, including any parent directories.
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	p := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+}
+
+// symlink creates a symlink under root, including any parent directories.
+func symlink(t *testing.T, root, target, path string) {
+	t.Helper()
+	p := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.Symlink(target, p); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+}
+
+func collectUnits(t *testing.T, root string, units ...string) map[string]collectors.SystemDConfig {
+	t.Helper()
+	collector := &collectors.SystemDFileCollector{
+		Services: units,
+		HostRoot: root,
+	}
+
+	configs, err := collector.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+
+	res := make(map[string]collectors.SystemDConfig, len(configs))
+	for _, cfg := range configs {
+		if cfg.Type != collectors.SystemDType {
+			t.Errorf("Expected type %s, got %s", collectors.SystemDType, cfg.Type)
+		}
+		sd, ok := cfg.Data.(collectors.SystemDConfig)
+		if !ok {
+			t.Fatalf("Expected SystemDConfig, got %T", cfg.Data)
+		}
+		if sd.Backend != collectors.SystemDBackendFile {
+			t.Errorf("Expected backend %s, got %s", collectors.SystemDBackendFile, sd.Backend)
+		}
+		res[sd.Unit] = sd
+	}
+	return res
+}
+
+func TestSystemDFileCollector_Collect(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, root, "usr/lib/systemd/system/containerd.service", `[Unit]
+Description=containerd container runtime
+After=network.target
+
+[Service]
+ExecStartPre=-/sbin/modprobe overlay
+ExecStart=/usr/bin/containerd
+Environment="A=1"
+Environment="B=2"
+LimitNOFILE=infinity
+
+[Install]
+WantedBy=multi-user.target
+`)
+	writeFile(t, root, "etc/systemd/system/containerd.service.d/10-override.conf", `[Service]
+ExecStart=
+ExecStart=/usr/local/bin/containerd \
+  --log-level debug
+`)
+	writeFile(t, root, "usr/lib/systemd/system/containerd.service.d/10-override.conf", `[Service]
+LimitNOFILE=1024
+`)
+	symlink(t, root, "/usr/lib/systemd/system/containerd.service",
+		"etc/systemd/system/multi-user.target.wants/containerd.service")
+	symlink(t, root, "0123456789abcdef", "run/systemd/units/invocation:containerd.service")
+
+	units := collectUnits(t, root, "containerd.service")
+	props := units["containerd.service"].Properties
+
+	tests := map[string]any{
+		"LoadState":     "loaded",
+		"ActiveState":   "active",
+		"UnitFileState": "enabled",
+		"InvocationID":  "0123456789abcdef",
+		"FragmentPath":  "/usr/lib/systemd/system/containerd.service",
+		"Description":   "containerd container runtime",
+		"ExecStart":     "/usr/local/bin/containerd --log-level debug",
+		"LimitNOFILE":   "infinity",
+	}
+	for key, want := range tests {
+		if got := props[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+
+	env, ok := props["Environment"].([]string)
+	if !ok || len(env) != 2 {
+		t.Errorf("Expected two Environment entries, got %v", props["Environment"])
+	}
+
+	dropIns, ok := props["DropInPaths"].([]string)
+	if !ok || len(dropIns) != 1 || dropIns[0] != "/etc/systemd/system/containerd.service.d/10-override.conf" {
+		t.Errorf("Unexpected DropInPaths: %v", props["DropInPaths"])
+	}
+}
+
+func TestSystemDFileCollector_AbsoluteSymlinks(t *testing.T) {
+	root := t.TempDir()
+
+	// Absolute links point into the host root, not the collector's root
+	writeFile(t, root, "lib/systemd/system/eidos-test.service", `[Service]
+ExecStart=/usr/bin/eidos-test
+`)
+	symlink(t, root, "/lib/systemd/system/eidos-test.service", "etc/systemd/system/eidos-test.service")
+	writeFile(t, root, "opt/dropins/10-env.conf", `[Service]
+Environment="A=1"
+`)
+	symlink(t, root, "/opt/dropins", "etc/systemd/system/eidos-test.service.d")
+	writeFile(t, root, "opt/conf/20-limits.conf", `[Service]
+LimitNOFILE=1024
+`)
+	symlink(t, root, "../../../../opt/conf/20-limits.conf", "run/systemd/system/eidos-test.service.d/20-limits.conf")
+
+	props := collectUnits(t, root, "eidos-test.service")["eidos-test.service"].Properties
+
+	for key, want := range map[string]any{
+		"LoadState":    "loaded",
+		"FragmentPath": "/etc/systemd/system/eidos-test.service",
+		"ExecStart":    "/usr/bin/eidos-test",
+		"Environment":  `"A=1"`,
+		"LimitNOFILE":  "1024",
+	} {
+		if got := props[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+
+	symlink(t, root, "/etc/systemd/system/loop.service", "etc/systemd/system/loop.service")
+	collector := &collectors.SystemDFileCollector{Services: []string{"loop.service"}, HostRoot: root}
+	if _, err := collector.Collect(context.Background()); err == nil {
+		t.Error("expected an error for a symlink loop")
+	}
+}
+
+func TestSystemDFileCollector_UnitStates(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, root, "lib/systemd/system/static.service", "[Service]\nExecStart=/bin/true\n")
+	writeFile(t, root, "lib/systemd/system/disabled.service", "[Service]\nExecStart=/bin/true\n[Install]\nWantedBy=multi-user.target\n")
+	writeFile(t, root, "lib/systemd/system/masked.service", "[Service]\nExecStart=/bin/true\n")
+	symlink(t, root, os.DevNull, "etc/systemd/system/masked.service")
+
+	units := collectUnits(t, root, "static.service", "disabled.service", "masked.service", "missing.service")
+
+	tests := []struct {
+		unit          string
+		loadState     string
+		unitFileState any
+	}{
+		{"static.service", "loaded", "static"},
+		{"disabled.service", "loaded", "disabled"},
+		{"masked.service", "masked", "masked"},
+		{"missing.service", "not-found", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			props := units[tt.unit].Properties
+			if props["LoadState"] != tt.loadState {
+				t.Errorf("LoadState = %v, want %v", props["LoadState"], tt.loadState)
+			}
+			if props["UnitFileState"] != tt.unitFileState {
+				t.Errorf("UnitFileState = %v, want %v", props["UnitFileState"], tt.unitFileState)
+			}
+			// No /run/systemd/units in the fixture, so runtime state is unknown
+			if props["ActiveState"] != "unknown" {
+				t.Errorf("ActiveState = %v, want unknown", props["ActiveState"])
+			}
+		})
+	}
+}
+
+func TestSystemDFileCollector_Collect_ContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // Cancel immediately
+
+	collector := &collectors.SystemDFileCollector{
+		Services: []string{"containerd.service"},
+		HostRoot: t.TempDir(),
+	}
+	_, err := collector.Collect(ctx)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -1,6 +1,19 @@
 package collectors
 
-import "context"
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
 
 // Collector is an interface for collecting configuration data.
 // Implementations of this interface can collect data from various sources
@@ -14,3 +27,169 @@ type Configuration struct {
 	Type string
 	Data any
 }
+
+// ID returns the identifier of the configuration within its type, e.g. the
+// unit name of SystemD configurations or the path of Sysctl configurations.
+// Configurations of unknown types have an empty ID.
+func (c Configuration) ID() string {
+	if d, ok := c.Data.(interface{ ID() string }); ok {
+		return d.ID()
+	}
+	return ""
+}
+
+// Sort orders configurations by type and then by ID. The sort is stable, so
+// configurations with the same ID, e.g. repeated boot parameters, keep their order.
+func Sort(configs []Configuration) {
+	slices.SortStableFunc(configs, func(a, b Configuration) int {
+		if c := cmp.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID(), b.ID())
+	})
+}
+
+// dataTypes maps each configuration type to the type of its Data.
+var dataTypes = map[string]reflect.Type{
+	KModType:     reflect.TypeOf(KModConfig{}),
+	SystemDType:  reflect.TypeOf(SystemDConfig{}),
+	GrubType:     reflect.TypeOf(GrubConfig{}),
+	SysctlType:   reflect.TypeOf(SysctlConfig{}),
+	NetworkType:  reflect.TypeOf(NetworkInterfaceConfig{}),
+	RDMAType:     reflect.TypeOf(RDMADeviceConfig{}),
+	PackageType:  reflect.TypeOf(PackageConfig{}),
+	SecurityType: reflect.TypeOf(SecurityConfig{}),
+	MountType:    reflect.TypeOf(MountConfig{}),
+	SwapType:     reflect.TypeOf(SwapConfig{}),
+	CgroupType:   reflect.TypeOf(CgroupConfig{}),
+	GPUType:      reflect.TypeOf(GPUConfig{}),
+}
+
+// Types returns the known configuration types, sorted.
+func Types() []string {
+	res := make([]string, 0, len(dataTypes))
+	for t := range dataTypes {
+		res = append(res, t)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// DataType returns the type of the Data of configurations of the given type.
+func DataType(typ string) (reflect.Type, bool) {
+	t, ok := dataTypes[typ]
+	return t, ok
+}
+
+// UnmarshalJSON decodes Data into the struct registered for the configuration
+// type. Data of unknown types is decoded into generic values.
+func (c *Configuration) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Type string
+		Data json.RawMessage
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	c.Type = raw.Type
+	c.Data = nil
+	if len(raw.Data) == 0 {
+		return nil
+	}
+	return c.decodeData(func(v any) error { return json.Unmarshal(raw.Data, v) })
+}
+
+// UnmarshalYAML decodes Data into the struct registered for the configuration
+// type. Data of unknown types is decoded into generic values.
+func (c *Configuration) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		Type string
+		Data yaml.Node
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	c.Type = raw.Type
+	c.Data = nil
+	if raw.Data.Kind == 0 {
+		return nil
+	}
+	return c.decodeData(raw.Data.Decode)
+}
+
+func (c *Configuration) decodeData(decode func(v any) error) error {
+	t, ok := dataTypes[c.Type]
+	if !ok {
+		var data any
+		if err := decode(&data); err != nil {
+			return fmt.Errorf("failed to decode %s data: %w", c.Type, err)
+		}
+		c.Data = data
+		return nil
+	}
+
+	v := reflect.New(t)
+	if err := decode(v.Interface()); err != nil {
+		return fmt.Errorf("failed to decode %s data: %w", c.Type, err)
+	}
+	c.Data = v.Elem().Interface()
+	return nil
+}
+
+// hostPath resolves an absolute host path against a host root prefix,
+// e.g. /host when running in a container with the host filesystem mounted.
+func hostPath(root, p string) string {
+	if root == "" || root == "/" {
+		return p
+	}
+	return filepath.Join(root, p)
+}
+
+// maxSymlinks is the number of symlinks followed resolving a path before
+// giving up, like the kernel's limit.
+const maxSymlinks = 40
+
+// resolveHostPath is hostPath with the symlinks in p resolved against the
+// host root instead of the root of this process, so an absolute link such as
+// /etc/systemd/system/x.service -> /lib/systemd/system/x.service is read from
+// the host. Missing path components are kept as is, opening the result then
+// fails with fs.ErrNotExist.
+func resolveHostPath(root, p string) (string, error) {
+	if root == "" || root == "/" {
+		return p, nil
+	}
+
+	// resolved is the host path resolved so far, "" for the host root
+	resolved := ""
+	rest := strings.Split(p, "/")
+	links := 0
+	for len(rest) > 0 {
+		name := rest[0]
+		rest = rest[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			if i := strings.LastIndex(resolved, "/"); i >= 0 {
+				resolved = resolved[:i]
+			}
+			continue
+		}
+
+		next := resolved + "/" + name
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			// Not a symlink, or missing
+			resolved = next
+			continue
+		}
+		if links++; links > maxSymlinks {
+			return "", fmt.Errorf("too many levels of symbolic links in %s", p)
+		}
+		if filepath.IsAbs(target) {
+			resolved = ""
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+	return filepath.Join(root, resolved), nil
+}
@@ -0,0 +1,124 @@
+package collectors
+
+import (
+	"reflect"
+	"strings"
+)
+
+// volatileSystemDProperties are systemd unit properties that change while a
+// unit runs: PIDs, usage counters and runtime state.
+var volatileSystemDProperties = map[string]bool{
+	"MainPID":            true,
+	"ExecMainPID":        true,
+	"ControlPID":         true,
+	"InvocationID":       true,
+	"NRestarts":          true,
+	"CPUUsageNSec":       true,
+	"MemoryCurrent":      true,
+	"MemoryPeak":         true,
+	"MemorySwapCurrent":  true,
+	"MemorySwapPeak":     true,
+	"MemoryZSwapCurrent": true,
+	"MemoryAvailable":    true,
+	"TasksCurrent":       true,
+	"IPIngressBytes":     true,
+	"IPIngressPackets":   true,
+	"IPEgressBytes":      true,
+	"IPEgressPackets":    true,
+	"IOReadBytes":        true,
+	"IOReadOperations":   true,
+	"IOWriteBytes":       true,
+	"IOWriteOperations":  true,
+	"StatusText":         true,
+}
+
+// volatileSysctlKeys are sysctl entries that change on every read or with
+// the load of the node: random identifiers, entropy and kernel object counts.
+var volatileSysctlKeys = map[string]bool{
+	"/proc/sys/kernel/random/uuid":          true,
+	"/proc/sys/kernel/random/boot_id":       true,
+	"/proc/sys/kernel/random/entropy_avail": true,
+	"/proc/sys/kernel/ns_last_pid":          true,
+	"/proc/sys/kernel/pty/nr":               true,
+	"/proc/sys/fs/file-nr":                  true,
+	"/proc/sys/fs/inode-nr":                 true,
+	"/proc/sys/fs/inode-state":              true,
+	"/proc/sys/fs/dentry-state":             true,
+	"/proc/sys/fs/aio-nr":                   true,
+}
+
+// execStatusFields is the number of leading fields of an Exec* property entry
+// (path, argv, ignore failure or flags) that describe the command. The fields
+// after them are start/exit timestamps, PID and exit status of the last run.
+const execStatusFields = 3
+
+// StripVolatile returns the configuration without the fields that change
+// between snapshots of an unchanged node: timestamps, PIDs and counters.
+// The configuration passed in is not modified.
+func StripVolatile(c Configuration) Configuration {
+	switch d := c.Data.(type) {
+	case SystemDConfig:
+		props := make(map[string]any, len(d.Properties))
+		for k, v := range d.Properties {
+			if volatileSystemDProperties[k] ||
+				strings.HasSuffix(k, "Timestamp") ||
+				strings.HasSuffix(k, "TimestampMonotonic") {
+				continue
+			}
+			if strings.HasPrefix(k, "Exec") {
+				v = stripExecStatus(v)
+			}
+			props[k] = v
+		}
+		d.Properties = props
+		c.Data = d
+	case SysctlConfig:
+		if volatileSysctlKeys[d.Key] {
+			d.Value = ""
+			c.Data = d
+		}
+	case SwapConfig:
+		d.UsedKB = 0
+		c.Data = d
+	}
+	return c
+}
+
+// stripExecStatus zeroes the runtime fields of Exec* properties read over
+// D-Bus, lists of (path, argv, ..., start, exit, pid, code, status) entries.
+// Decoded snapshots hold the entries as []any instead of [][]any. Other
+// values, e.g. command lines parsed from unit files, are returned as is.
+func stripExecStatus(v any) any {
+	switch entries := v.(type) {
+	case [][]any:
+		res := make([][]any, len(entries))
+		for i, e := range entries {
+			res[i] = stripExecEntry(e)
+		}
+		return res
+	case []any:
+		res := make([]any, len(entries))
+		for i, e := range entries {
+			if fields, ok := e.([]any); ok {
+				e = stripExecEntry(fields)
+			}
+			res[i] = e
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// stripExecEntry returns a copy of the entry with the fields after
+// execStatusFields set to their zero value.
+func stripExecEntry(e []any) []any {
+	stripped := make([]any, len(e))
+	copy(stripped, e)
+	for j := execStatusFields; j < len(stripped); j++ {
+		if stripped[j] != nil {
+			stripped[j] = reflect.Zero(reflect.TypeOf(stripped[j])).Interface()
+		}
+	}
+	return stripped
+}
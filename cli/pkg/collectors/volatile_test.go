@@ -0,0 +1,110 @@
+package collectors_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+func TestStripVolatile(t *testing.T) {
+	props := map[string]any{
+		"Restart":                       "always",
+		"MainPID":                       uint32(4242),
+		"NRestarts":                     uint32(3),
+		"MemoryCurrent":                 uint64(1 << 20),
+		"ActiveEnterTimestamp":          uint64(1700000000),
+		"ActiveEnterTimestampMonotonic": uint64(12345),
+		"ExecStart": [][]any{
+			{"/usr/bin/containerd", []string{"/usr/bin/containerd"}, false,
+				uint64(1700000000), uint64(12345), uint64(0), uint64(0), uint32(4242), int32(0), int32(0)},
+		},
+		"ExecReload": "/bin/kill -HUP $MAINPID",
+	}
+	c := collectors.Configuration{
+		Type: collectors.SystemDType,
+		Data: collectors.SystemDConfig{Unit: "containerd.service", Properties: props},
+	}
+
+	got := collectors.StripVolatile(c).Data.(collectors.SystemDConfig).Properties
+
+	for _, k := range []string{"MainPID", "NRestarts", "MemoryCurrent", "ActiveEnterTimestamp", "ActiveEnterTimestampMonotonic"} {
+		if _, ok := got[k]; ok {
+			t.Errorf("expected %s to be stripped", k)
+		}
+	}
+	if got["Restart"] != "always" || got["ExecReload"] != "/bin/kill -HUP $MAINPID" {
+		t.Errorf("expected configuration properties to be kept, got %v", got)
+	}
+
+	want := []any{"/usr/bin/containerd", []string{"/usr/bin/containerd"}, false,
+		uint64(0), uint64(0), uint64(0), uint64(0), uint32(0), int32(0), int32(0)}
+	if exec := got["ExecStart"].([][]any); !reflect.DeepEqual(exec[0], want) {
+		t.Errorf("expected runtime fields of ExecStart to be zeroed, got %v", exec[0])
+	}
+
+	// The original configuration is unchanged
+	if props["MainPID"] != uint32(4242) || props["ExecStart"].([][]any)[0][7] != uint32(4242) {
+		t.Error("expected original properties to be unchanged")
+	}
+}
+
+func TestStripVolatile_Swap(t *testing.T) {
+	c := collectors.Configuration{
+		Type: collectors.SwapType,
+		Data: collectors.SwapConfig{Source: collectors.SwapSourceProc, Device: "/swap.img", SizeKB: 1024, UsedKB: 512},
+	}
+	got := collectors.StripVolatile(c).Data.(collectors.SwapConfig)
+	if got.UsedKB != 0 || got.SizeKB != 1024 {
+		t.Errorf("expected only UsedKB to be stripped, got %+v", got)
+	}
+}
+
+func TestStripVolatile_Sysctl(t *testing.T) {
+	for key, want := range map[string]string{
+		"/proc/sys/kernel/random/uuid":    "",
+		"/proc/sys/kernel/random/boot_id": "",
+		"/proc/sys/fs/file-nr":            "",
+		"/proc/sys/kernel/ns_last_pid":    "",
+		"/proc/sys/vm/swappiness":         "60",
+	} {
+		c := collectors.Configuration{
+			Type: collectors.SysctlType,
+			Data: collectors.SysctlConfig{Key: key, Value: "60"},
+		}
+		got := collectors.StripVolatile(c).Data.(collectors.SysctlConfig)
+		if got.Key != key || got.Value != want {
+			t.Errorf("StripVolatile(%s) = %+v, want value %q", key, got, want)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	configs := []collectors.Configuration{
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness"}},
+		{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "console", Value: "ttyS0"}},
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "overlay"}},
+		{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "console", Value: "tty0"}},
+		{Type: collectors.PackageType, Data: collectors.PackageConfig{Name: "libc6", Arch: "i386"}},
+		{Type: collectors.PackageType, Data: collectors.PackageConfig{Name: "libc6", Arch: "amd64"}},
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+		{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "iommu", Value: "pt"}},
+	}
+
+	collectors.Sort(configs)
+
+	var got []string
+	for _, c := range configs {
+		id := c.Type + "/" + c.ID()
+		if g, ok := c.Data.(collectors.GrubConfig); ok {
+			id += "=" + g.Value
+		}
+		got = append(got, id)
+	}
+	want := "Grub/console=ttyS0,Grub/console=tty0,Grub/iommu=pt,KMod/nvidia,KMod/overlay," +
+		"Package/libc6:amd64,Package/libc6:i386,Sysctl//proc/sys/vm/swappiness"
+	if strings.Join(got, ",") != want {
+		t.Errorf("unexpected order:\n got: %s\nwant: %s", strings.Join(got, ","), want)
+	}
+}
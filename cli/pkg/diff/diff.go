@@ -0,0 +1,238 @@
+// Package diff compares snapshots configuration by configuration.
+//
+// Configurations are matched by type and ID, see collectors.Configuration.ID,
+// and compared field by field. Fields are compared on their JSON
+// representation, so typed and generically decoded snapshots compare equal.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// Kind is the kind of a change.
+type Kind string
+
+const (
+	// Added configurations are only in the newer snapshot.
+	Added Kind = "added"
+	// Removed configurations are only in the older snapshot.
+	Removed Kind = "removed"
+	// Modified configurations are in both snapshots with different fields.
+	Modified Kind = "modified"
+)
+
+// Change is a configuration added, removed or modified between two snapshots.
+type Change struct {
+	Kind Kind   `json:"kind" yaml:"kind"`
+	Type string `json:"type" yaml:"type"`
+	ID   string `json:"id" yaml:"id"`
+	// Fields are the modified fields of Modified changes.
+	Fields []Field `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// Field is a modified field of a configuration. Path is dotted, e.g.
+// "Properties.ActiveState". Old or New is nil when the field was added or
+// removed.
+type Field struct {
+	Path string `json:"path" yaml:"path"`
+	Old  any    `json:"old" yaml:"old"`
+	New  any    `json:"new" yaml:"new"`
+}
+
+// String returns a one line summary of the change, e.g. "modified Sysctl
+// /proc/sys/vm/swappiness".
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s %s", c.Kind, c.Type, c.ID)
+}
+
+// key identifies a configuration. Configurations with the same type and ID,
+// e.g. repeated boot parameters, are told apart by their occurrence.
+type key struct {
+	typ, id string
+	n       int
+}
+
+// Compare returns the changes from the from to the to configurations, sorted
+// by type and ID.
+func Compare(from, to []collectors.Configuration) ([]Change, error) {
+	oldByKey, oldKeys, err := index(from)
+	if err != nil {
+		return nil, err
+	}
+	newByKey, newKeys, err := index(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, k := range oldKeys {
+		if _, ok := newByKey[k]; !ok {
+			changes = append(changes, Change{Kind: Removed, Type: k.typ, ID: k.id})
+		}
+	}
+	for _, k := range newKeys {
+		o, ok := oldByKey[k]
+		if !ok {
+			changes = append(changes, Change{Kind: Added, Type: k.typ, ID: k.id})
+			continue
+		}
+		var fields []Field
+		compareValues("", o, newByKey[k], &fields)
+		if len(fields) > 0 {
+			changes = append(changes, Change{Kind: Modified, Type: k.typ, ID: k.id, Fields: fields})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Type != changes[j].Type {
+			return changes[i].Type < changes[j].Type
+		}
+		return changes[i].ID < changes[j].ID
+	})
+	return changes, nil
+}
+
+// index returns the JSON representation of the configurations' data by key,
+// and the keys in order.
+func index(configs []collectors.Configuration) (map[key]any, []key, error) {
+	byKey := make(map[key]any, len(configs))
+	keys := make([]key, 0, len(configs))
+	for _, c := range configs {
+		k := key{typ: c.Type, id: c.ID()}
+		for _, ok := byKey[k]; ok; _, ok = byKey[k] {
+			k.n++
+		}
+		v, err := normalize(c.Data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to compare %s %s: %w", c.Type, c.ID(), err)
+		}
+		byKey[k] = v
+		keys = append(keys, k)
+	}
+	return byKey, keys, nil
+}
+
+// normalize converts v to its generic JSON representation.
+func normalize(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res any
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Flatten returns the fields of configuration data by dotted path, compared
+// like Compare does: objects are flattened key by key, other values, including
+// lists, are a single field. Data that isn't an object is the field "".
+func Flatten(data any) (map[string]any, error) {
+	v, err := normalize(data)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]any)
+	flatten("", v, fields)
+	return fields, nil
+}
+
+func flatten(path string, v any, fields map[string]any) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		fields[path] = v
+		return
+	}
+	for name, child := range m {
+		p := name
+		if path != "" {
+			p = path + "." + name
+		}
+		flatten(p, child, fields)
+	}
+}
+
+// compareValues appends the differences of two JSON values to fields. Objects
+// are compared key by key, other values, including lists, as a whole.
+func compareValues(path string, from, to any, fields *[]Field) {
+	oldMap, oldOK := from.(map[string]any)
+	newMap, newOK := to.(map[string]any)
+	if !oldOK || !newOK {
+		if !reflect.DeepEqual(from, to) {
+			*fields = append(*fields, Field{Path: path, Old: from, New: to})
+		}
+		return
+	}
+
+	names := make([]string, 0, len(oldMap)+len(newMap))
+	for name := range oldMap {
+		names = append(names, name)
+	}
+	for name := range newMap {
+		if _, ok := oldMap[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := name
+		if path != "" {
+			p = path + "." + name
+		}
+		compareValues(p, oldMap[name], newMap[name], fields)
+	}
+}
+
+// WriteText writes the changes in a human-readable form, one line per change
+// prefixed with +, - or ~ followed by the modified fields.
+func WriteText(w io.Writer, changes []Change) error {
+	var b strings.Builder
+	for _, c := range changes {
+		switch c.Kind {
+		case Added:
+			fmt.Fprintf(&b, "+ %s %s\n", c.Type, c.ID)
+		case Removed:
+			fmt.Fprintf(&b, "- %s %s\n", c.Type, c.ID)
+		default:
+			fmt.Fprintf(&b, "~ %s %s\n", c.Type, c.ID)
+		}
+		for _, f := range c.Fields {
+			path := f.Path
+			if path == "" {
+				path = "(value)"
+			}
+			fmt.Fprintf(&b, "    %s: %s -> %s\n", path, FormatValue(f.Old), FormatValue(f.New))
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write changes: %w", err)
+	}
+	return nil
+}
+
+// FormatValue formats a field value for display: strings are quoted, nil is
+// <none> and other values are JSON.
+func FormatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "<none>"
+	case string:
+		return strconv.Quote(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,118 @@
+package diff_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/diff"
+)
+
+func TestCompare(t *testing.T) {
+	from := []collectors.Configuration{
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "60"}},
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nouveau"}},
+		{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+			Unit:       "kubelet.service",
+			Properties: map[string]any{"ActiveState": "active"},
+		}},
+		{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "console", Value: "tty0"}},
+		{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "console", Value: "ttyS0"}},
+	}
+	to := []collectors.Configuration{
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "0"}},
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+		{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+			Unit:       "kubelet.service",
+			Properties: map[string]any{"ActiveState": "active"},
+		}},
+		{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "console", Value: "tty0"}},
+	}
+
+	changes, err := diff.Compare(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []diff.Change{
+		{Kind: diff.Removed, Type: collectors.GrubType, ID: "console"},
+		{Kind: diff.Removed, Type: collectors.KModType, ID: "nouveau"},
+		{Kind: diff.Added, Type: collectors.KModType, ID: "nvidia"},
+		{Kind: diff.Modified, Type: collectors.SysctlType, ID: "/proc/sys/vm/swappiness", Fields: []diff.Field{
+			{Path: "Value", Old: "60", New: "0"},
+		}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+}
+
+func TestCompare_Fields(t *testing.T) {
+	unit := func(props map[string]any) []collectors.Configuration {
+		return []collectors.Configuration{{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+			Unit: "kubelet.service", Properties: props,
+		}}}
+	}
+
+	changes, err := diff.Compare(
+		unit(map[string]any{"ActiveState": "active", "Restart": "always", "Environment": []any{"A=1"}}),
+		unit(map[string]any{"ActiveState": "failed", "Result": "exit-code", "Environment": []any{"A=1", "B=2"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Kind != diff.Modified {
+		t.Fatalf("changes = %+v, want one modification", changes)
+	}
+
+	want := []diff.Field{
+		{Path: "Properties.ActiveState", Old: "active", New: "failed"},
+		{Path: "Properties.Environment", Old: []any{"A=1"}, New: []any{"A=1", "B=2"}},
+		{Path: "Properties.Restart", Old: "always", New: nil},
+		{Path: "Properties.Result", Old: nil, New: "exit-code"},
+	}
+	if !reflect.DeepEqual(changes[0].Fields, want) {
+		t.Errorf("fields = %+v, want %+v", changes[0].Fields, want)
+	}
+}
+
+func TestCompare_Unchanged(t *testing.T) {
+	configs := []collectors.Configuration{
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+		{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "console", Value: "tty0"}},
+		{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "console", Value: "ttyS0"}},
+	}
+	changes, err := diff.Compare(configs, configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("changes = %+v, want none", changes)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	err := diff.WriteText(&buf, []diff.Change{
+		{Kind: diff.Added, Type: "KMod", ID: "nvidia"},
+		{Kind: diff.Removed, Type: "KMod", ID: "nouveau"},
+		{Kind: diff.Modified, Type: "Sysctl", ID: "/proc/sys/vm/swappiness", Fields: []diff.Field{
+			{Path: "Value", Old: "60", New: "0"},
+			{Path: "Extra", New: 1.0},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `+ KMod nvidia
+- KMod nouveau
+~ Sysctl /proc/sys/vm/swappiness
+    Value: "60" -> "0"
+    Extra: <none> -> 1
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,411 @@
+// Package fleet compares the snapshots of nodes that should be configured
+// identically and reports the nodes deviating from the majority.
+package fleet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cluster"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/diff"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/remote"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+// Node is the snapshot of a node of the fleet.
+type Node struct {
+	Name     string
+	Snapshot *snapshot.Snapshot
+}
+
+// Outlier is a node deviating from the majority. Value is nil when the
+// configuration or field is missing on the node.
+type Outlier struct {
+	Node  string `json:"node" yaml:"node"`
+	Value any    `json:"value" yaml:"value"`
+}
+
+// Deviation is a configuration, or a field of it, that isn't the same on all
+// nodes. Field is empty for configurations missing on some nodes, Majority is
+// then true if most nodes have the configuration and nil otherwise.
+type Deviation struct {
+	Type  string `json:"type" yaml:"type"`
+	ID    string `json:"id" yaml:"id"`
+	Field string `json:"field,omitempty" yaml:"field,omitempty"`
+	// Majority is the most common value, Count the number of nodes having it.
+	// Tie is set when other values are as common.
+	Majority any       `json:"majority" yaml:"majority"`
+	Count    int       `json:"count" yaml:"count"`
+	Tie      bool      `json:"tie,omitempty" yaml:"tie,omitempty"`
+	Outliers []Outlier `json:"outliers" yaml:"outliers"`
+}
+
+// Report is the result of a fleet comparison.
+type Report struct {
+	Nodes      []string    `json:"nodes" yaml:"nodes"`
+	Deviations []Deviation `json:"deviations" yaml:"deviations"`
+}
+
+// Load reads the snapshots in dir and its subdirectories, in JSON or YAML and
+// optionally compressed, e.g. the output of 'eidos cluster snapshot' or an
+// extracted 'eidos remote snapshot' archive. Nodes are named by the node or
+// host label of the snapshot, its hostname or its file name.
+func Load(dir string) ([]Node, error) {
+	var nodes []Node
+	paths := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isSnapshotFile(path) {
+			return err
+		}
+		snap, err := load(path)
+		if err != nil {
+			return err
+		}
+
+		name := nodeName(snap, path)
+		if prev, ok := paths[name]; ok {
+			return fmt.Errorf("node %s is in both %s and %s", name, prev, path)
+		}
+		paths[name] = path
+		nodes = append(nodes, Node{Name: name, Snapshot: snap})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(nodes, func(a, b Node) int { return strings.Compare(a.Name, b.Name) })
+	return nodes, nil
+}
+
+func isSnapshotFile(path string) bool {
+	ext := filepath.Ext(path)
+	if serializers.CompressionFromPath(path) != serializers.CompressionNone {
+		ext = filepath.Ext(strings.TrimSuffix(path, ext))
+	}
+	return ext == ".json" || ext == ".yaml" || ext == ".yml"
+}
+
+func load(path string) (*snapshot.Snapshot, error) {
+	in, err := serializers.OpenInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	snap, err := snapshot.Decode(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return snap, nil
+}
+
+func nodeName(snap *snapshot.Snapshot, path string) string {
+	for _, label := range []string{cluster.NodeLabel, remote.HostLabel} {
+		if name := snap.Metadata.Labels[label]; name != "" {
+			return name
+		}
+	}
+	if snap.Metadata.Hostname != "" {
+		return snap.Metadata.Hostname
+	}
+	name := filepath.Base(path)
+	for isSnapshotFile(name) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name
+}
+
+// identitySysctlKeys are sysctl entries naming the node, they differ on every
+// node of a fleet.
+var identitySysctlKeys = map[string]bool{
+	"/proc/sys/kernel/hostname":       true,
+	"/proc/sys/kernel/domainname":     true,
+	"/proc/sys/kernel/random/boot_id": true,
+	"/proc/sys/kernel/random/uuid":    true,
+}
+
+// Options configures a fleet comparison.
+type Options struct {
+	// KeepIdentity compares the values identifying a node too: its hostname,
+	// boot and random UUIDs, interface MAC addresses and RDMA GUIDs.
+	KeepIdentity bool
+	// Ignore are configurations excluded from the comparison, TYPE or
+	// TYPE:ID patterns with the ID matched like path.Match, e.g.
+	// Sysctl:/proc/sys/fs/*.
+	Ignore []string
+}
+
+// ignored returns whether c matches one of the Ignore patterns.
+func (o Options) ignored(c collectors.Configuration) (bool, error) {
+	for _, p := range o.Ignore {
+		typ, id, hasID := strings.Cut(p, ":")
+		if typ != c.Type {
+			continue
+		}
+		if !hasID {
+			return true, nil
+		}
+		ok, err := path.Match(id, c.ID())
+		if err != nil {
+			return false, fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// stripIdentity returns the configuration without the values identifying
+// the node, and false if the whole configuration identifies it.
+func stripIdentity(c collectors.Configuration) (collectors.Configuration, bool) {
+	switch d := c.Data.(type) {
+	case collectors.SysctlConfig:
+		return c, !identitySysctlKeys[d.Key]
+	case collectors.NetworkInterfaceConfig:
+		d.Address = ""
+		c.Data = d
+	case collectors.RDMADeviceConfig:
+		d.NodeGUID, d.SysImageGUID = "", ""
+		ports := make([]collectors.RDMAPortConfig, len(d.Ports))
+		for i, p := range d.Ports {
+			p.PortGUID = ""
+			ports[i] = p
+		}
+		d.Ports = ports
+		c.Data = d
+	}
+	return c, true
+}
+
+// key identifies a configuration like in diff.Compare.
+type key struct {
+	typ, id string
+	n       int
+}
+
+// Compare compares the snapshots of at least two nodes. Volatile fields and,
+// unless opts.KeepIdentity is set, the values identifying each node are
+// ignored. Deviations are sorted by type, ID and field.
+func Compare(nodes []Node, opts Options) (*Report, error) {
+	if len(nodes) < 2 {
+		return nil, errors.New("at least two snapshots are needed to compare a fleet")
+	}
+
+	report := &Report{Deviations: []Deviation{}}
+	// fields are the fields of each configuration by node
+	fields := make(map[key]map[string]map[string]any)
+	var keys []key
+	for _, n := range nodes {
+		report.Nodes = append(report.Nodes, n.Name)
+		seen := make(map[key]bool)
+		for _, c := range n.Snapshot.Items {
+			ignore, err := opts.ignored(c)
+			if err != nil {
+				return nil, err
+			}
+			if ignore {
+				continue
+			}
+			c = collectors.StripVolatile(c)
+			if !opts.KeepIdentity {
+				var keep bool
+				if c, keep = stripIdentity(c); !keep {
+					continue
+				}
+			}
+			k := key{typ: c.Type, id: c.ID()}
+			for seen[k] {
+				k.n++
+			}
+			seen[k] = true
+
+			f, err := diff.Flatten(c.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare %s %s of %s: %w", c.Type, c.ID(), n.Name, err)
+			}
+			if fields[k] == nil {
+				fields[k] = make(map[string]map[string]any)
+				keys = append(keys, k)
+			}
+			fields[k][n.Name] = f
+		}
+	}
+
+	for _, k := range keys {
+		byNode := fields[k]
+
+		// Configurations missing on some nodes
+		var present []string
+		values := make(map[string]any, len(nodes))
+		for _, n := range report.Nodes {
+			if _, ok := byNode[n]; ok {
+				values[n] = true
+				present = append(present, n)
+			} else {
+				values[n] = nil
+			}
+		}
+		if d, ok := deviation(report.Nodes, values); ok {
+			d.Type, d.ID = k.typ, k.id
+			report.Deviations = append(report.Deviations, d)
+		}
+
+		// Fields differing between the nodes having the configuration
+		var paths []string
+		for _, f := range byNode {
+			for p := range f {
+				if !slices.Contains(paths, p) {
+					paths = append(paths, p)
+				}
+			}
+		}
+		slices.Sort(paths)
+		for _, p := range paths {
+			for _, n := range present {
+				values[n] = byNode[n][p]
+			}
+			if d, ok := deviation(present, values); ok {
+				d.Type, d.ID, d.Field = k.typ, k.id, p
+				report.Deviations = append(report.Deviations, d)
+			}
+		}
+	}
+
+	slices.SortStableFunc(report.Deviations, func(a, b Deviation) int {
+		if c := strings.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.ID, b.ID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Field, b.Field)
+	})
+	return report, nil
+}
+
+// deviation returns the deviation of the values of nodes, if they aren't all
+// equal. Values are compared on their JSON encoding.
+func deviation(nodes []string, values map[string]any) (Deviation, bool) {
+	encoded := make(map[string]string, len(nodes))
+	counts := make(map[string]int)
+	for _, n := range nodes {
+		b, _ := json.Marshal(values[n])
+		encoded[n] = string(b)
+		counts[string(b)]++
+	}
+	if len(counts) < 2 {
+		return Deviation{}, false
+	}
+
+	// The most common value, ties are broken by encoding for stable results
+	var majority string
+	for v, c := range counts {
+		if c > counts[majority] || (c == counts[majority] && v < majority) {
+			majority = v
+		}
+	}
+	d := Deviation{Count: counts[majority], Outliers: []Outlier{}}
+	for v, c := range counts {
+		if v != majority && c == d.Count {
+			d.Tie = true
+		}
+	}
+	for _, n := range nodes {
+		if encoded[n] == majority {
+			d.Majority = values[n]
+			continue
+		}
+		d.Outliers = append(d.Outliers, Outlier{Node: n, Value: values[n]})
+	}
+	return d, true
+}
+
+// Outliers returns the number of deviations of each node by type.
+func (r *Report) Outliers() map[string]map[string]int {
+	counts := make(map[string]map[string]int)
+	for _, d := range r.Deviations {
+		for _, o := range d.Outliers {
+			if counts[o.Node] == nil {
+				counts[o.Node] = make(map[string]int)
+			}
+			counts[o.Node][d.Type]++
+		}
+	}
+	return counts
+}
+
+// WriteText writes the deviations grouped by type, followed by a matrix of
+// the number of deviations of each node by type.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d nodes, %d deviations\n", len(r.Nodes), len(r.Deviations))
+
+	var types []string
+	for _, d := range r.Deviations {
+		if !slices.Contains(types, d.Type) {
+			types = append(types, d.Type)
+			fmt.Fprintf(&b, "\n%s\n", d.Type)
+		}
+
+		what, majority := d.ID, "present"
+		if d.Field != "" {
+			what, majority = d.ID+" "+d.Field, diff.FormatValue(d.Majority)
+		} else if d.Majority == nil {
+			majority = "missing"
+		}
+		tie := ""
+		if d.Tie {
+			tie = ", tie"
+		}
+		fmt.Fprintf(&b, "  %s: %s on %d nodes%s\n", what, majority, d.Count, tie)
+		for _, o := range d.Outliers {
+			value := diff.FormatValue(o.Value)
+			if d.Field == "" {
+				value = "present"
+				if o.Value == nil {
+					value = "missing"
+				}
+			}
+			fmt.Fprintf(&b, "    %s: %s\n", o.Node, value)
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	counts := r.Outliers()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "NODE\t%s\tTOTAL\n", strings.Join(types, "\t"))
+	for _, n := range r.Nodes {
+		row := []string{n}
+		total := 0
+		for _, t := range types {
+			c := counts[n][t]
+			total += c
+			if c == 0 {
+				row = append(row, ".")
+			} else {
+				row = append(row, fmt.Sprint(c))
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", strings.Join(row, "\t"), total)
+	}
+	return tw.Flush()
+}
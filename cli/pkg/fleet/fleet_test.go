@@ -0,0 +1,220 @@
+package fleet_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cluster"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/fleet"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+func node(name, swappiness string, kmods ...string) fleet.Node {
+	items := []collectors.Configuration{
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: swappiness}},
+	}
+	for _, m := range kmods {
+		items = append(items, collectors.Configuration{Type: collectors.KModType, Data: collectors.KModConfig{Name: m}})
+	}
+	snap := snapshot.New(items)
+	snap.Metadata.Hostname = name
+	return fleet.Node{Name: name, Snapshot: snap}
+}
+
+func TestCompare(t *testing.T) {
+	nodes := []fleet.Node{
+		node("gpu-1", "60", "nvidia", "nvidia_peermem"),
+		node("gpu-2", "60", "nvidia", "nvidia_peermem"),
+		node("gpu-3", "0", "nvidia"),
+		node("gpu-4", "60", "nvidia", "nvidia_peermem", "nouveau"),
+	}
+	report, err := fleet.Compare(nodes, fleet.Options{})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	if len(report.Deviations) != 3 {
+		t.Fatalf("deviations = %+v", report.Deviations)
+	}
+
+	nouveau := report.Deviations[0]
+	if nouveau.ID != "nouveau" || nouveau.Majority != nil || nouveau.Count != 3 ||
+		len(nouveau.Outliers) != 1 || nouveau.Outliers[0].Node != "gpu-4" {
+		t.Errorf("nouveau deviation = %+v", nouveau)
+	}
+	peermem := report.Deviations[1]
+	if peermem.ID != "nvidia_peermem" || peermem.Majority != true || peermem.Count != 3 ||
+		len(peermem.Outliers) != 1 || peermem.Outliers[0].Node != "gpu-3" || peermem.Outliers[0].Value != nil {
+		t.Errorf("nvidia_peermem deviation = %+v", peermem)
+	}
+	swappiness := report.Deviations[2]
+	if swappiness.Type != collectors.SysctlType || swappiness.Field != "Value" || swappiness.Majority != "60" ||
+		swappiness.Tie || len(swappiness.Outliers) != 1 || swappiness.Outliers[0].Value != "0" {
+		t.Errorf("swappiness deviation = %+v", swappiness)
+	}
+
+	counts := report.Outliers()
+	if counts["gpu-3"][collectors.KModType] != 1 || counts["gpu-3"][collectors.SysctlType] != 1 || len(counts["gpu-1"]) != 0 {
+		t.Errorf("outliers = %v", counts)
+	}
+
+	var b strings.Builder
+	if err := report.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"4 nodes, 3 deviations",
+		"  nouveau: missing on 3 nodes\n    gpu-4: present",
+		"  /proc/sys/vm/swappiness Value: \"60\" on 3 nodes\n    gpu-3: \"0\"",
+		"gpu-3  1     1       2",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCompare_Tie(t *testing.T) {
+	report, err := fleet.Compare([]fleet.Node{node("a", "0"), node("b", "60")}, fleet.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Deviations) != 1 || !report.Deviations[0].Tie {
+		t.Errorf("deviations = %+v", report.Deviations)
+	}
+
+	if _, err := fleet.Compare([]fleet.Node{node("a", "0")}, fleet.Options{}); err == nil {
+		t.Error("Compare() of a single node succeeded")
+	}
+}
+
+// hostNode returns the snapshot of a node with the values a collector reads
+// on a real host: identities, counters and unit runtime state.
+func hostNode(name, swappiness string, i int) fleet.Node {
+	id := fmt.Sprintf("%d", i)
+	snap := snapshot.New([]collectors.Configuration{
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/kernel/hostname", Value: name}},
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/kernel/random/boot_id", Value: "8d2c6a1e-0000-4000-8000-00000000000" + id}},
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/kernel/random/uuid", Value: "f3b1e2c4-0000-4000-8000-00000000000" + id}},
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/kernel/random/entropy_avail", Value: "25" + id}},
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/fs/file-nr", Value: "320" + id + "\t0\t9223372036854775807"}},
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: swappiness}},
+		{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+			Unit:    "containerd.service",
+			Backend: collectors.SystemDBackendDBus,
+			Properties: map[string]any{
+				"Restart":              "always",
+				"MainPID":              uint32(1000 + i),
+				"ActiveEnterTimestamp": uint64(1700000000000000 + i),
+				"ExecStart": [][]any{{"/usr/local/bin/containerd", []string{"/usr/local/bin/containerd"}, false,
+					uint64(1700000000000000 + i), uint64(5000 + i), uint64(0), uint64(0), uint32(1000 + i), int32(0), int32(0)}},
+			},
+		}},
+		{Type: collectors.NetworkType, Data: collectors.NetworkInterfaceConfig{
+			Name: "eth0", LinkLayer: collectors.LinkLayerEthernet, Address: "0a:58:0a:00:00:0" + id, MTU: 9001,
+		}},
+		{Type: collectors.RDMAType, Data: collectors.RDMADeviceConfig{
+			Name: "mlx5_0", NodeGUID: "b8ce:f603:00a1:000" + id, FirmwareVersion: "28.39.1002",
+			Ports: []collectors.RDMAPortConfig{{Port: 1, State: "ACTIVE", PortGUID: "b8ce:f603:00a1:000" + id}},
+		}},
+	})
+	snap.Metadata.Hostname = name
+	return fleet.Node{Name: name, Snapshot: snap}
+}
+
+func TestCompare_HostValues(t *testing.T) {
+	dir := t.TempDir()
+	for i, swappiness := range []string{"60", "60", "10"} {
+		n := hostNode(fmt.Sprintf("gpu-%d", i+1), swappiness, i+1)
+		b, err := json.Marshal(n.Snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, n.Name+".json"), b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Decoded snapshots, like the ones compare-fleet reads
+	nodes, err := fleet.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := fleet.Compare(nodes, fleet.Options{})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(report.Deviations) != 1 || report.Deviations[0].ID != "/proc/sys/vm/swappiness" ||
+		report.Deviations[0].Outliers[0].Node != "gpu-3" {
+		t.Errorf("expected only the swappiness drift, got %+v", report.Deviations)
+	}
+
+	report, err = fleet.Compare(nodes, fleet.Options{Ignore: []string{"Sysctl:/proc/sys/vm/*"}})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(report.Deviations) != 0 {
+		t.Errorf("expected ignored sysctls not to be compared, got %+v", report.Deviations)
+	}
+
+	report, err = fleet.Compare(nodes, fleet.Options{KeepIdentity: true})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	var ids []string
+	for _, d := range report.Deviations {
+		ids = append(ids, d.ID+" "+d.Field)
+	}
+	// boot_id and uuid are volatile and stripped anyway
+	want := "eth0 Address,mlx5_0 NodeGUID,mlx5_0 Ports,/proc/sys/kernel/hostname Value,/proc/sys/vm/swappiness Value"
+	if strings.Join(ids, ",") != want {
+		t.Errorf("deviations with identities:\n got: %s\nwant: %s", strings.Join(ids, ","), want)
+	}
+
+	if _, err := fleet.Compare(nodes, fleet.Options{Ignore: []string{"Sysctl:["}}); err == nil {
+		t.Error("Compare() with a malformed ignore pattern succeeded")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path string, n fleet.Node) {
+		t.Helper()
+		b, err := json.Marshal(n.Snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	labeled := node("ip-10-0-0-1", "60")
+	labeled.Snapshot.Metadata.Labels = map[string]string{cluster.NodeLabel: "gpu-1"}
+	write("gpu-1.json", labeled)
+	write("gpu-2/snapshot.json", node("gpu-2", "60"))
+	if err := os.WriteFile(filepath.Join(dir, "gpu-2", "eidos.log"), []byte("log"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := fleet.Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "gpu-1" || nodes[1].Name != "gpu-2" {
+		t.Errorf("nodes = %+v", nodes)
+	}
+
+	write("copy.json", node("gpu-2", "60"))
+	if _, err := fleet.Load(dir); err == nil {
+		t.Error("Load() with a duplicate node succeeded")
+	}
+}
@@ -0,0 +1,351 @@
+// Package history stores snapshots of a node over time in a local directory,
+// together with the changes between consecutive snapshots.
+//
+// Each entry is stored as two files named after its ID, the time the snapshot
+// was taken:
+//
+//	20251019T120000Z.json      entry metadata and the changes to the previous entry
+//	20251019T120000Z.json.gz   the snapshot
+//
+// Files are written atomically and an entry is only listed once both files
+// exist, so a crash while storing a snapshot never leaves a partial entry.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/diff"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+const (
+	// idLayout formats entry IDs, they sort in the order snapshots were taken.
+	idLayout = "20060102T150405Z"
+
+	entrySuffix    = ".json"
+	snapshotSuffix = ".json.gz"
+
+	// Latest refers to the most recent entry.
+	Latest = "latest"
+)
+
+// ErrNotFound is returned for entries that don't exist.
+var ErrNotFound = errors.New("history entry not found")
+
+// Entry describes a stored snapshot.
+type Entry struct {
+	ID       string    `json:"id" yaml:"id"`
+	Created  time.Time `json:"created" yaml:"created"`
+	Hostname string    `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Items    int       `json:"items" yaml:"items"`
+	// Trigger describes what caused the snapshot, e.g. "file /etc/sysctl.d/99-k8s.conf"
+	// or "unit kubelet.service", empty for periodic snapshots.
+	Trigger string `json:"trigger,omitempty" yaml:"trigger,omitempty"`
+	// Previous is the ID of the entry the changes are relative to, empty for
+	// the first entry.
+	Previous string        `json:"previous,omitempty" yaml:"previous,omitempty"`
+	Changes  []diff.Change `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+// Retention limits the entries kept in a store. Zero values don't limit.
+type Retention struct {
+	// MaxEntries is the maximum number of entries kept.
+	MaxEntries int
+	// MaxAge is the maximum age of entries kept.
+	MaxAge time.Duration
+}
+
+// Store is a history of snapshots in a directory. A store is not safe for
+// concurrent use by multiple processes.
+type Store struct {
+	dir       string
+	retention Retention
+}
+
+// Open opens the store in dir, creating the directory if needed.
+func Open(dir string, retention Retention) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return &Store{dir: dir, retention: retention}, nil
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Add stores a snapshot with the changes to the latest entry and applies the
+// retention policy. Trigger describes what caused the snapshot, if anything.
+// Snapshots without changes are not stored, the returned entry is nil then.
+// Snapshots should be stripped of volatile fields, see
+// collectors.StripVolatile, or every snapshot is a change.
+func (s *Store) Add(snap *snapshot.Snapshot, trigger string) (*Entry, error) {
+	created := snap.Metadata.Created
+	if created.IsZero() {
+		created = time.Now().UTC().Truncate(time.Second)
+	}
+
+	entry := &Entry{
+		Created:  created,
+		Hostname: snap.Metadata.Hostname,
+		Items:    len(snap.Items),
+		Trigger:  trigger,
+	}
+
+	prev, err := s.Get(Latest)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		prevSnap, err := s.Snapshot(prev.ID)
+		if err != nil {
+			return nil, err
+		}
+		changes, err := diff.Compare(prevSnap.Items, snap.Items)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			return nil, nil
+		}
+		entry.Previous, entry.Changes = prev.ID, changes
+	}
+
+	entry.ID = s.newID(created)
+	if err := s.write(entry, snap); err != nil {
+		return nil, err
+	}
+	if err := s.Prune(time.Now()); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// List returns all entries, oldest first.
+func (s *Store) List() ([]Entry, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(ids))
+	for _, id := range ids {
+		e, err := s.read(id)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, nil
+}
+
+// Get returns an entry by ID. The ID can be abbreviated to a unique prefix,
+// "latest" is the most recent entry and "latest~N" the Nth entry before it.
+func (s *Store) Get(ref string) (*Entry, error) {
+	id, err := s.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return s.read(id)
+}
+
+// Snapshot returns the snapshot of an entry, see Get for the references
+// accepted.
+func (s *Store) Snapshot(ref string) (*snapshot.Snapshot, error) {
+	id, err := s.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := serializers.OpenInput(filepath.Join(s.dir, id+snapshotSuffix))
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	snap, err := snapshot.Decode(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", id, err)
+	}
+	return snap, nil
+}
+
+// Prune removes the entries exceeding the retention policy. The latest entry
+// is always kept, it's the baseline for the next snapshot.
+func (s *Store) Prune(now time.Time) error {
+	ids, err := s.ids()
+	if err != nil {
+		return err
+	}
+
+	var remove []string
+	keep := len(ids)
+	if n := s.retention.MaxEntries; n > 0 && keep > n {
+		remove = append(remove, ids[:keep-n]...)
+		ids, keep = ids[keep-n:], n
+	}
+	if s.retention.MaxAge > 0 {
+		for keep > 1 {
+			created, err := time.Parse(idLayout, strings.SplitN(ids[0], "-", 2)[0])
+			if err != nil || now.Sub(created) <= s.retention.MaxAge {
+				break
+			}
+			remove = append(remove, ids[0])
+			ids, keep = ids[1:], keep-1
+		}
+	}
+
+	for _, id := range remove {
+		// The snapshot goes first, entries without snapshots are never listed
+		for _, suffix := range []string{snapshotSuffix, entrySuffix} {
+			if err := os.Remove(filepath.Join(s.dir, id+suffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to prune history entry %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// newID returns the ID for an entry created at t, with a counter appended if
+// an entry was already created in the same second.
+func (s *Store) newID(t time.Time) string {
+	base := t.UTC().Format(idLayout)
+	id := base
+	for n := 1; s.exists(id); n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	return id
+}
+
+func (s *Store) exists(id string) bool {
+	_, err := os.Stat(filepath.Join(s.dir, id+entrySuffix))
+	return err == nil
+}
+
+// ids returns the IDs of all complete entries, oldest first.
+func (s *Store) ids() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var ids []string
+	for _, f := range files {
+		id, ok := strings.CutSuffix(f.Name(), snapshotSuffix)
+		if !ok || !s.exists(id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return idLess(ids[i], ids[j]) })
+	return ids, nil
+}
+
+// idLess orders IDs by time and then by counter.
+func idLess(a, b string) bool {
+	at, an, _ := strings.Cut(a, "-")
+	bt, bn, _ := strings.Cut(b, "-")
+	if at != bt {
+		return at < bt
+	}
+	ai, _ := strconv.Atoi(an)
+	bi, _ := strconv.Atoi(bn)
+	return ai < bi
+}
+
+func (s *Store) resolve(ref string) (string, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return "", err
+	}
+
+	if rest, ok := strings.CutPrefix(ref, Latest); ok {
+		n := 0
+		if rest != "" {
+			if n, err = strconv.Atoi(strings.TrimPrefix(rest, "~")); err != nil || !strings.HasPrefix(rest, "~") || n < 0 {
+				return "", fmt.Errorf("invalid history reference %q", ref)
+			}
+		}
+		if n >= len(ids) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+		return ids[len(ids)-1-n], nil
+	}
+
+	var match []string
+	for _, id := range ids {
+		if id == ref {
+			return id, nil
+		}
+		if strings.HasPrefix(id, ref) {
+			match = append(match, id)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	case 1:
+		return match[0], nil
+	default:
+		return "", fmt.Errorf("ambiguous history reference %q matches %d entries", ref, len(match))
+	}
+}
+
+func (s *Store) read(id string) (*Entry, error) {
+	b, err := os.ReadFile(filepath.Join(s.dir, id+entrySuffix))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read history entry: %w", err)
+	}
+	var e Entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("failed to read history entry %s: %w", id, err)
+	}
+	return &e, nil
+}
+
+// write stores the entry file first and the snapshot last, the entry only
+// becomes visible once both exist.
+func (s *Store) write(entry *Entry, snap *snapshot.Snapshot) error {
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	if err := writeFile(filepath.Join(s.dir, entry.ID+entrySuffix), func(out serializers.Output) error {
+		_, err := out.Write(append(b, '\n'))
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(s.dir, entry.ID+snapshotSuffix), func(out serializers.Output) error {
+		return serializers.NewWriter(serializers.FormatJSON, out).Serialize(snap)
+	})
+}
+
+func writeFile(path string, write func(serializers.Output) error) error {
+	out, err := serializers.OpenOutput(path, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := write(out); err != nil {
+		_ = out.Abort()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
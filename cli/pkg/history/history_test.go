@@ -0,0 +1,158 @@
+package history_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/diff"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/history"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+func snap(created time.Time, swappiness string) *snapshot.Snapshot {
+	s := snapshot.New([]collectors.Configuration{
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: swappiness}},
+	})
+	s.Metadata.Created = created
+	return s
+}
+
+func TestStore(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "history"), history.Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2025, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	first, err := store.Add(snap(t0, "60"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == nil || first.ID != "20251019T120000Z" || first.Previous != "" || first.Items != 2 {
+		t.Fatalf("first entry = %+v", first)
+	}
+
+	// Unchanged snapshots are not stored
+	if e, err := store.Add(snap(t0.Add(time.Minute), "60"), ""); err != nil || e != nil {
+		t.Fatalf("unchanged snapshot stored: %+v, %v", e, err)
+	}
+
+	// Snapshots taken in the same second get distinct IDs
+	second, err := store.Add(snap(t0, "0"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != "20251019T120000Z-1" || second.Previous != first.ID {
+		t.Fatalf("second entry = %+v", second)
+	}
+	want := []diff.Change{{Kind: diff.Modified, Type: collectors.SysctlType, ID: "/proc/sys/vm/swappiness",
+		Fields: []diff.Field{{Path: "Value", Old: "60", New: "0"}}}}
+	if len(second.Changes) != 1 || second.Changes[0].Fields[0] != want[0].Fields[0] {
+		t.Errorf("changes = %+v, want %+v", second.Changes, want)
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ID != first.ID || entries[1].ID != second.ID {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	for ref, id := range map[string]string{
+		"latest":             second.ID,
+		"latest~1":           first.ID,
+		"20251019T120000Z":   first.ID,
+		"20251019T120000Z-1": second.ID,
+	} {
+		e, err := store.Get(ref)
+		if err != nil {
+			t.Errorf("Get(%q) error = %v", ref, err)
+			continue
+		}
+		if e.ID != id {
+			t.Errorf("Get(%q) = %s, want %s", ref, e.ID, id)
+		}
+	}
+
+	s, err := store.Snapshot("latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := s.Items[1].Data.(collectors.SysctlConfig); !ok || d.Value != "0" {
+		t.Errorf("latest snapshot items = %+v", s.Items)
+	}
+}
+
+func TestStore_Resolve_Errors(t *testing.T) {
+	store, err := history.Open(t.TempDir(), history.Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(history.Latest); !errors.Is(err, history.ErrNotFound) {
+		t.Errorf("empty store error = %v, want ErrNotFound", err)
+	}
+
+	t0 := time.Date(2025, 10, 19, 12, 0, 0, 0, time.UTC)
+	for i, v := range []string{"1", "2"} {
+		if _, err := store.Add(snap(t0.Add(time.Duration(i)*time.Second), v), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, ref := range []string{"2025", "latest~x", "latest~5", "nope"} {
+		if _, err := store.Get(ref); err == nil {
+			t.Errorf("Get(%q) succeeded, want error", ref)
+		}
+	}
+}
+
+func TestStore_Retention(t *testing.T) {
+	dir := t.TempDir()
+	store, err := history.Open(dir, history.Retention{MaxEntries: 3, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries older than an hour are pruned, but the latest is always kept
+	old := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Second)
+	for i := range 5 {
+		if _, err := store.Add(snap(old.Add(time.Duration(i)*time.Minute), string(rune('a'+i))), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Items != 2 {
+		t.Fatalf("entries after age pruning = %+v", entries)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i := range 5 {
+		if _, err := store.Add(snap(now.Add(time.Duration(i)*time.Second), string(rune('A'+i))), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, err = store.List(); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+
+	// Pruned entries leave no files behind
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 6 {
+		t.Errorf("got %d files, want 6", len(files))
+	}
+}
@@ -0,0 +1,572 @@
+// Package inventory reads Ansible INI inventories, such as the hosts file of
+// the Cloud Native Stack playbooks, and selects hosts from them.
+package inventory
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// All is the implicit group of all hosts.
+	All = "all"
+	// Ungrouped is the implicit group of hosts listed outside any group.
+	Ungrouped = "ungrouped"
+)
+
+// Host is a host of the inventory with its resolved variables.
+type Host struct {
+	Name string
+	// Groups are the groups the host is a member of, directly or through
+	// children groups, including all.
+	Groups []string
+	// Vars are the host variables merged over the variables of its groups,
+	// legacy names of connection variables are stored by their current name.
+	Vars map[string]string
+}
+
+// aliases maps the legacy and alternative names of connection variables to
+// the names they are stored as.
+var aliases = map[string]string{
+	"ansible_ssh_host":         "ansible_host",
+	"ansible_ssh_port":         "ansible_port",
+	"ansible_ssh_user":         "ansible_user",
+	"ansible_ssh_pass":         "ansible_password",
+	"ansible_private_key_file": "ansible_ssh_private_key_file",
+	"ansible_sudo":             "ansible_become",
+	"ansible_sudo_pass":        "ansible_become_password",
+	"ansible_become_pass":      "ansible_become_password",
+}
+
+// Address returns the address to connect to, ansible_host or the name.
+func (h *Host) Address() string {
+	if v := h.Vars["ansible_host"]; v != "" {
+		return v
+	}
+	return h.Name
+}
+
+// User returns the remote user, empty for the SSH default.
+func (h *Host) User() string {
+	return h.Vars["ansible_user"]
+}
+
+// Port returns the SSH port, empty for the SSH default.
+func (h *Host) Port() string {
+	return h.Vars["ansible_port"]
+}
+
+// KeyFile returns the private key file, empty for the SSH default.
+func (h *Host) KeyFile() string {
+	return h.Vars["ansible_ssh_private_key_file"]
+}
+
+// Local returns whether the host is managed without SSH.
+func (h *Host) Local() bool {
+	return h.Var("ansible_connection") == "local"
+}
+
+// Var returns the value of a variable. Legacy names of connection variables
+// are resolved, e.g. ansible_ssh_user is ansible_user.
+func (h *Host) Var(name string) string {
+	return h.Vars[canonical(name)]
+}
+
+func canonical(name string) string {
+	if c, ok := aliases[name]; ok {
+		return c
+	}
+	return name
+}
+
+type group struct {
+	name     string
+	hosts    []string
+	children []string
+	vars     map[string]string
+}
+
+// Inventory is a parsed inventory.
+type Inventory struct {
+	// hosts are the host names in order of appearance
+	hosts    []string
+	hostVars map[string]map[string]string
+	groups   map[string]*group
+}
+
+// Load parses the inventory file at path.
+func Load(path string) (*Inventory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open inventory: %w", err)
+	}
+	defer f.Close()
+
+	inv, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inventory %s: %w", path, err)
+	}
+	return inv, nil
+}
+
+// Parse parses an INI inventory. Host patterns with numeric or alphabetic
+// ranges, e.g. node[01:16], are expanded.
+func Parse(r io.Reader) (*Inventory, error) {
+	inv := &Inventory{
+		hostVars: make(map[string]map[string]string),
+		groups:   make(map[string]*group),
+	}
+	inv.group(All)
+	inv.group(Ungrouped)
+
+	section, kind := Ungrouped, "hosts"
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated section %q", n, line)
+			}
+			section, kind, _ = strings.Cut(line[1:end], ":")
+			if kind == "" {
+				kind = "hosts"
+			}
+			if kind != "hosts" && kind != "vars" && kind != "children" {
+				return nil, fmt.Errorf("line %d: invalid section type %q", n, kind)
+			}
+			if section == "" {
+				return nil, fmt.Errorf("line %d: empty group name", n)
+			}
+			inv.group(section)
+			continue
+		}
+
+		var err error
+		switch kind {
+		case "hosts":
+			err = inv.parseHost(section, line)
+		case "vars":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				err = fmt.Errorf("expected key=value, got %q", line)
+				break
+			}
+			inv.group(section).vars[canonical(strings.TrimSpace(key))] = unquote(strings.TrimSpace(value))
+		case "children":
+			child := strings.Fields(line)[0]
+			g := inv.group(section)
+			if !slices.Contains(g.children, child) {
+				g.children = append(g.children, child)
+			}
+			inv.group(child)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, g := range inv.groups {
+		if err := inv.checkCycle(g.name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return inv, nil
+}
+
+// parseHost parses a host line of a group: a host pattern, optionally with a
+// port, followed by key=value variables.
+func (inv *Inventory) parseHost(section, line string) error {
+	fields, err := splitFields(line)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("invalid host line %q", line)
+	}
+	pattern := fields[0]
+	vars := make(map[string]string)
+	for _, f := range fields[1:] {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q", f)
+		}
+		vars[canonical(key)] = value
+	}
+
+	// host:port after any range, but not a bare IPv6 address
+	rest := pattern[strings.LastIndexByte(pattern, ']')+1:]
+	if strings.Count(rest, ":") == 1 {
+		i := strings.LastIndexByte(pattern, ':')
+		if _, err := strconv.Atoi(pattern[i+1:]); err == nil {
+			vars["ansible_port"] = pattern[i+1:]
+			pattern = pattern[:i]
+		}
+	}
+
+	names, err := expand(pattern)
+	if err != nil {
+		return err
+	}
+	g := inv.group(section)
+	for _, name := range names {
+		if _, ok := inv.hostVars[name]; !ok {
+			inv.hosts = append(inv.hosts, name)
+			inv.hostVars[name] = make(map[string]string)
+		}
+		for k, v := range vars {
+			inv.hostVars[name][k] = v
+		}
+		if !slices.Contains(g.hosts, name) {
+			g.hosts = append(g.hosts, name)
+		}
+	}
+	return nil
+}
+
+func (inv *Inventory) group(name string) *group {
+	g, ok := inv.groups[name]
+	if !ok {
+		g = &group{name: name, vars: make(map[string]string)}
+		inv.groups[name] = g
+	}
+	return g
+}
+
+func (inv *Inventory) checkCycle(name string, seen []string) error {
+	if slices.Contains(seen, name) {
+		return fmt.Errorf("group %q is its own child", name)
+	}
+	for _, c := range inv.groups[name].children {
+		if err := inv.checkCycle(c, append(seen, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hosts returns all hosts in order of appearance.
+func (inv *Inventory) Hosts() []Host {
+	hosts := make([]Host, 0, len(inv.hosts))
+	for _, name := range inv.hosts {
+		hosts = append(hosts, inv.host(name))
+	}
+	return hosts
+}
+
+// Groups returns the group names, sorted.
+func (inv *Inventory) Groups() []string {
+	names := make([]string, 0, len(inv.groups))
+	for name := range inv.groups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Select returns the hosts matching an Ansible host pattern: group or host
+// names or globs separated by commas or colons, "all" or "*" for all hosts.
+// Prefixed with "!" they exclude hosts, with "&" they intersect.
+func (inv *Inventory) Select(pattern string) ([]Host, error) {
+	selected := make(map[string]bool)
+	var excludes, intersects []string
+	for _, p := range strings.FieldsFunc(pattern, func(r rune) bool { return r == ',' || r == ':' }) {
+		p = strings.TrimSpace(p)
+		switch {
+		case strings.HasPrefix(p, "!"):
+			excludes = append(excludes, p[1:])
+		case strings.HasPrefix(p, "&"):
+			intersects = append(intersects, p[1:])
+		default:
+			names, err := inv.match(p)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range names {
+				selected[n] = true
+			}
+		}
+	}
+	for _, p := range intersects {
+		names, err := inv.match(p)
+		if err != nil {
+			return nil, err
+		}
+		for n := range selected {
+			if !slices.Contains(names, n) {
+				delete(selected, n)
+			}
+		}
+	}
+	for _, p := range excludes {
+		names, err := inv.match(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			delete(selected, n)
+		}
+	}
+
+	var hosts []Host
+	for _, name := range inv.hosts {
+		if selected[name] {
+			hosts = append(hosts, inv.host(name))
+		}
+	}
+	return hosts, nil
+}
+
+// match returns the hosts of a single pattern.
+func (inv *Inventory) match(p string) ([]string, error) {
+	if p == All || p == "*" {
+		return inv.hosts, nil
+	}
+	if _, ok := inv.groups[p]; ok {
+		return inv.members(p), nil
+	}
+	if _, ok := inv.hostVars[p]; ok {
+		return []string{p}, nil
+	}
+	if !strings.ContainsAny(p, "*?[") {
+		return nil, fmt.Errorf("no group or host %q in inventory", p)
+	}
+
+	var names []string
+	for _, g := range inv.Groups() {
+		if ok, err := path.Match(p, g); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		} else if ok {
+			names = append(names, inv.members(g)...)
+		}
+	}
+	for _, h := range inv.hosts {
+		if ok, _ := path.Match(p, h); ok {
+			names = append(names, h)
+		}
+	}
+	return names, nil
+}
+
+// members returns the hosts of a group and its children.
+func (inv *Inventory) members(name string) []string {
+	if name == All {
+		return inv.hosts
+	}
+	if name == Ungrouped {
+		var hosts []string
+		for _, h := range inv.hosts {
+			if len(inv.groupsOf(h)) == 0 || slices.Contains(inv.groups[Ungrouped].hosts, h) {
+				hosts = append(hosts, h)
+			}
+		}
+		return hosts
+	}
+	g := inv.groups[name]
+	hosts := slices.Clone(g.hosts)
+	for _, c := range g.children {
+		for _, h := range inv.members(c) {
+			if !slices.Contains(hosts, h) {
+				hosts = append(hosts, h)
+			}
+		}
+	}
+	return hosts
+}
+
+// groupsOf returns the named groups a host is a direct member of.
+func (inv *Inventory) groupsOf(host string) []string {
+	var groups []string
+	for _, g := range inv.groups {
+		if g.name != All && g.name != Ungrouped && slices.Contains(g.hosts, host) {
+			groups = append(groups, g.name)
+		}
+	}
+	return groups
+}
+
+// host resolves a host's groups and variables. Like Ansible, group
+// variables are applied by the depth of the groups below all, the deepest
+// last, groups of the same depth by name, and host variables override them.
+func (inv *Inventory) host(name string) Host {
+	seen := make(map[string]bool)
+	var visit func(g string)
+	visit = func(g string) {
+		if seen[g] {
+			return
+		}
+		seen[g] = true
+		for _, p := range inv.parents(g) {
+			visit(p)
+		}
+	}
+	direct := inv.groupsOf(name)
+	if len(direct) == 0 {
+		direct = []string{Ungrouped}
+	}
+	for _, g := range direct {
+		visit(g)
+	}
+	delete(seen, All)
+
+	depth := make(map[string]int, len(seen))
+	groups := make([]string, 0, len(seen))
+	for g := range seen {
+		depth[g] = inv.depth(g)
+		groups = append(groups, g)
+	}
+	slices.SortFunc(groups, func(a, b string) int {
+		if depth[a] != depth[b] {
+			return depth[a] - depth[b]
+		}
+		return strings.Compare(a, b)
+	})
+
+	vars := maps.Clone(inv.groups[All].vars)
+	for _, g := range groups {
+		maps.Copy(vars, inv.groups[g].vars)
+	}
+	maps.Copy(vars, inv.hostVars[name])
+
+	slices.Sort(groups)
+	return Host{Name: name, Groups: append([]string{All}, groups...), Vars: vars}
+}
+
+// depth returns the depth of a group below all, groups without parents are
+// children of all.
+func (inv *Inventory) depth(g string) int {
+	if g == All {
+		return 0
+	}
+	d := 1
+	for _, p := range inv.parents(g) {
+		d = max(d, inv.depth(p)+1)
+	}
+	return d
+}
+
+// parents returns the groups having g as a child.
+func (inv *Inventory) parents(g string) []string {
+	var parents []string
+	for _, p := range inv.groups {
+		if slices.Contains(p.children, g) {
+			parents = append(parents, p.name)
+		}
+	}
+	return parents
+}
+
+// expand expands the ranges of a host pattern, e.g. node[01:03] to node01,
+// node02 and node03 and db-[a:c] to db-a, db-b and db-c.
+func expand(pattern string) ([]string, error) {
+	start := strings.IndexByte(pattern, '[')
+	if start < 0 {
+		return []string{pattern}, nil
+	}
+	end := strings.IndexByte(pattern[start:], ']')
+	if end < 0 {
+		return nil, fmt.Errorf("invalid host range %q", pattern)
+	}
+	end += start
+	prefix, rng, suffix := pattern[:start], pattern[start+1:end], pattern[end+1:]
+
+	from, to, ok := strings.Cut(rng, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid host range %q", pattern)
+	}
+	var items []string
+	if lo, err := strconv.Atoi(from); err == nil {
+		hi, err := strconv.Atoi(to)
+		if err != nil || hi < lo {
+			return nil, fmt.Errorf("invalid host range %q", pattern)
+		}
+		for i := lo; i <= hi; i++ {
+			items = append(items, fmt.Sprintf("%0*d", len(from), i))
+		}
+	} else if len(from) == 1 && len(to) == 1 && from[0] <= to[0] {
+		for c := from[0]; c <= to[0]; c++ {
+			items = append(items, string(c))
+		}
+	} else {
+		return nil, fmt.Errorf("invalid host range %q", pattern)
+	}
+
+	rest, err := expand(suffix)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(items)*len(rest))
+	for _, item := range items {
+		for _, r := range rest {
+			names = append(names, prefix+item+r)
+		}
+	}
+	return names, nil
+}
+
+// splitFields splits a line at whitespace outside of quotes and removes the
+// quotes, e.g. a='b c' is a single field a=b c.
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	var cur strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inField = r, true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inField = false
+			}
+		case r == '#' && !inField:
+			// Comment after the host
+			return fields, nil
+		default:
+			cur.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if inField {
+		fields = append(fields, cur.String())
+	}
+	return fields, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// SplitArgs splits a command line at whitespace outside of quotes, e.g. the
+// ansible_ssh_common_args variable.
+func SplitArgs(s string) ([]string, error) {
+	return splitFields(s)
+}
@@ -0,0 +1,150 @@
+package inventory_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/inventory"
+)
+
+const hosts = `# CNS inventory
+bastion ansible_connection=local
+
+[master]
+#localhost ansible_ssh_user=nvidia ansible_ssh_pass=nvidiapass
+cp-1 ansible_host=10.0.0.10 ansible_ssh_user=nvidia ansible_ssh_common_args='-o StrictHostKeyChecking=no'
+
+[nodes]
+gpu-[01:03] ansible_user=ubuntu
+gpu-04:2222 ansible_host=10.0.0.14 # legacy
+
+[a100]
+gpu-01
+gpu-02
+
+[nodes:vars]
+ansible_ssh_private_key_file=~/.ssh/nodes
+ansible_become=true
+
+[a100:vars]
+ansible_ssh_private_key_file="~/.ssh/a100"
+
+[cluster:children]
+master
+nodes
+
+[nodes:children]
+a100
+
+[all:vars]
+ansible_user=admin
+`
+
+func names(hosts []inventory.Host) string {
+	var n []string
+	for _, h := range hosts {
+		n = append(n, h.Name)
+	}
+	return strings.Join(n, ",")
+}
+
+func TestParse(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader(hosts))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	all := inv.Hosts()
+	if got := names(all); got != "bastion,cp-1,gpu-01,gpu-02,gpu-03,gpu-04" {
+		t.Fatalf("hosts = %s", got)
+	}
+
+	tests := []struct {
+		host    string
+		address string
+		user    string
+		port    string
+		key     string
+	}{
+		{"bastion", "bastion", "admin", "", ""},
+		{"cp-1", "10.0.0.10", "nvidia", "", ""},
+		{"gpu-01", "gpu-01", "ubuntu", "", "~/.ssh/a100"},
+		{"gpu-03", "gpu-03", "ubuntu", "", "~/.ssh/nodes"},
+		{"gpu-04", "10.0.0.14", "admin", "2222", "~/.ssh/nodes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			i := slices.IndexFunc(all, func(h inventory.Host) bool { return h.Name == tt.host })
+			h := all[i]
+			if h.Address() != tt.address || h.User() != tt.user || h.Port() != tt.port || h.KeyFile() != tt.key {
+				t.Errorf("host = %s %s %s %s", h.Address(), h.User(), h.Port(), h.KeyFile())
+			}
+		})
+	}
+
+	if !all[0].Local() || all[1].Local() {
+		t.Error("bastion should be the only local host")
+	}
+	if got := all[1].Var("ansible_ssh_common_args"); got != "-o StrictHostKeyChecking=no" {
+		t.Errorf("common args = %q", got)
+	}
+	if got := strings.Join(all[2].Groups, ","); got != "all,a100,cluster,nodes" {
+		t.Errorf("groups = %s", got)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader(hosts))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{"all", "bastion,cp-1,gpu-01,gpu-02,gpu-03,gpu-04", false},
+		{"nodes", "gpu-01,gpu-02,gpu-03,gpu-04", false},
+		{"cluster", "cp-1,gpu-01,gpu-02,gpu-03,gpu-04", false},
+		{"ungrouped", "bastion", false},
+		{"nodes:!a100", "gpu-03,gpu-04", false},
+		{"cluster:&a100", "gpu-01,gpu-02", false},
+		{"cp-1,gpu-04", "cp-1,gpu-04", false},
+		{"gpu-0[34]", "gpu-03,gpu-04", false},
+		{"missing", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := inv.Select(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Select() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if names(got) != tt.want {
+				t.Errorf("Select() = %s, want %s", names(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"unterminated section", "[nodes\nhost"},
+		{"invalid section type", "[nodes:hosts2]"},
+		{"invalid variable", "[nodes]\nhost ansible_user"},
+		{"unterminated quote", "[nodes]\nhost ansible_ssh_common_args='-o x"},
+		{"invalid range", "[nodes]\nhost[3:1]"},
+		{"cyclic children", "[a:children]\nb\n[b:children]\na"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := inventory.Parse(strings.NewReader(tt.input)); err == nil {
+				t.Error("Parse() succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// Package k8s is a minimal Kubernetes API client for the few node, job and
+// pod operations eidos needs, configured from a kubeconfig file.
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// maxLogBytes limits the size of pod logs read.
+const maxLogBytes = 64 << 20
+
+// refreshBefore is how long before they expire credentials are refreshed.
+const refreshBefore = 30 * time.Second
+
+// StatusError is an error response of the API server.
+type StatusError struct {
+	Code    int
+	Reason  string
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s (%d %s)", e.Message, e.Code, e.Reason)
+	}
+	return fmt.Sprintf("%d %s", e.Code, http.StatusText(e.Code))
+}
+
+// IsNotFound returns whether err is a not found response.
+func IsNotFound(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Code == http.StatusNotFound
+}
+
+// Client calls the API server. Credentials issued by exec plugins and read
+// from token files are refreshed when they expire or the server rejects them.
+type Client struct {
+	config    *Config
+	http      *http.Client
+	transport *http.Transport
+
+	mu   sync.Mutex
+	cred *credential
+}
+
+// NewClient returns a client of the API server of cfg.
+func NewClient(cfg *Config) (*Client, error) {
+	u, err := url.Parse(cfg.Server)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid API server URL %q", cfg.Server)
+	}
+	c := &Client{
+		config: cfg,
+		cred:   &credential{token: cfg.Token, expiry: cfg.expiry},
+	}
+
+	c.transport = http.DefaultTransport.(*http.Transport).Clone()
+	c.transport.TLSClientConfig = cfg.TLS
+	if cfg.TLS != nil && len(cfg.TLS.Certificates) > 0 {
+		c.cred.cert = &cfg.TLS.Certificates[0]
+		if cfg.refresh != nil {
+			// The client certificate may be replaced by a refresh
+			c.transport.TLSClientConfig = cfg.TLS.Clone()
+			c.transport.TLSClientConfig.Certificates = nil
+			c.transport.TLSClientConfig.GetClientCertificate = c.clientCertificate
+		}
+	}
+	c.http = &http.Client{Transport: c.transport, Timeout: 60 * time.Second}
+	return c, nil
+}
+
+// credential returns the current credentials. They are refreshed if they
+// expire soon or if they are rejected, the credentials the server refused.
+func (c *Client) credential(rejected *credential) (*credential, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.config.refresh == nil {
+		return c.cred, nil
+	}
+	expiring := !c.cred.expiry.IsZero() && time.Until(c.cred.expiry) < refreshBefore
+	// Requests rejected concurrently refresh the credentials only once
+	if !expiring && rejected != c.cred {
+		return c.cred, nil
+	}
+
+	cred, err := c.config.refresh()
+	if err != nil {
+		return nil, err
+	}
+	if cred.cert == nil {
+		cred.cert = c.cred.cert
+	} else {
+		// Connections keep the certificate of their handshake
+		c.transport.CloseIdleConnections()
+	}
+	c.cred = cred
+	return cred, nil
+}
+
+func (c *Client) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cred.cert == nil {
+		return &tls.Certificate{}, nil
+	}
+	return c.cred.cert, nil
+}
+
+// Namespace returns the namespace of the kubeconfig context, default if not
+// set.
+func (c *Client) Namespace() string {
+	if c.config.Namespace == "" {
+		return "default"
+	}
+	return c.config.Namespace
+}
+
+// ListNodes lists the nodes matching the label selector, all when empty.
+func (c *Client) ListNodes(ctx context.Context, labelSelector string) ([]Node, error) {
+	var list NodeList
+	q := url.Values{}
+	if labelSelector != "" {
+		q.Set("labelSelector", labelSelector)
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/nodes", q, nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return list.Items, nil
+}
+
+// CreateJob creates a job and returns it as created.
+func (c *Client) CreateJob(ctx context.Context, namespace string, job *Job) (*Job, error) {
+	job.APIVersion, job.Kind = "batch/v1", "Job"
+	var created Job
+	if err := c.do(ctx, http.MethodPost, jobsPath(namespace), nil, job, &created); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	return &created, nil
+}
+
+// GetJob returns a job.
+func (c *Client) GetJob(ctx context.Context, namespace, name string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, jobsPath(namespace)+"/"+url.PathEscape(name), nil, nil, &job); err != nil {
+		return nil, fmt.Errorf("failed to get job %s: %w", name, err)
+	}
+	return &job, nil
+}
+
+// DeleteJob deletes a job and, in the background, its pods.
+func (c *Client) DeleteJob(ctx context.Context, namespace, name string) error {
+	q := url.Values{"propagationPolicy": {"Background"}}
+	if err := c.do(ctx, http.MethodDelete, jobsPath(namespace)+"/"+url.PathEscape(name), q, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete job %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListPods lists the pods of a namespace matching the label selector.
+func (c *Client) ListPods(ctx context.Context, namespace, labelSelector string) ([]Pod, error) {
+	var list PodList
+	q := url.Values{"labelSelector": {labelSelector}}
+	if err := c.do(ctx, http.MethodGet, podsPath(namespace), q, nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return list.Items, nil
+}
+
+// PodLogs returns the logs of a pod's container.
+func (c *Client) PodLogs(ctx context.Context, namespace, pod, container string) ([]byte, error) {
+	q := url.Values{"container": {container}}
+	resp, err := c.request(ctx, http.MethodGet, podsPath(namespace)+"/"+url.PathEscape(pod)+"/log", q, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs of pod %s: %w", pod, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxLogBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs of pod %s: %w", pod, err)
+	}
+	return b, nil
+}
+
+func jobsPath(namespace string) string {
+	return "/apis/batch/v1/namespaces/" + url.PathEscape(namespace) + "/jobs"
+}
+
+func podsPath(namespace string) string {
+	return "/api/v1/namespaces/" + url.PathEscape(namespace) + "/pods"
+}
+
+// do sends a JSON request and decodes the response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.request(ctx, method, path, q, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// request sends a request and returns the response if successful, the
+// caller closes its body. Requests rejected as unauthorized are sent again
+// once with refreshed credentials.
+func (c *Client) request(ctx context.Context, method, path string, q url.Values, body []byte) (*http.Response, error) {
+	cred, err := c.credential(nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(ctx, method, path, q, body, cred)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && c.config.refresh != nil {
+		resp.Body.Close()
+		if cred, err = c.credential(cred); err != nil {
+			return nil, err
+		}
+		if resp, err = c.send(ctx, method, path, q, body, cred); err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	se := &StatusError{Code: resp.StatusCode}
+	var status Status
+	if b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20)); json.Unmarshal(b, &status) == nil {
+		se.Reason, se.Message = status.Reason, status.Message
+	}
+	return nil, se
+}
+
+func (c *Client) send(ctx context.Context, method, path string, q url.Values, body []byte, cred *credential) (*http.Response, error) {
+	u := c.config.Server + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case cred.token != "":
+		req.Header.Set("Authorization", "Bearer "+cred.token)
+	case c.config.Username != "":
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+	return c.http.Do(req)
+}
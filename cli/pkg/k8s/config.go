@@ -0,0 +1,426 @@
+package k8s
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	inClusterNSFile    = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// tokenFileRefresh is how often token files are read again, the kubelet
+// rotates projected service account tokens before they expire.
+const tokenFileRefresh = time.Minute
+
+// Config is the connection configuration of an API server.
+type Config struct {
+	Server    string
+	Namespace string
+	Token     string
+	Username  string
+	Password  string
+	TLS       *tls.Config
+
+	// expiry is when Token or the client certificate expires, zero if unknown.
+	expiry time.Time
+	// refresh issues new credentials when they expire or are rejected, nil
+	// for static credentials.
+	refresh func() (*credential, error)
+}
+
+// credential is a token or client certificate and when it expires.
+type credential struct {
+	token  string
+	cert   *tls.Certificate
+	expiry time.Time
+}
+
+// kubeconfig is the subset of the kubeconfig file format eidos supports.
+type kubeconfig struct {
+	CurrentContext string              `yaml:"current-context"`
+	Clusters       []kubeconfigCluster `yaml:"clusters"`
+	Users          []kubeconfigUser    `yaml:"users"`
+	Contexts       []kubeconfigContext `yaml:"contexts"`
+}
+
+type kubeconfigCluster struct {
+	Name string `yaml:"name"`
+	// dir is the directory of the file defining the entry, relative paths are
+	// resolved against it.
+	dir     string
+	Cluster struct {
+		Server                   string `yaml:"server"`
+		CertificateAuthority     string `yaml:"certificate-authority"`
+		CertificateAuthorityData string `yaml:"certificate-authority-data"`
+		InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		TLSServerName            string `yaml:"tls-server-name"`
+	} `yaml:"cluster"`
+}
+
+type kubeconfigUser struct {
+	Name string `yaml:"name"`
+	dir  string
+	User struct {
+		Token                 string      `yaml:"token"`
+		TokenFile             string      `yaml:"tokenFile"`
+		ClientCertificate     string      `yaml:"client-certificate"`
+		ClientCertificateData string      `yaml:"client-certificate-data"`
+		ClientKey             string      `yaml:"client-key"`
+		ClientKeyData         string      `yaml:"client-key-data"`
+		Username              string      `yaml:"username"`
+		Password              string      `yaml:"password"`
+		Exec                  *execConfig `yaml:"exec"`
+		AuthProvider          any         `yaml:"auth-provider"`
+	} `yaml:"user"`
+}
+
+type kubeconfigContext struct {
+	Name    string `yaml:"name"`
+	Context struct {
+		Cluster   string `yaml:"cluster"`
+		User      string `yaml:"user"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"context"`
+}
+
+// execConfig runs a credential plugin, e.g. for EKS or GKE.
+type execConfig struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args"`
+	Env        []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+}
+
+// LoadConfig loads the configuration of a kubeconfig context, the current
+// context when empty. Without a path, the files of $KUBECONFIG are merged as
+// kubectl does, or ~/.kube/config is used, and the in-cluster service account
+// when running in a pod without either.
+func LoadConfig(path, context string) (*Config, error) {
+	paths := []string{path}
+	if path == "" {
+		paths = defaultKubeconfigs()
+	}
+	if len(paths) == 0 {
+		if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+			return inClusterConfig()
+		}
+		return nil, errors.New("no kubeconfig found, set --kubeconfig or $KUBECONFIG")
+	}
+
+	var kc kubeconfig
+	for _, p := range paths {
+		if err := kc.merge(p); err != nil {
+			return nil, err
+		}
+	}
+	return kc.config(context)
+}
+
+// defaultKubeconfigs returns the existing files of $KUBECONFIG, or
+// ~/.kube/config if none exists.
+func defaultKubeconfigs() []string {
+	var paths []string
+	for _, p := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if _, err := os.Stat(p); err == nil && !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) > 0 {
+		return paths
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		p := filepath.Join(home, ".kube", "config")
+		if _, err := os.Stat(p); err == nil {
+			return []string{p}
+		}
+	}
+	return nil
+}
+
+// merge adds the entries of a kubeconfig file like kubectl merges $KUBECONFIG:
+// the first file setting the current context or defining a named cluster,
+// user or context wins.
+func (kc *kubeconfig) merge(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var f kubeconfig
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	if kc.CurrentContext == "" {
+		kc.CurrentContext = f.CurrentContext
+	}
+	for _, c := range f.Clusters {
+		if !slices.ContainsFunc(kc.Clusters, func(e kubeconfigCluster) bool { return e.Name == c.Name }) {
+			c.dir = dir
+			kc.Clusters = append(kc.Clusters, c)
+		}
+	}
+	for _, u := range f.Users {
+		if !slices.ContainsFunc(kc.Users, func(e kubeconfigUser) bool { return e.Name == u.Name }) {
+			u.dir = dir
+			kc.Users = append(kc.Users, u)
+		}
+	}
+	for _, c := range f.Contexts {
+		if !slices.ContainsFunc(kc.Contexts, func(e kubeconfigContext) bool { return e.Name == c.Name }) {
+			kc.Contexts = append(kc.Contexts, c)
+		}
+	}
+	return nil
+}
+
+func (kc *kubeconfig) config(name string) (*Config, error) {
+	if name == "" {
+		name = kc.CurrentContext
+	}
+	if name == "" {
+		return nil, errors.New("kubeconfig has no current context, set --context")
+	}
+
+	cfg := &Config{TLS: &tls.Config{MinVersion: tls.VersionTLS12}}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == name {
+			clusterName, userName, cfg.Namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", name)
+	}
+
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		cfg.Server = strings.TrimSuffix(c.Cluster.Server, "/")
+		cfg.TLS.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		cfg.TLS.ServerName = c.Cluster.TLSServerName
+		ca, err := dataOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, c.dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate authority of cluster %q: %w", clusterName, err)
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("invalid certificate authority of cluster %q", clusterName)
+			}
+			cfg.TLS.RootCAs = pool
+		}
+	}
+	if !found || cfg.Server == "" {
+		return nil, fmt.Errorf("cluster %q not found in kubeconfig", clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		user := u.User
+		if user.AuthProvider != nil {
+			return nil, fmt.Errorf("auth-provider of user %q is not supported, use an exec credential plugin", userName)
+		}
+
+		cert, err := dataOrFile(user.ClientCertificateData, user.ClientCertificate, u.dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate of user %q: %w", userName, err)
+		}
+		key, err := dataOrFile(user.ClientKeyData, user.ClientKey, u.dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client key of user %q: %w", userName, err)
+		}
+		if cert != nil || key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate of user %q: %w", userName, err)
+			}
+			cfg.TLS.Certificates = []tls.Certificate{pair}
+		}
+
+		cfg.Token, cfg.Username, cfg.Password = user.Token, user.Username, user.Password
+		switch {
+		case user.Exec != nil:
+			plugin := user.Exec
+			cfg.refresh = func() (*credential, error) {
+				cred, err := plugin.credentials()
+				if err != nil {
+					return nil, fmt.Errorf("failed to get credentials of user %q: %w", userName, err)
+				}
+				return cred, nil
+			}
+		case user.TokenFile != "":
+			path := resolve(user.TokenFile, u.dir)
+			cfg.refresh = func() (*credential, error) {
+				cred, err := tokenFileCredential(path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read token of user %q: %w", userName, err)
+				}
+				return cred, nil
+			}
+		}
+		if err := cfg.setCredential(); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// setCredential sets the initial credentials of a refreshable configuration.
+func (cfg *Config) setCredential() error {
+	if cfg.refresh == nil {
+		return nil
+	}
+	cred, err := cfg.refresh()
+	if err != nil {
+		return err
+	}
+	cfg.Token, cfg.expiry = cred.token, cred.expiry
+	if cred.cert != nil {
+		cfg.TLS.Certificates = []tls.Certificate{*cred.cert}
+	}
+	return nil
+}
+
+// credentials runs the credential plugin and returns the token or client
+// certificate it issued, with their expiration time if the plugin sets one.
+func (e *execConfig) credentials() (*credential, error) {
+	apiVersion := e.APIVersion
+	if apiVersion == "" {
+		apiVersion = "client.authentication.k8s.io/v1"
+	}
+	info, err := json.Marshal(map[string]any{
+		"apiVersion": apiVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]any{"interactive": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(e.Command, e.Args...)
+	cmd.Env = append(os.Environ(), "KUBERNETES_EXEC_INFO="+string(info))
+	for _, env := range e.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", e.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var ec struct {
+		Status struct {
+			Token                 string    `json:"token"`
+			ClientCertificateData string    `json:"clientCertificateData"`
+			ClientKeyData         string    `json:"clientKeyData"`
+			ExpirationTimestamp   time.Time `json:"expirationTimestamp"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(out, &ec); err != nil {
+		return nil, fmt.Errorf("invalid ExecCredential from %s: %w", e.Command, err)
+	}
+	s := ec.Status
+	cred := &credential{token: s.Token, expiry: s.ExpirationTimestamp}
+	if s.ClientCertificateData != "" {
+		pair, err := tls.X509KeyPair([]byte(s.ClientCertificateData), []byte(s.ClientKeyData))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate from %s: %w", e.Command, err)
+		}
+		cred.cert = &pair
+	}
+	return cred, nil
+}
+
+// tokenFileCredential reads a token file, which is read again after
+// tokenFileRefresh as it may be rotated.
+func tokenFileCredential(path string) (*credential, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &credential{
+		token:  strings.TrimSpace(string(b)),
+		expiry: time.Now().Add(tokenFileRefresh),
+	}, nil
+}
+
+func inClusterConfig() (*Config, error) {
+	ca, err := os.ReadFile(inClusterCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid service account CA")
+	}
+	ns, _ := os.ReadFile(inClusterNSFile)
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	cfg := &Config{
+		Server:    "https://" + host + ":" + port,
+		Namespace: strings.TrimSpace(string(ns)),
+		TLS:       &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool},
+		refresh: func() (*credential, error) {
+			cred, err := tokenFileCredential(inClusterTokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read service account token: %w", err)
+			}
+			return cred, nil
+		},
+	}
+	if err := cfg.setCredential(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// dataOrFile returns the base64 decoded data or the content of the file,
+// relative to the kubeconfig directory. Both empty returns nil.
+func dataOrFile(data, file, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(resolve(file, dir))
+	}
+	return nil, nil
+}
+
+func resolve(path, dir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,285 @@
+package k8s_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/k8s"
+)
+
+func writeKubeconfig(t *testing.T, server, ca string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+    namespace: eidos
+- name: other
+  context:
+    cluster: missing
+    user: admin
+users:
+- name: admin
+  user:
+    token: secret-token
+`, server, ca)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"kind":"Status","message":"Unauthorized","reason":"Unauthorized","code":401}`)
+			return
+		}
+		if r.URL.Path != "/api/v1/nodes" || r.URL.Query().Get("labelSelector") != "gpu=true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		fmt.Fprint(w, `{"items":[{"metadata":{"name":"gpu-1"},"status":{"conditions":[{"type":"Ready","status":"True"}]}}]}`)
+	}))
+	defer srv.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	path := writeKubeconfig(t, srv.URL, base64.StdEncoding.EncodeToString(ca))
+
+	cfg, err := k8s.LoadConfig(path, "")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Namespace() != "eidos" {
+		t.Errorf("namespace = %q", client.Namespace())
+	}
+
+	nodes, err := client.ListNodes(context.Background(), "gpu=true")
+	if err != nil {
+		t.Fatalf("ListNodes() error = %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Metadata.Name != "gpu-1" || !nodes[0].Ready() {
+		t.Errorf("nodes = %+v", nodes)
+	}
+
+	// Requests are rejected with the server's status message
+	cfg.Token = "wrong"
+	client, _ = k8s.NewClient(cfg)
+	if _, err := client.ListNodes(context.Background(), ""); err == nil || err.Error() != "failed to list nodes: Unauthorized (401 Unauthorized)" {
+		t.Errorf("ListNodes() error = %v", err)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	path := writeKubeconfig(t, "https://127.0.0.1:6443", "")
+
+	tests := []struct {
+		name    string
+		context string
+	}{
+		{"unknown context", "staging"},
+		{"unknown cluster", "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := k8s.LoadConfig(path, tt.context); err == nil {
+				t.Error("LoadConfig() succeeded")
+			}
+		})
+	}
+
+	if _, err := k8s.LoadConfig(filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Error("LoadConfig() of a missing file succeeded")
+	}
+}
+
+func TestLoadConfig_MergesKubeconfigEnv(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	if err := os.WriteFile(first, []byte(`current-context: prod
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+users:
+- name: admin
+  user:
+    token: first-token
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(`current-context: other
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443
+users:
+- name: admin
+  user:
+    token: second-token
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", strings.Join([]string{filepath.Join(dir, "missing"), first, second}, string(os.PathListSeparator)))
+
+	cfg, err := k8s.LoadConfig("", "")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	// The first file defining an entry wins
+	if cfg.Server != "https://prod.example.com:6443" || cfg.Token != "first-token" {
+		t.Errorf("server = %q, token = %q", cfg.Server, cfg.Token)
+	}
+}
+
+// writeExecKubeconfig writes a kubeconfig whose user runs a credential plugin
+// issuing token-1, token-2... expiring at expiry, and returns its path and the
+// file counting the plugin runs.
+func writeExecKubeconfig(t *testing.T, server, ca, expiry string) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("credential plugin is a shell script")
+	}
+	dir := t.TempDir()
+	count := filepath.Join(dir, "count")
+	plugin := filepath.Join(dir, "plugin.sh")
+	script := `#!/bin/sh
+n=$(($(cat "$COUNT" 2>/dev/null || echo 0) + 1))
+echo $n > "$COUNT"
+printf '{"apiVersion":"client.authentication.k8s.io/v1","kind":"ExecCredential","status":{"token":"token-%d","expirationTimestamp":"%s"}}' $n "$EXPIRY"
+`
+	if err := os.WriteFile(plugin, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "config")
+	content := fmt.Sprintf(`current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: plugin
+users:
+- name: plugin
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: %s
+      env:
+      - name: COUNT
+        value: %s
+      - name: EXPIRY
+        value: %q
+`, server, ca, plugin, count, expiry)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, count
+}
+
+func TestClient_RefreshesExecCredentials(t *testing.T) {
+	// The server only accepts the token issued last
+	var valid atomic.Value
+	valid.Store("token-1")
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+valid.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"items":[]}`)
+	}))
+	defer srv.Close()
+	ca := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	runs := func(t *testing.T, count string) string {
+		t.Helper()
+		b, err := os.ReadFile(count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(b))
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		valid.Store("token-1")
+		path, count := writeExecKubeconfig(t, srv.URL, ca, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		cfg, err := k8s.LoadConfig(path, "")
+		if err != nil {
+			t.Fatalf("LoadConfig() error = %v", err)
+		}
+		client, err := k8s.NewClient(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.ListNodes(context.Background(), ""); err != nil {
+			t.Fatalf("ListNodes() error = %v", err)
+		}
+
+		// The token is revoked, the request is retried with a new one
+		valid.Store("token-2")
+		if _, err := client.ListNodes(context.Background(), ""); err != nil {
+			t.Fatalf("ListNodes() after revocation error = %v", err)
+		}
+		if n := runs(t, count); n != "2" {
+			t.Errorf("plugin ran %s times, want 2", n)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		valid.Store("token-1")
+		path, count := writeExecKubeconfig(t, srv.URL, ca, "2000-01-01T00:00:00Z")
+		cfg, err := k8s.LoadConfig(path, "")
+		if err != nil {
+			t.Fatalf("LoadConfig() error = %v", err)
+		}
+		client, err := k8s.NewClient(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Expired tokens are refreshed before each request
+		valid.Store("token-2")
+		if _, err := client.ListNodes(context.Background(), ""); err != nil {
+			t.Fatalf("ListNodes() error = %v", err)
+		}
+		valid.Store("token-3")
+		if _, err := client.ListNodes(context.Background(), ""); err != nil {
+			t.Fatalf("ListNodes() error = %v", err)
+		}
+		if n := runs(t, count); n != "3" {
+			t.Errorf("plugin ran %s times, want 3", n)
+		}
+	})
+}
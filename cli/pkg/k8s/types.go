@@ -0,0 +1,196 @@
+package k8s
+
+// The API types are the subset of the Kubernetes core/v1 and batch/v1 fields
+// eidos reads or sets.
+
+// ObjectMeta is the metadata of an object.
+type ObjectMeta struct {
+	Name              string            `json:"name,omitempty"`
+	GenerateName      string            `json:"generateName,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+}
+
+// Condition is the condition of a node or job.
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Node is a cluster node.
+type Node struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Unschedulable bool `json:"unschedulable,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []Condition `json:"conditions,omitempty"`
+	} `json:"status"`
+}
+
+// Ready returns whether the node reports the Ready condition.
+func (n *Node) Ready() bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == "Ready" {
+			return c.Status == "True"
+		}
+	}
+	return false
+}
+
+// NodeList is a list of nodes.
+type NodeList struct {
+	Items []Node `json:"items"`
+}
+
+// Job runs pods to completion.
+type Job struct {
+	APIVersion string     `json:"apiVersion,omitempty"`
+	Kind       string     `json:"kind,omitempty"`
+	Metadata   ObjectMeta `json:"metadata"`
+	Spec       JobSpec    `json:"spec"`
+	Status     JobStatus  `json:"status,omitempty"`
+}
+
+// JobSpec is the specification of a job.
+type JobSpec struct {
+	BackoffLimit            *int32          `json:"backoffLimit,omitempty"`
+	ActiveDeadlineSeconds   *int64          `json:"activeDeadlineSeconds,omitempty"`
+	TTLSecondsAfterFinished *int32          `json:"ttlSecondsAfterFinished,omitempty"`
+	Template                PodTemplateSpec `json:"template"`
+}
+
+// JobStatus is the status of a job.
+type JobStatus struct {
+	Active     int32       `json:"active,omitempty"`
+	Succeeded  int32       `json:"succeeded,omitempty"`
+	Failed     int32       `json:"failed,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// Finished returns whether the job completed or failed, and the failure
+// condition if it failed.
+func (s *JobStatus) Finished() (bool, *Condition) {
+	for i, c := range s.Conditions {
+		if c.Status != "True" {
+			continue
+		}
+		switch c.Type {
+		case "Complete":
+			return true, nil
+		case "Failed":
+			return true, &s.Conditions[i]
+		}
+	}
+	return false, nil
+}
+
+// PodTemplateSpec is the template of the pods of a job.
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `json:"metadata,omitempty"`
+	Spec     PodSpec    `json:"spec"`
+}
+
+// PodSpec is the specification of a pod.
+type PodSpec struct {
+	NodeName                      string       `json:"nodeName,omitempty"`
+	RestartPolicy                 string       `json:"restartPolicy,omitempty"`
+	HostPID                       bool         `json:"hostPID,omitempty"`
+	HostNetwork                   bool         `json:"hostNetwork,omitempty"`
+	ServiceAccountName            string       `json:"serviceAccountName,omitempty"`
+	AutomountServiceAccountToken  *bool        `json:"automountServiceAccountToken,omitempty"`
+	TerminationGracePeriodSeconds *int64       `json:"terminationGracePeriodSeconds,omitempty"`
+	PriorityClassName             string       `json:"priorityClassName,omitempty"`
+	ImagePullSecrets              []LocalRef   `json:"imagePullSecrets,omitempty"`
+	Tolerations                   []Toleration `json:"tolerations,omitempty"`
+	Containers                    []Container  `json:"containers"`
+	Volumes                       []Volume     `json:"volumes,omitempty"`
+}
+
+// LocalRef references an object in the same namespace.
+type LocalRef struct {
+	Name string `json:"name"`
+}
+
+// Toleration tolerates node taints.
+type Toleration struct {
+	Key      string `json:"key,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Effect   string `json:"effect,omitempty"`
+}
+
+// Container is a container of a pod.
+type Container struct {
+	Name            string           `json:"name"`
+	Image           string           `json:"image"`
+	ImagePullPolicy string           `json:"imagePullPolicy,omitempty"`
+	Args            []string         `json:"args,omitempty"`
+	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
+	VolumeMounts    []VolumeMount    `json:"volumeMounts,omitempty"`
+}
+
+// SecurityContext is the security configuration of a container.
+type SecurityContext struct {
+	Privileged *bool `json:"privileged,omitempty"`
+}
+
+// VolumeMount mounts a volume into a container.
+type VolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// Volume is a volume of a pod.
+type Volume struct {
+	Name     string          `json:"name"`
+	HostPath *HostPathSource `json:"hostPath,omitempty"`
+}
+
+// HostPathSource is a host path volume.
+type HostPathSource struct {
+	Path string `json:"path"`
+}
+
+// Pod is a pod.
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Status   struct {
+		Phase             string            `json:"phase,omitempty"`
+		Reason            string            `json:"reason,omitempty"`
+		Message           string            `json:"message,omitempty"`
+		ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty"`
+	} `json:"status"`
+}
+
+// ContainerStatus is the status of a container of a pod.
+type ContainerStatus struct {
+	Name  string `json:"name"`
+	State struct {
+		Waiting *struct {
+			Reason  string `json:"reason,omitempty"`
+			Message string `json:"message,omitempty"`
+		} `json:"waiting,omitempty"`
+		Terminated *struct {
+			ExitCode int32  `json:"exitCode"`
+			Reason   string `json:"reason,omitempty"`
+		} `json:"terminated,omitempty"`
+	} `json:"state"`
+}
+
+// PodList is a list of pods.
+type PodList struct {
+	Items []Pod `json:"items"`
+}
+
+// Status is the error response of the API server.
+type Status struct {
+	Message string `json:"message,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Code    int    `json:"code,omitempty"`
+}
@@ -0,0 +1,217 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenOp
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+// operators are ordered so that longer operators are matched first.
+var operators = []string{"==", "!=", "=~", "!~", "<=", ">=", "=", "<", ">"}
+
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) tokenize() error {
+	s := p.expr
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case strings.HasPrefix(s[i:], "&&"):
+			p.tokens = append(p.tokens, token{tokenAnd, "&&", i})
+			i += 2
+		case c == ',':
+			p.tokens = append(p.tokens, token{tokenAnd, ",", i})
+			i++
+		case strings.HasPrefix(s[i:], "||"):
+			p.tokens = append(p.tokens, token{tokenOr, "||", i})
+			i += 2
+		case c == '(':
+			p.tokens = append(p.tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			p.tokens = append(p.tokens, token{tokenRParen, ")", i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return fmt.Errorf("unterminated quote at offset %d", i)
+			}
+			p.tokens = append(p.tokens, token{tokenWord, s[i+1 : i+1+end], i})
+			i += end + 2
+		default:
+			if op := operatorAt(s[i:]); op != "" {
+				p.tokens = append(p.tokens, token{tokenOp, op, i})
+				i += len(op)
+				continue
+			}
+			if c == '!' {
+				p.tokens = append(p.tokens, token{tokenNot, "!", i})
+				i++
+				continue
+			}
+			start := i
+			for i < len(s) && !isDelimiter(s[i:]) {
+				i++
+			}
+			p.tokens = append(p.tokens, token{tokenWord, s[start:i], start})
+		}
+	}
+	return nil
+}
+
+func operatorAt(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isDelimiter(s string) bool {
+	switch s[0] {
+	case ' ', '\t', '\n', '(', ')', ',', '"', '\'', '!':
+		return true
+	}
+	return strings.HasPrefix(s, "&&") || strings.HasPrefix(s, "||") || operatorAt(s) != ""
+}
+
+func (p *parser) peek() *token {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *parser) next() *token {
+	t := p.peek()
+	if t != nil {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t != nil && t.kind == tokenOr; t = p.peek() {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t != nil && t.kind == tokenAnd; t = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.next()
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	switch t.kind {
+	case tokenNot:
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expr}, nil
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r == nil || r.kind != tokenRParen {
+			return nil, fmt.Errorf("missing ) for ( at offset %d", t.offset)
+		}
+		return expr, nil
+	case tokenWord:
+		return p.parseComparison(t)
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.offset)
+	}
+}
+
+func (p *parser) parseComparison(field *token) (node, error) {
+	n := cmpNode{path: strings.Split(field.text, ".")}
+	for _, s := range n.path {
+		if s == "" {
+			return nil, fmt.Errorf("invalid field %q at offset %d", field.text, field.offset)
+		}
+	}
+
+	op := p.peek()
+	if op == nil || op.kind != tokenOp {
+		return n, nil
+	}
+	p.pos++
+
+	value := p.next()
+	if value == nil || value.kind != tokenWord {
+		return nil, fmt.Errorf("missing value after %q at offset %d", op.text, op.offset)
+	}
+	n.op = op.text
+	n.value = value.text
+
+	switch n.op {
+	case "=~", "!~":
+		re, err := regexp.Compile(n.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", n.value, err)
+		}
+		n.re = re
+	case "<", "<=", ">", ">=":
+		f, err := strconv.ParseFloat(n.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s requires a number, got %q", n.op, n.value)
+		}
+		n.number = f
+	}
+	return n, nil
+}
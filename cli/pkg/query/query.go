@@ -0,0 +1,230 @@
+// Package query implements a small expression language to select snapshot
+// configurations by type and field.
+//
+// An expression compares fields of a configuration with values:
+//
+//	Type=Sysctl && Key=/proc/sys/vm/*
+//	Type=SystemD && Properties.ActiveState!=active
+//	Type=Network && (MTU>=9000 || Driver=~^mlx5)
+//
+// Field paths are dotted and case-insensitive. Paths that don't start with Type
+// or Data are looked up in the configuration data, lists match when any element
+// matches. A path without an operator matches when the field is present and
+// not empty.
+//
+// Operators:
+//
+//	= ==     glob match (path.Match syntax)
+//	!=       no glob match
+//	=~ !~    regular expression match / no match
+//	< <= > >= numeric comparison
+//
+// Comparisons can be combined with && (or ","), || and !, and grouped with
+// parentheses. Values containing spaces or operator characters can be quoted
+// with single or double quotes.
+package query
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// Query is a parsed expression.
+type Query struct {
+	expr string
+	root node
+}
+
+// Parse parses a query expression.
+func Parse(expr string) (*Query, error) {
+	p := &parser{expr: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.tokens[p.pos].text, p.tokens[p.pos].offset)
+	}
+	return &Query{expr: expr, root: root}, nil
+}
+
+// String returns the expression the query was parsed from.
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Match reports whether the configuration matches the query.
+func (q *Query) Match(c collectors.Configuration) bool {
+	return q.root.eval(c)
+}
+
+// Filter returns the configurations matching the query, in their original order.
+func (q *Query) Filter(configs []collectors.Configuration) []collectors.Configuration {
+	res := make([]collectors.Configuration, 0, len(configs))
+	for _, c := range configs {
+		if q.Match(c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+type node interface {
+	eval(c collectors.Configuration) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(c collectors.Configuration) bool { return n.left.eval(c) && n.right.eval(c) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(c collectors.Configuration) bool { return n.left.eval(c) || n.right.eval(c) }
+
+type notNode struct{ expr node }
+
+func (n notNode) eval(c collectors.Configuration) bool { return !n.expr.eval(c) }
+
+// cmpNode compares the values of a field path. With multiple values, e.g. from
+// a list, the comparison matches if any value matches.
+type cmpNode struct {
+	path   []string
+	op     string
+	value  string
+	re     *regexp.Regexp
+	number float64
+}
+
+func (n cmpNode) eval(c collectors.Configuration) bool {
+	values := lookup(c, n.path)
+
+	switch n.op {
+	case "":
+		for _, v := range values {
+			if v != "" {
+				return true
+			}
+		}
+		return false
+	case "!=":
+		return !n.any(values, n.glob)
+	case "!~":
+		return !n.any(values, n.re.MatchString)
+	case "=~":
+		return n.any(values, n.re.MatchString)
+	case "<", "<=", ">", ">=":
+		return n.any(values, n.compare)
+	default:
+		return n.any(values, n.glob)
+	}
+}
+
+func (n cmpNode) any(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n cmpNode) glob(v string) bool {
+	ok, err := path.Match(n.value, v)
+	return err == nil && ok
+}
+
+func (n cmpNode) compare(v string) bool {
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return false
+	}
+	switch n.op {
+	case "<":
+		return f < n.number
+	case "<=":
+		return f <= n.number
+	case ">":
+		return f > n.number
+	default:
+		return f >= n.number
+	}
+}
+
+// lookup returns the string values at the path of a configuration.
+func lookup(c collectors.Configuration, p []string) []string {
+	if strings.EqualFold(p[0], "Type") && len(p) == 1 {
+		return []string{c.Type}
+	}
+	if strings.EqualFold(p[0], "Data") {
+		p = p[1:]
+	}
+
+	var res []string
+	collect(reflect.ValueOf(c.Data), p, &res)
+	return res
+}
+
+func collect(v reflect.Value, p []string, res *[]string) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	// Lists match on any element
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		if len(p) == 0 && v.Type().Elem().Kind() == reflect.Uint8 {
+			*res = append(*res, fmt.Sprint(v.Interface()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			collect(v.Index(i), p, res)
+		}
+		return
+	}
+
+	if len(p) == 0 {
+		if v.IsValid() && v.CanInterface() && v.Kind() != reflect.Struct && v.Kind() != reflect.Map {
+			*res = append(*res, fmt.Sprint(v.Interface()))
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f := v.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, p[0]) })
+		if f.IsValid() {
+			collect(f, p[1:], res)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		// Exact keys first, decoded YAML snapshots use lower case keys
+		if e := v.MapIndex(reflect.ValueOf(p[0]).Convert(v.Type().Key())); e.IsValid() {
+			collect(e, p[1:], res)
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if strings.EqualFold(iter.Key().String(), p[0]) {
+				collect(iter.Value(), p[1:], res)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package query_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/query"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+var configs = []collectors.Configuration{
+	{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "60"}},
+	{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/overcommit_memory", Value: "1"}},
+	{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/kernel/pid_max", Value: "4194304"}},
+	{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+	{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+		Unit:       "kubelet.service",
+		Properties: map[string]any{"ActiveState": "failed", "Restart": "always"},
+	}},
+	{Type: collectors.RDMAType, Data: collectors.RDMADeviceConfig{
+		Name: "mlx5_0",
+		Ports: []collectors.RDMAPortConfig{
+			{Port: 1, State: "ACTIVE"},
+			{Port: 2, State: "DOWN"},
+		},
+	}},
+	{Type: collectors.NetworkType, Data: collectors.NetworkInterfaceConfig{Name: "eth0", MTU: 9000, Driver: "mlx5_core"}},
+}
+
+// names returns a short identifier of each configuration.
+func names(configs []collectors.Configuration) string {
+	var res []string
+	for _, c := range configs {
+		switch d := c.Data.(type) {
+		case collectors.SysctlConfig:
+			res = append(res, d.Key[strings.LastIndex(d.Key, "/")+1:])
+		case collectors.KModConfig:
+			res = append(res, d.Name)
+		case collectors.SystemDConfig:
+			res = append(res, d.Unit)
+		case collectors.RDMADeviceConfig:
+			res = append(res, d.Name)
+		case collectors.NetworkInterfaceConfig:
+			res = append(res, d.Name)
+		default:
+			res = append(res, c.Type)
+		}
+	}
+	return strings.Join(res, ",")
+}
+
+func TestQuery_Filter(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"Type=Sysctl && Key=/proc/sys/vm/*", "swappiness,overcommit_memory"},
+		{"type=sysctl", ""},
+		{"Type=Sysctl, Data.Value>1000", "pid_max"},
+		{"Type=KMod || Type=SystemD", "nvidia,kubelet.service"},
+		{"Properties.ActiveState!=active && Type==SystemD", "kubelet.service"},
+		{"properties.restart", "kubelet.service"},
+		{"Ports.State=DOWN", "mlx5_0"},
+		{"Ports.Port>=3", ""},
+		{"Type=Network && (MTU>=9000 || Driver=~^mlx5)", "eth0"},
+		{"!(Type=Sysctl) && !Type=RDMA && Name!=eth0", "nvidia,kubelet.service"},
+		{`Key=~"kernel/(pid|threads)"`, "pid_max"},
+		{"Name && Name!~^mlx", "nvidia,eth0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := query.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := names(q.Filter(configs)); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQuery_DecodedSnapshot(t *testing.T) {
+	// Snapshots read from YAML use lower case keys
+	yamlSnapshot := `
+- type: SystemD
+  data:
+    unit: kubelet.service
+    properties:
+      ActiveState: active
+- type: Sysctl
+  data:
+    key: /proc/sys/vm/swappiness
+    value: "60"
+`
+	s, err := snapshot.Decode(strings.NewReader(yamlSnapshot))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	q, err := query.Parse("Properties.ActiveState=active || Value<100")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := q.Filter(s.Items); len(got) != 2 {
+		t.Errorf("expected 2 matches, got %d", len(got))
+	}
+}
+
+func TestQuery_SignedSnapshot(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	q, err := query.Parse("Type=Sysctl")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// filter returns the JSON document of a signed snapshot filtered by q, signed
+	// again with key if it isn't nil.
+	filter := func(key ed25519.PrivateKey) []byte {
+		t.Helper()
+		s := snapshot.New(configs)
+		if err := s.Sign(priv); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		s.SetItems(q.Filter(s.Items))
+		if key != nil {
+			if err := s.Sign(key); err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+		}
+		var buf bytes.Buffer
+		if err := serializers.NewWriter(serializers.FormatJSON, &buf).Serialize(s); err != nil {
+			t.Fatalf("Serialize failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	// The original signature doesn't cover the filtered items and is dropped
+	if _, err := snapshot.Verify(filter(nil), pub); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("expected unsigned result, got %v", err)
+	}
+
+	s, err := snapshot.Verify(filter(priv), pub)
+	if err != nil {
+		t.Fatalf("Verify of the signed result failed: %v", err)
+	}
+	if len(s.Items) != 3 {
+		t.Errorf("expected 3 sysctls, got %d", len(s.Items))
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"Type=",
+		"Type=Sysctl &&",
+		"(Type=Sysctl",
+		"Type=Sysctl)",
+		"Value>abc",
+		"Key=~(",
+		`Key="unterminated`,
+		"Data..Key=x",
+	} {
+		if _, err := query.Parse(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
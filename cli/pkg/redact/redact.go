@@ -0,0 +1,253 @@
+// Package redact masks secrets in snapshot configurations before they are
+// serialized.
+package redact
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+// Rule describes values to redact. A rule has either a Key or a Pattern.
+type Rule struct {
+	// Name identifies the rule in the redaction report.
+	Name string
+	// Key is a regular expression matched against names: map keys, struct
+	// field names, the Key of key/value configurations and the NAME of NAME=VALUE
+	// assignments in strings, e.g. environment variables and command line
+	// options. The whole value of a matching name is redacted.
+	Key string
+	// Pattern is a regular expression matched against string values. If it has
+	// a capture group, only the first group is redacted, otherwise the whole match.
+	Pattern string
+}
+
+type compiledRule struct {
+	name    string
+	key     *regexp.Regexp
+	pattern *regexp.Regexp
+}
+
+// assignmentRe matches NAME=VALUE assignments in strings, with optionally
+// quoted values.
+var assignmentRe = regexp.MustCompile(`([A-Za-z0-9_.-]+)=("[^"]*"|'[^']*'|[^\s"',;]+)`)
+
+// Redactor masks secrets in configurations and counts the redacted values.
+// It is safe for concurrent use.
+type Redactor struct {
+	rules []compiledRule
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// Report is the number of values redacted in total and by rule name.
+type Report struct {
+	Total int
+	Rules map[string]int
+}
+
+// New creates a redactor with the given rules, usually DefaultRules followed by
+// user defined rules.
+func New(rules ...Rule) (*Redactor, error) {
+	r := &Redactor{counts: make(map[string]int)}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if (rule.Key == "") == (rule.Pattern == "") {
+			return nil, fmt.Errorf("redaction rule %s must have either a key or a pattern", rule.Name)
+		}
+
+		c := compiledRule{name: rule.Name}
+		var err error
+		if rule.Key != "" {
+			c.key, err = regexp.Compile(rule.Key)
+		} else {
+			c.pattern, err = regexp.Compile(rule.Pattern)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %s: %w", rule.Name, err)
+		}
+		r.rules = append(r.rules, c)
+	}
+	return r, nil
+}
+
+// Redact returns the configuration with secrets masked. The configuration passed
+// in is not modified.
+func (r *Redactor) Redact(c collectors.Configuration) collectors.Configuration {
+	counts := make(map[string]int)
+	if c.Data != nil {
+		c.Data = r.value(reflect.ValueOf(c.Data), "", counts).Interface()
+	}
+
+	if len(counts) > 0 {
+		r.mu.Lock()
+		for name, n := range counts {
+			r.counts[name] += n
+		}
+		r.mu.Unlock()
+	}
+	return c
+}
+
+// Report returns the number of values redacted so far.
+func (r *Redactor) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := Report{Rules: make(map[string]int, len(r.counts))}
+	for name, n := range r.counts {
+		rep.Rules[name] = n
+		rep.Total += n
+	}
+	return rep
+}
+
+// String formats the report for logs, e.g. "3 (secret-key-names=2, jwt=1)".
+func (rep Report) String() string {
+	names := make([]string, 0, len(rep.Rules))
+	for name := range rep.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := fmt.Sprint(rep.Total)
+	for i, name := range names {
+		sep := ", "
+		if i == 0 {
+			sep = " ("
+		}
+		s += fmt.Sprintf("%s%s=%d", sep, name, rep.Rules[name])
+	}
+	if len(names) > 0 {
+		s += ")"
+	}
+	return s
+}
+
+// value returns a redacted copy of v. name is the map key or field name v was
+// found under.
+func (r *Redactor) value(v reflect.Value, name string, counts map[string]int) reflect.Value {
+	switch v.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(r.redactString(v.String(), name, counts)).Convert(v.Type())
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.New(v.Type()).Elem()
+		res.Set(r.value(v.Elem(), name, counts))
+		return res
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.New(v.Type().Elem())
+		res.Elem().Set(r.value(v.Elem(), name, counts))
+		return res
+	case reflect.Struct:
+		res := reflect.New(v.Type()).Elem()
+		res.Set(v)
+		// Key/value configurations (sysctl, grub, ...) are named by their Key
+		key := v.FieldByName("Key")
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fieldName := f.Name
+			if f.Name == "Value" && key.IsValid() && key.Kind() == reflect.String {
+				fieldName = key.String()
+			}
+			res.Field(i).Set(r.value(v.Field(i), fieldName, counts))
+		}
+		return res
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			res.SetMapIndex(iter.Key(), r.value(iter.Value(), k, counts))
+		}
+		return res
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		res := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(r.value(v.Index(i), name, counts))
+		}
+		return res
+	case reflect.Array:
+		res := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(r.value(v.Index(i), name, counts))
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) redactString(s, name string, counts map[string]int) string {
+	if s == "" || s == Mask {
+		return s
+	}
+
+	if rule := r.keyRule(name); rule != "" {
+		counts[rule]++
+		return Mask
+	}
+
+	// NAME=VALUE assignments, e.g. environment variables and --option=value
+	s = assignmentRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := assignmentRe.FindStringSubmatch(m)
+		rule := r.keyRule(sub[1])
+		if rule == "" || sub[2] == Mask {
+			return m
+		}
+		counts[rule]++
+		return sub[1] + "=" + Mask
+	})
+
+	for _, rule := range r.rules {
+		if rule.pattern == nil {
+			continue
+		}
+		s = rule.pattern.ReplaceAllStringFunc(s, func(m string) string {
+			sub := rule.pattern.FindStringSubmatchIndex(m)
+			counts[rule.name]++
+			if len(sub) >= 4 && sub[2] >= 0 {
+				return m[:sub[2]] + Mask + m[sub[3]:]
+			}
+			return Mask
+		})
+	}
+	return s
+}
+
+// keyRule returns the name of the first key rule matching name.
+func (r *Redactor) keyRule(name string) string {
+	if name == "" {
+		return ""
+	}
+	for _, rule := range r.rules {
+		if rule.key != nil && rule.key.MatchString(name) {
+			return rule.name
+		}
+	}
+	return ""
+}
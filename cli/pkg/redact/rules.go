@@ -0,0 +1,45 @@
+package redact
+
+// DefaultRules returns the built-in rules for common secret key names and
+// token formats. Patterns only use a capture group to keep the context of the
+// secret, e.g. the user of URL credentials, other groups are non-capturing.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Name: "secret-key-names",
+			Key:  `(?i)(?:passw(?:or)?d|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credential)`,
+		},
+		{
+			Name:    "private-key-block",
+			Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+		},
+		{
+			Name:    "url-credentials",
+			Pattern: `[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`,
+		},
+		{
+			Name:    "bearer-token",
+			Pattern: `(?i)bearer\s+([A-Za-z0-9._~+/=-]{8,})`,
+		},
+		{
+			Name:    "jwt",
+			Pattern: `eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+		},
+		{
+			Name:    "ngc-api-key",
+			Pattern: `nvapi-[A-Za-z0-9_-]{20,}`,
+		},
+		{
+			Name:    "aws-access-key-id",
+			Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+		},
+		{
+			Name:    "github-token",
+			Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`,
+		},
+		{
+			Name:    "slack-token",
+			Pattern: `\bxox[abprs]-[A-Za-z0-9-]{10,}\b`,
+		},
+	}
+}
@@ -0,0 +1,362 @@
+// Package remote snapshots hosts over SSH by streaming the eidos binary to
+// them and running it, with the ssh client of the system like Ansible.
+package remote
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"debug/elf"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/inventory"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// HostLabel is the snapshot label set to the inventory name of the host.
+	HostLabel = "eidos.nvidia.com/host"
+
+	// DefaultConcurrency is the number of hosts snapshotted at a time, the
+	// default number of Ansible forks.
+	DefaultConcurrency = 5
+
+	// DefaultTimeout is the time a host snapshot may take, including copying
+	// eidos.
+	DefaultTimeout = 5 * time.Minute
+
+	connectTimeout = 10
+)
+
+// DefaultArgs are the arguments of eidos on the hosts.
+var DefaultArgs = []string{"snapshot", "--output", "json"}
+
+// machines maps the ELF machines of binaries to the machine names of uname.
+var machines = map[elf.Machine][]string{
+	elf.EM_X86_64:  {"x86_64", "amd64"},
+	elf.EM_AARCH64: {"aarch64", "arm64"},
+	elf.EM_PPC64:   {"ppc64le", "ppc64"},
+	elf.EM_S390:    {"s390x"},
+}
+
+// Result is the outcome of the snapshot of a host.
+type Result struct {
+	Host     string
+	Snapshot *snapshot.Snapshot
+	// Stderr is the log output of eidos on the host.
+	Stderr []byte
+	Err    error
+}
+
+// Runner snapshots inventory hosts over SSH.
+type Runner struct {
+	// Binary is the eidos binary copied to the hosts, the running executable
+	// when empty. It must be a Linux binary of the architecture of the hosts.
+	Binary string
+	// Args are the arguments of eidos, DefaultArgs when nil.
+	Args []string
+	// Become runs eidos with sudo, unless a host sets ansible_become.
+	Become bool
+	// Concurrency is the number of hosts snapshotted at a time,
+	// DefaultConcurrency when zero.
+	Concurrency int
+	// Timeout is the time a host snapshot may take, DefaultTimeout when zero.
+	Timeout time.Duration
+	// Dir is the directory on the hosts eidos is copied to, $TMPDIR or /tmp
+	// when empty. It must not be mounted noexec.
+	Dir string
+	// SSH is the ssh client command, ssh when empty.
+	SSH    string
+	Logger *slog.Logger
+}
+
+// Run snapshots the hosts and returns the results in the order of the hosts.
+// Failed hosts don't stop the others, their results carry the error.
+func (r *Runner) Run(ctx context.Context, hosts []inventory.Host) ([]Result, error) {
+	if r.Logger == nil {
+		r.Logger = slog.Default()
+	}
+	binary := r.Binary
+	if binary == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the eidos binary: %w", err)
+		}
+		binary = exe
+	}
+	bin, err := os.ReadFile(binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the eidos binary: %w", err)
+	}
+	f, err := elf.NewFile(bytes.NewReader(bin))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a Linux binary, set the eidos binary for the hosts", binary)
+	}
+	machine := f.Machine
+
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+
+	r.Logger.Info("snapshotting hosts", slog.Int("hosts", len(hosts)), slog.Int("concurrency", concurrency))
+	results := make([]Result, len(hosts))
+	for i, h := range hosts {
+		results[i].Host = h.Name
+		g.Go(func() error {
+			start := time.Now()
+			snap, stderr, err := r.snapshotHost(ctx, &h, bin, machine)
+			if err != nil {
+				r.Logger.Error("failed to snapshot host", slog.String("host", h.Name), slog.String("error", err.Error()))
+			} else {
+				r.Logger.Info("snapshotted host", slog.String("host", h.Name),
+					slog.Int("items", len(snap.Items)), slog.Duration("duration", time.Since(start)))
+			}
+			results[i].Snapshot, results[i].Stderr, results[i].Err = snap, stderr, err
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results, ctx.Err()
+}
+
+// snapshotHost checks the architecture of the host, streams the binary to a
+// temporary file in Dir and runs it, removing the file when done.
+func (r *Runner) snapshotHost(ctx context.Context, h *inventory.Host, bin []byte, machine elf.Machine) (*snapshot.Snapshot, []byte, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out, err := r.run(ctx, h, "uname -m", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m := strings.TrimSpace(string(out)); !slices.Contains(machines[machine], m) {
+		return nil, nil, fmt.Errorf("host architecture is %s, the eidos binary is built for %s", m, machine)
+	}
+
+	// The file is removed if the copy fails half way
+	template := "-t eidos.XXXXXX"
+	if r.Dir != "" {
+		template = quote(strings.TrimSuffix(r.Dir, "/") + "/eidos.XXXXXX")
+	}
+	out, err = r.run(ctx, h, `umask 077 && f=$(mktemp `+template+`) && trap 'rm -f "$f"' EXIT && `+
+		`cat > "$f" && chmod 700 "$f" && trap - EXIT && echo "$f"`, bytes.NewReader(bin))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to copy eidos: %w", err)
+	}
+	path := strings.TrimSpace(string(out))
+
+	args := r.Args
+	if args == nil {
+		args = DefaultArgs
+	}
+	command := quote(path)
+	for _, a := range args {
+		command += " " + quote(a)
+	}
+
+	var stdin io.Reader
+	if r.become(h) {
+		if password := h.Var("ansible_become_password"); password != "" {
+			command = "sudo -S -p '' " + command
+			stdin = strings.NewReader(password + "\n")
+		} else {
+			command = "sudo -n " + command
+		}
+	}
+	// The shell removes the file when it exits, also when it's hung up or
+	// terminated because the connection is gone
+	command = `f=` + quote(path) + `; trap 'rm -f "$f"' EXIT; trap 'exit 1' HUP INT TERM PIPE; ` + command
+
+	var stderr bytes.Buffer
+	out, err = r.runStderr(ctx, h, command, stdin, &stderr)
+	if err != nil {
+		if ctx.Err() != nil {
+			r.remove(h, path)
+		}
+		return nil, stderr.Bytes(), err
+	}
+
+	snap, err := snapshot.Decode(bytes.NewReader(out))
+	if err != nil {
+		return nil, stderr.Bytes(), err
+	}
+	if snap.Metadata.Labels == nil {
+		snap.Metadata.Labels = make(map[string]string)
+	}
+	snap.Metadata.Labels[HostLabel] = h.Name
+	return snap, stderr.Bytes(), nil
+}
+
+func (r *Runner) become(h *inventory.Host) bool {
+	switch strings.ToLower(h.Var("ansible_become")) {
+	case "true", "yes", "1":
+		return true
+	case "false", "no", "0":
+		return false
+	}
+	return r.Become
+}
+
+// remove removes eidos from the host after a timeout. sshd doesn't signal
+// commands without a terminal when the connection is closed, so the trap of
+// the shell may not run before eidos completes.
+func (r *Runner) remove(h *inventory.Host, path string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*connectTimeout*time.Second)
+	defer cancel()
+	if _, err := r.run(ctx, h, "rm -f "+quote(path), nil); err != nil {
+		r.Logger.Warn("failed to remove eidos from host", slog.String("host", h.Name),
+			slog.String("path", path), slog.String("error", err.Error()))
+	}
+}
+
+// run runs a shell command on the host and returns its output. The error
+// includes the last line of the standard error.
+func (r *Runner) run(ctx context.Context, h *inventory.Host, command string, stdin io.Reader) ([]byte, error) {
+	var stderr bytes.Buffer
+	return r.runStderr(ctx, h, command, stdin, &stderr)
+}
+
+// runStderr is run with the standard error written to stderr.
+func (r *Runner) runStderr(ctx context.Context, h *inventory.Host, command string, stdin io.Reader, stderr *bytes.Buffer) ([]byte, error) {
+	cmd, err := r.command(ctx, h, command)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin = stdin
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err == nil {
+		return out, nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, errors.New("timed out")
+	} else if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if line := lastLine(stderr.Bytes()); line != "" {
+		return nil, fmt.Errorf("%w: %s", err, line)
+	}
+	return nil, err
+}
+
+// command returns the command running a shell command on the host: ssh with
+// the connection variables of the host, sshpass for password authentication
+// and sh for local hosts.
+func (r *Runner) command(ctx context.Context, h *inventory.Host, command string) (*exec.Cmd, error) {
+	if h.Local() {
+		return exec.CommandContext(ctx, "sh", "-c", command), nil
+	}
+
+	ssh := r.SSH
+	if ssh == "" {
+		ssh = "ssh"
+	}
+	args := []string{"-o", "BatchMode=yes", "-o", fmt.Sprintf("ConnectTimeout=%d", connectTimeout)}
+	if user := h.User(); user != "" {
+		args = append(args, "-l", user)
+	}
+	if port := h.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	if key := h.KeyFile(); key != "" {
+		args = append(args, "-i", key)
+	}
+	for _, v := range []string{"ansible_ssh_common_args", "ansible_ssh_extra_args"} {
+		extra, err := inventory.SplitArgs(h.Var(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", v, err)
+		}
+		args = append(args, extra...)
+	}
+	args = append(args, h.Address(), command)
+
+	password := h.Var("ansible_password")
+	if password == "" {
+		return exec.CommandContext(ctx, ssh, args...), nil
+	}
+
+	// Password authentication isn't possible in batch mode
+	sshpass, err := exec.LookPath("sshpass")
+	if err != nil {
+		return nil, errors.New("password authentication requires sshpass, or use a key file or ssh-agent")
+	}
+	args[1] = "BatchMode=no"
+	cmd := exec.CommandContext(ctx, sshpass, append([]string{"-e", ssh}, args...)...)
+	cmd.Env = append(os.Environ(), "SSHPASS="+password)
+	return cmd, nil
+}
+
+// quote quotes s for the POSIX shell.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func lastLine(b []byte) string {
+	b = bytes.TrimSpace(b)
+	if i := bytes.LastIndexByte(b, '\n'); i >= 0 {
+		b = b[i+1:]
+	}
+	return string(bytes.TrimSpace(b))
+}
+
+// WriteArchive writes the results as a tar archive with a directory per host:
+// snapshot.json with the snapshot, eidos.log with the log output of eidos and
+// error with the error, if any.
+func WriteArchive(w io.Writer, results []Result) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	for _, r := range results {
+		dir := strings.ReplaceAll(r.Host, "/", "_") + "/"
+		if r.Snapshot != nil {
+			b, err := json.MarshalIndent(r.Snapshot, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to serialize snapshot of %s: %w", r.Host, err)
+			}
+			if err := add(dir+"snapshot.json", append(b, '\n')); err != nil {
+				return err
+			}
+		}
+		if len(r.Stderr) > 0 {
+			if err := add(dir+"eidos.log", r.Stderr); err != nil {
+				return err
+			}
+		}
+		if r.Err != nil {
+			if err := add(dir+"error", []byte(r.Err.Error()+"\n")); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package remote_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/inventory"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/remote"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+// TestMain runs the test binary as a fake eidos when it's called with the
+// snapshot command, it's the binary the tests copy to the hosts.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == "snapshot" {
+		snap := snapshot.New([]collectors.Configuration{
+			{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "60"}},
+		})
+		snap.Metadata.Hostname = os.Getenv("FAKE_SSH_HOST")
+		if snap.Metadata.Hostname == "slow" {
+			time.Sleep(time.Second)
+		}
+		os.Stderr.WriteString("collecting\n")
+		_ = json.NewEncoder(os.Stdout).Encode(snap)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeSSH writes an ssh client that logs its arguments and runs the command
+// locally, failing for the host "down".
+func fakeSSH(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "ssh.log")
+	script := `#!/bin/sh
+echo "$@" >> ` + log + `
+while [ $# -gt 2 ]; do shift; done
+if [ "$1" = down ]; then
+	echo "ssh: connect to host down port 22: Connection refused" >&2
+	exit 255
+fi
+FAKE_SSH_HOST=$1 exec sh -c "$2"
+`
+	path := filepath.Join(dir, "ssh")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path, log
+}
+
+func TestRunner_Run(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader(`[nodes]
+gpu-01 ansible_host=10.0.0.11 ansible_user=ubuntu ansible_port=2222 ansible_ssh_private_key_file=/keys/gpu
+down
+[nodes:vars]
+ansible_ssh_common_args='-o StrictHostKeyChecking=no'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssh, log := fakeSSH(t)
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	r := &remote.Runner{
+		Binary:      exe,
+		SSH:         ssh,
+		Concurrency: 1,
+		Dir:         dir,
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	results, err := r.Run(t.Context(), inv.Hosts())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results", len(results))
+	}
+	ok := results[0]
+	if ok.Err != nil {
+		t.Fatalf("gpu-01 error = %v", ok.Err)
+	}
+	if ok.Snapshot.Metadata.Labels[remote.HostLabel] != "gpu-01" || ok.Snapshot.Metadata.Hostname != "10.0.0.11" {
+		t.Errorf("metadata = %+v", ok.Snapshot.Metadata)
+	}
+	if len(ok.Snapshot.Items) != 1 || string(ok.Stderr) != "collecting\n" {
+		t.Errorf("items = %+v, stderr = %q", ok.Snapshot.Items, ok.Stderr)
+	}
+	if err := results[1].Err; err == nil || !strings.Contains(err.Error(), "Connection refused") {
+		t.Errorf("down error = %v", err)
+	}
+
+	b, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), dir+"/eidos.") {
+		t.Errorf("eidos not copied to %s: %s", dir, b)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("eidos not removed from %s: %v", dir, entries)
+	}
+	want := "-o BatchMode=yes -o ConnectTimeout=10 -l ubuntu -p 2222 -i /keys/gpu -o StrictHostKeyChecking=no 10.0.0.11 uname -m"
+	if line := strings.SplitN(string(b), "\n", 2)[0]; line != want {
+		t.Errorf("ssh args = %q, want %q", line, want)
+	}
+}
+
+func TestRunner_Run_Timeout(t *testing.T) {
+	inv, err := inventory.Parse(strings.NewReader("slow\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssh, _ := fakeSSH(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The killed ssh client leaves eidos behind, it's removed over a new
+	// connection
+	dir := t.TempDir()
+	r := &remote.Runner{
+		Binary:  exe,
+		SSH:     ssh,
+		Timeout: 200 * time.Millisecond,
+		Dir:     dir,
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	results, err := r.Run(t.Context(), inv.Hosts())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if err := results[0].Err; err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("slow error = %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("eidos not removed from %s: %v", dir, entries)
+	}
+}
+
+func TestRunner_Run_NotLinuxBinary(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "eidos")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	r := &remote.Runner{Binary: bin, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if _, err := r.Run(t.Context(), nil); err == nil {
+		t.Error("Run() with a script binary succeeded")
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	results := []remote.Result{
+		{Host: "gpu-01", Snapshot: snapshot.New(nil), Stderr: []byte("log\n")},
+		{Host: "gpu-02", Err: errors.New("timed out")},
+	}
+	var buf bytes.Buffer
+	if err := remote.WriteArchive(&buf, results); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	var files []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, hdr.Name)
+	}
+	if got := strings.Join(files, ","); got != "gpu-01/snapshot.json,gpu-01/eidos.log,gpu-02/error" {
+		t.Errorf("files = %s", got)
+	}
+}
@@ -0,0 +1,166 @@
+package serializers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+)
+
+var checkReport = checks.Report{
+	Node: "gpu-1",
+	Results: []checks.Result{
+		{Rule: "kernel-modules", Description: "modules loaded", Severity: checks.SeverityError,
+			Status: checks.StatusPass, Message: "overlay and br_netfilter are loaded"},
+		{Rule: "swap-disabled", Description: "swap disabled", Severity: checks.SeverityError,
+			Status: checks.StatusFail, Message: "swap is active on /swap.img", Remediation: "Run 'swapoff -a'"},
+		{Rule: "cgroup-v2", Description: "cgroup v2", Severity: checks.SeverityWarning,
+			Status: checks.StatusSkip, Message: "no mount data in snapshot"},
+	},
+}
+
+func TestWriter_SerializeJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatJUnit, &buf).Serialize(checkReport); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Error("expected XML header")
+	}
+
+	var doc struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Skipped  int `xml:"skipped,attr"`
+		Suites   []struct {
+			Cases []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Message string `xml:"message,attr"`
+					Type    string `xml:"type,attr"`
+					Text    string `xml:",chardata"`
+				} `xml:"failure"`
+				Skipped *struct{} `xml:"skipped"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+
+	if doc.Tests != 3 || doc.Failures != 1 || doc.Skipped != 1 {
+		t.Errorf("unexpected counts: tests=%d failures=%d skipped=%d", doc.Tests, doc.Failures, doc.Skipped)
+	}
+	if len(doc.Suites) != 1 || len(doc.Suites[0].Cases) != 3 {
+		t.Fatalf("expected one suite with 3 test cases, got %+v", doc.Suites)
+	}
+
+	cases := doc.Suites[0].Cases
+	if cases[0].Failure != nil || cases[0].Skipped != nil {
+		t.Errorf("passing rule should have no failure or skipped element")
+	}
+	f := cases[1].Failure
+	if f == nil {
+		t.Fatal("expected failure element for swap-disabled")
+	}
+	if f.Message != "swap is active on /swap.img" || f.Type != "error" {
+		t.Errorf("unexpected failure: %+v", f)
+	}
+	if !strings.Contains(f.Text, "Remediation: Run 'swapoff -a'") {
+		t.Errorf("failure should contain remediation, got %q", f.Text)
+	}
+	if cases[2].Skipped == nil {
+		t.Error("expected skipped element for cgroup-v2")
+	}
+}
+
+func TestWriter_SerializeSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatSARIF, &buf).Serialize(&checkReport); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID   string `json:"id"`
+						Help *struct {
+							Text string `json:"text"`
+						} `json:"help"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Kind      string `json:"kind"`
+				Level     string `json:"level"`
+				Message   struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation *struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "eidos" || len(run.Tool.Driver.Rules) != 3 {
+		t.Errorf("unexpected driver: %+v", run.Tool.Driver)
+	}
+	if h := run.Tool.Driver.Rules[1].Help; h == nil || h.Text != "Run 'swapoff -a'" {
+		t.Errorf("expected remediation as rule help, got %+v", h)
+	}
+
+	want := []struct{ rule, kind, level string }{
+		{"kernel-modules", "pass", "none"},
+		{"swap-disabled", "fail", "error"},
+		{"cgroup-v2", "notApplicable", "none"},
+	}
+	for i, w := range want {
+		r := run.Results[i]
+		if r.RuleID != w.rule || r.RuleIndex != i || r.Kind != w.kind || r.Level != w.level {
+			t.Errorf("result %d: expected %+v, got %+v", i, w, r)
+		}
+	}
+	if !strings.Contains(run.Results[1].Message.Text, "Remediation: Run 'swapoff -a'") {
+		t.Errorf("failed result should contain remediation, got %q", run.Results[1].Message.Text)
+	}
+	// GitHub code scanning rejects results without a physical location
+	for i, r := range run.Results {
+		if len(r.Locations) != 1 || r.Locations[0].PhysicalLocation == nil ||
+			r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "gpu-1" {
+			t.Errorf("result %d: expected physical location on gpu-1, got %+v", i, r.Locations)
+		}
+	}
+}
+
+func TestWriter_SerializeResultsRequired(t *testing.T) {
+	snapshot := []collectors.Configuration{{Type: collectors.KModType, Data: collectors.KModConfig{Name: "overlay"}}}
+	for _, f := range []serializers.Format{serializers.FormatJUnit, serializers.FormatSARIF} {
+		var buf bytes.Buffer
+		if err := serializers.NewWriter(f, &buf).Serialize(snapshot); err == nil {
+			t.Errorf("%s: expected error for snapshot without results", f)
+		}
+	}
+}
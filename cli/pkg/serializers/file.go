@@ -0,0 +1,213 @@
+package serializers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// StdoutPath is the output or input path that refers to stdout or stdin.
+const StdoutPath = "-"
+
+// Compression identifies the compression applied to an output file
+type Compression string
+
+const (
+	// CompressionNone writes output uncompressed
+	CompressionNone Compression = ""
+	// CompressionGzip writes gzip compressed output (.gz)
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd writes zstd compressed output (.zst)
+	CompressionZstd Compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// CompressionFromPath selects the compression based on the file extension.
+func CompressionFromPath(path string) Compression {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".gzip":
+		return CompressionGzip
+	case ".zst", ".zstd":
+		return CompressionZstd
+	default:
+		return CompressionNone
+	}
+}
+
+// Output is a destination for serialized data. Data is only published on Close,
+// Abort discards everything written so far.
+type Output interface {
+	io.WriteCloser
+	Abort() error
+}
+
+// OpenOutput returns the output for the given path. An empty path or "-" writes
+// to stdout, any other path is written atomically with perm permissions and
+// compressed based on its extension.
+func OpenOutput(path string, perm os.FileMode) (Output, error) {
+	if path == "" || path == StdoutPath {
+		return stdoutOutput{}, nil
+	}
+	return NewFileWriter(path, perm)
+}
+
+// stdoutOutput writes directly to stdout, there's nothing to commit or discard.
+type stdoutOutput struct{}
+
+func (stdoutOutput) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdoutOutput) Close() error                { return nil }
+func (stdoutOutput) Abort() error                { return nil }
+
+// FileWriter writes to a temporary file next to the destination and renames it
+// over the destination on Close, so readers never observe a partially written file.
+type FileWriter struct {
+	path string
+	perm os.FileMode
+	tmp  *os.File
+	w    io.Writer
+	comp io.WriteCloser
+}
+
+// NewFileWriter creates a FileWriter for path. The file is compressed according
+// to CompressionFromPath and created with perm permissions.
+func NewFileWriter(path string, perm os.FileMode) (*FileWriter, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	fw := &FileWriter{
+		path: path,
+		perm: perm,
+		tmp:  tmp,
+		w:    tmp,
+	}
+
+	switch CompressionFromPath(path) {
+	case CompressionGzip:
+		fw.comp = gzip.NewWriter(tmp)
+	case CompressionZstd:
+		fw.comp, err = zstd.NewWriter(tmp)
+		if err != nil {
+			_ = fw.Abort()
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+	case CompressionNone:
+	}
+	if fw.comp != nil {
+		fw.w = fw.comp
+	}
+
+	return fw, nil
+}
+
+// Write writes p to the temporary file.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+// Close flushes and syncs the temporary file and renames it to the destination.
+func (w *FileWriter) Close() error {
+	if w.comp != nil {
+		if err := w.comp.Close(); err != nil {
+			_ = w.Abort()
+			return fmt.Errorf("failed to compress output: %w", err)
+		}
+	}
+	if err := w.tmp.Chmod(w.perm); err != nil {
+		_ = w.Abort()
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := w.tmp.Sync(); err != nil {
+		_ = w.Abort()
+		return fmt.Errorf("failed to sync output: %w", err)
+	}
+	if err := w.tmp.Close(); err != nil {
+		_ = os.Remove(w.tmp.Name())
+		return fmt.Errorf("failed to close output: %w", err)
+	}
+	if err := os.Rename(w.tmp.Name(), w.path); err != nil {
+		_ = os.Remove(w.tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", w.path, err)
+	}
+	return nil
+}
+
+// Abort removes the temporary file, leaving any existing destination untouched.
+func (w *FileWriter) Abort() error {
+	if w.comp != nil {
+		_ = w.comp.Close()
+	}
+	_ = w.tmp.Close()
+	if err := os.Remove(w.tmp.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove temp file: %w", err)
+	}
+	return nil
+}
+
+// OpenInput opens a snapshot for reading, "-" reads from stdin. Gzip and zstd
+// compressed input is detected from its magic bytes and decompressed transparently.
+func OpenInput(path string) (io.ReadCloser, error) {
+	var f io.ReadCloser = os.Stdin
+	if path != StdoutPath {
+		var err error
+		f, err = os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+	}
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read gzip input: %w", err)
+		}
+		return &readCloser{Reader: gz, closers: []io.Closer{gz, f}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read zstd input: %w", err)
+		}
+		zrc := zr.IOReadCloser()
+		return &readCloser{Reader: zrc, closers: []io.Closer{zrc, f}}, nil
+	default:
+		return &readCloser{Reader: br, closers: []io.Closer{f}}, nil
+	}
+}
+
+// readCloser closes all underlying readers in order.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,135 @@
+package serializers_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+)
+
+func TestCompressionFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want serializers.Compression
+	}{
+		{"snapshot.json", serializers.CompressionNone},
+		{"snapshot.json.gz", serializers.CompressionGzip},
+		{"snapshot.yaml.GZ", serializers.CompressionGzip},
+		{"snapshot.json.zst", serializers.CompressionZstd},
+		{"-", serializers.CompressionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := serializers.CompressionFromPath(tt.path); got != tt.want {
+				t.Errorf("CompressionFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func writeOutput(t *testing.T, path string, perm os.FileMode, data string) {
+	t.Helper()
+	out, err := serializers.OpenOutput(path, perm)
+	if err != nil {
+		t.Fatalf("OpenOutput failed: %v", err)
+	}
+	if _, err := io.WriteString(out, data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func readInput(t *testing.T, path string) string {
+	t.Helper()
+	in, err := serializers.OpenInput(path)
+	if err != nil {
+		t.Fatalf("OpenInput failed: %v", err)
+	}
+	defer in.Close()
+
+	b, err := io.ReadAll(in)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	return string(b)
+}
+
+func TestFileWriter_Atomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snapshot.json")
+
+	writeOutput(t, path, 0o640, `{"a":1}`)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected mode 0640, got %o", info.Mode().Perm())
+	}
+
+	// Aborted writes leave the previous content and no temp files behind
+	out, err := serializers.NewFileWriter(path, 0o640)
+	if err != nil {
+		t.Fatalf("NewFileWriter failed: %v", err)
+	}
+	if _, err := io.WriteString(out, "partial"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := out.Abort(); err != nil {
+		t.Fatalf("Abort failed: %v", err)
+	}
+
+	if got := readInput(t, path); got != `{"a":1}` {
+		t.Errorf("Expected previous content, got %q", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the output file, got %d entries", len(entries))
+	}
+}
+
+func TestFileWriter_Gzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json.gz")
+	writeOutput(t, path, 0o600, `{"a":1}`)
+
+	// File is actually gzip compressed
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	if _, err := gzip.NewReader(f); err != nil {
+		t.Fatalf("Expected gzip content: %v", err)
+	}
+
+	if got := readInput(t, path); got != `{"a":1}` {
+		t.Errorf("Unexpected round trip content: %q", got)
+	}
+}
+
+func TestFileWriter_Zstd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json.zst")
+	writeOutput(t, path, 0o600, `{"a":1}`)
+
+	if got := readInput(t, path); got != `{"a":1}` {
+		t.Errorf("Unexpected round trip content: %q", got)
+	}
+}
+
+func TestFileWriter_MissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "snapshot.json")
+	if _, err := serializers.NewFileWriter(path, 0o600); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+}
@@ -0,0 +1,81 @@
+package serializers
+
+import (
+	"fmt"
+	"html/template"
+)
+
+// htmlTemplate renders a self-contained report, styles are inlined so the
+// file can be opened offline or attached to a ticket.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1a1a1a; }
+h1 { border-bottom: 3px solid #76b900; padding-bottom: .3em; }
+h2 { margin-top: 2em; }
+table { border-collapse: collapse; margin: 1em 0; width: 100%; font-size: 14px; }
+th, td { border: 1px solid #d0d0d0; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+td { font-family: SFMono-Regular, Consolas, monospace; word-break: break-all; }
+nav a { margin-right: 1em; }
+.summary span { display: inline-block; margin-right: 1em; padding: 4px 10px; border-radius: 4px; font-weight: bold; }
+.pass { background: #e6f4d7; color: #2d5a00; }
+.fail { background: #fde2e1; color: #a4000f; }
+.skip { background: #eeeeee; color: #555555; }
+tr.fail td { background: #fff1f0; }
+details { margin: .5em 0; }
+summary { cursor: pointer; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+{{- if .Results }}
+<h2 id="validation">Validation Results</h2>
+<p class="summary"><span class="pass">{{ .Summary.Pass }} passed</span><span class="fail">{{ .Summary.Fail }} failed</span><span class="skip">{{ .Summary.Skip }} skipped</span></p>
+<table>
+<tr><th>Status</th><th>Rule</th><th>Severity</th><th>Message</th><th>Remediation</th></tr>
+{{- range .Results }}
+<tr class="{{ .Status }}"><th class="{{ .Status }}">{{ .Status }}</th><td>{{ .Rule }}</td><td>{{ .Severity }}</td><td>{{ .Message }}</td><td>{{ .Remediation }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+<nav>{{ range .Groups }}<a href="#{{ .Type }}">{{ .Type }} ({{ .Count }})</a>{{ end }}</nav>
+{{- range .Groups }}
+<h2 id="{{ .Type }}">{{ .Type }}</h2>
+{{- if .Columns }}
+<table>
+<tr>{{ range .Columns }}<th>{{ . }}</th>{{ end }}</tr>
+{{- range .Rows }}
+<tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+{{- else }}
+{{- range .Items }}
+<details>
+<summary>{{ .Title }}</summary>
+<table>
+{{- range .Fields }}
+<tr><th>{{ .Key }}</th><td>{{ .Value }}</td></tr>
+{{- end }}
+</table>
+</details>
+{{- end }}
+{{- end }}
+{{- end }}
+</body>
+</html>
+`))
+
+func (w *Writer) serializeHTML(config any) error {
+	r, err := newReport(config)
+	if err != nil {
+		return err
+	}
+	if err := htmlTemplate.Execute(w.output, r); err != nil {
+		return fmt.Errorf("failed to serialize to HTML: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package serializers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// serializeJUnit writes validation results as a JUnit XML report with one
+// test case per rule.
+func (w *Writer) serializeJUnit(config any) error {
+	results, err := checkResults(config, FormatJUnit)
+	if err != nil {
+		return err
+	}
+
+	suite := junitTestSuite{Name: "eidos check", Tests: len(results)}
+	for _, r := range results {
+		tc := junitTestCase{
+			Name:      r.Rule,
+			ClassName: "eidos.check." + string(r.Severity),
+			SystemOut: r.Description,
+		}
+		switch r.Status {
+		case checks.StatusFail:
+			suite.Failures++
+			var text strings.Builder
+			text.WriteString(r.Message)
+			if r.Remediation != "" {
+				text.WriteString("\n\nRemediation: " + r.Remediation)
+			}
+			tc.Failure = &junitMessage{
+				Message: r.Message,
+				Type:    string(r.Severity),
+				Text:    text.String(),
+			}
+		case checks.StatusSkip:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: r.Message}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	doc := junitTestSuites{
+		Name:     "eidos",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w.output, xml.Header); err != nil {
+		return fmt.Errorf("failed to serialize to JUnit: %w", err)
+	}
+	encoder := xml.NewEncoder(w.output)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to serialize to JUnit: %w", err)
+	}
+	if _, err := io.WriteString(w.output, "\n"); err != nil {
+		return fmt.Errorf("failed to serialize to JUnit: %w", err)
+	}
+	return nil
+}
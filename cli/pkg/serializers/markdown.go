@@ -0,0 +1,83 @@
+package serializers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+)
+
+func (w *Writer) serializeMarkdown(config any) error {
+	r, err := newReport(config)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", r.Title)
+
+	if len(r.Results) > 0 {
+		b.WriteString("\n## Validation Results\n\n")
+		fmt.Fprintf(&b, "**%d passed**, **%d failed**, **%d skipped**\n\n",
+			r.Summary.Pass, r.Summary.Fail, r.Summary.Skip)
+		writeMarkdownTable(&b, []string{"Status", "Rule", "Severity", "Message", "Remediation"}, nil)
+		for _, res := range r.Results {
+			status := strings.ToUpper(string(res.Status))
+			if res.Status == checks.StatusFail {
+				// Highlight failures so they stand out in rendered and plain text
+				status = "**" + status + "**"
+			}
+			writeMarkdownRow(&b, []string{status, res.Rule, string(res.Severity), res.Message, res.Remediation})
+		}
+	}
+
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", g.Type, g.Count)
+		if g.Columns != nil {
+			writeMarkdownTable(&b, g.Columns, g.Rows)
+			continue
+		}
+		for _, item := range g.Items {
+			fmt.Fprintf(&b, "### %s\n\n", escapeMarkdown(item.Title))
+			rows := make([][]string, 0, len(item.Fields))
+			for _, f := range item.Fields {
+				rows = append(rows, []string{f.Key, f.Value})
+			}
+			writeMarkdownTable(&b, []string{"Key", "Value"}, rows)
+			b.WriteString("\n")
+		}
+	}
+
+	if _, err := io.WriteString(w.output, b.String()); err != nil {
+		return fmt.Errorf("failed to serialize to Markdown: %w", err)
+	}
+	return nil
+}
+
+func writeMarkdownTable(b *strings.Builder, columns []string, rows [][]string) {
+	writeMarkdownRow(b, columns)
+	sep := make([]string, len(columns))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	b.WriteString("|" + strings.Join(sep, "|") + "|\n")
+	for _, row := range rows {
+		writeMarkdownRow(b, row)
+	}
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = escapeMarkdown(c)
+	}
+	b.WriteString("| " + strings.Join(escaped, " | ") + " |\n")
+}
+
+// escapeMarkdown escapes characters that would break table cells.
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return s
+}
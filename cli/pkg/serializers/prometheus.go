@@ -0,0 +1,204 @@
+package serializers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// metricPrefix is the namespace of all metrics exported by eidos.
+const metricPrefix = "eidos_"
+
+// metricFamily is a gauge with its samples in the Prometheus text format.
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+func (f *metricFamily) add(value float64, labels ...string) {
+	s := metricSample{value: value}
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.labels = append(s.labels, [2]string{labels[i], labels[i+1]})
+	}
+	f.samples = append(f.samples, s)
+}
+
+// serializePrometheus writes the snapshot in the Prometheus text exposition
+// format, for use with the node_exporter textfile collector.
+func (w *Writer) serializePrometheus(config any) error {
+	var configs []collectors.Configuration
+	var results []checks.Result
+	switch c := config.(type) {
+	case []collectors.Configuration:
+		configs = c
+	case checks.Report:
+		configs, results = c.Configurations, c.Results
+	case *checks.Report:
+		configs, results = c.Configurations, c.Results
+	default:
+		return fmt.Errorf("unsupported config type %T for prometheus format", config)
+	}
+
+	families := snapshotMetrics(configs)
+	if results != nil {
+		families = append(families, checkMetrics(results))
+	}
+
+	var b strings.Builder
+	for _, f := range families {
+		if len(f.samples) == 0 {
+			continue
+		}
+		writeMetricFamily(&b, f)
+	}
+
+	if _, err := io.WriteString(w.output, b.String()); err != nil {
+		return fmt.Errorf("failed to serialize to Prometheus: %w", err)
+	}
+	return nil
+}
+
+func snapshotMetrics(configs []collectors.Configuration) []*metricFamily {
+	count := &metricFamily{
+		name: "snapshot_configurations",
+		help: "Number of configurations in the snapshot by collector type.",
+	}
+	sysctl := &metricFamily{
+		name: "sysctl_value",
+		help: "Numeric kernel parameter value.",
+	}
+	kmod := &metricFamily{
+		name: "kernel_module_loaded",
+		help: "Kernel module loaded on the node.",
+	}
+	unitActive := &metricFamily{
+		name: "systemd_unit_active",
+		help: "Whether the systemd unit is active (1) or not (0).",
+	}
+	unitState := &metricFamily{
+		name: "systemd_unit_state",
+		help: "Current systemd unit state, the sample for the current state is 1.",
+	}
+	bootParam := &metricFamily{
+		name: "boot_param_present",
+		help: "Kernel command line parameter present on the node.",
+	}
+
+	counts := make(map[string]int)
+	for _, c := range configs {
+		counts[c.Type]++
+
+		switch d := c.Data.(type) {
+		case collectors.SysctlConfig:
+			// Only single numeric values become metrics, "4096 87380 6291456" etc. are skipped
+			v, err := strconv.ParseFloat(strings.TrimSpace(d.Value), 64)
+			if err != nil {
+				continue
+			}
+			sysctl.add(v, "key", sysctlName(d.Key))
+		case collectors.KModConfig:
+			kmod.add(1, "module", d.Name)
+		case collectors.SystemDConfig:
+			state := fmt.Sprint(d.Properties["ActiveState"])
+			if d.Properties["ActiveState"] == nil {
+				state = "unknown"
+			}
+			active := 0.0
+			if state == "active" {
+				active = 1
+			}
+			unitActive.add(active, "unit", d.Unit)
+			unitState.add(1, "unit", d.Unit, "state", state)
+		case collectors.GrubConfig:
+			bootParam.add(1, "param", d.Key, "value", d.Value)
+		}
+	}
+
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		count.add(float64(counts[t]), "type", t)
+	}
+
+	return []*metricFamily{count, sysctl, kmod, unitActive, unitState, bootParam}
+}
+
+// checkMetrics exports one sample per rule and status, so alerts can match on
+// status="fail" without relying on absent series.
+func checkMetrics(results []checks.Result) *metricFamily {
+	f := &metricFamily{
+		name: "check_status",
+		help: "Validation rule status, the sample for the current status is 1.",
+	}
+	for _, r := range results {
+		for _, s := range []checks.Status{checks.StatusPass, checks.StatusFail, checks.StatusSkip} {
+			v := 0.0
+			if r.Status == s {
+				v = 1
+			}
+			f.add(v, "rule", r.Rule, "severity", string(r.Severity), "status", string(s))
+		}
+	}
+	return f
+}
+
+// sysctlName converts a /proc/sys path into the dotted sysctl name.
+func sysctlName(key string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(key, "/proc/sys/"), "/", ".")
+}
+
+func writeMetricFamily(b *strings.Builder, f *metricFamily) {
+	name := metricPrefix + f.name
+	fmt.Fprintf(b, "# HELP %s %s\n", name, f.help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+
+	lines := make([]string, 0, len(f.samples))
+	for _, s := range f.samples {
+		var line strings.Builder
+		line.WriteString(name)
+		if len(s.labels) > 0 {
+			line.WriteString("{")
+			for i, l := range s.labels {
+				if i > 0 {
+					line.WriteString(",")
+				}
+				fmt.Fprintf(&line, "%s=\"%s\"", l[0], escapeLabelValue(l[1]))
+			}
+			line.WriteString("}")
+		}
+		line.WriteString(" ")
+		line.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+		lines = append(lines, line.String())
+	}
+
+	// Sorted output keeps the file stable between runs. Duplicate series, e.g. a
+	// boot parameter given twice, are rejected by the textfile collector.
+	sort.Strings(lines)
+	for i, l := range lines {
+		if i > 0 && l == lines[i-1] {
+			continue
+		}
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
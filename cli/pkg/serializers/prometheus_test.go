@@ -0,0 +1,78 @@
+package serializers_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+)
+
+func TestWriter_SerializePrometheus(t *testing.T) {
+	report := checks.Report{
+		Configurations: []collectors.Configuration{
+			{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "60"}},
+			{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/net/ipv4/tcp_rmem", Value: "4096 87380 6291456"}},
+			{Type: collectors.KModType, Data: collectors.KModConfig{Name: "overlay"}},
+			{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+				Unit:       "kubelet.service",
+				Properties: map[string]any{"ActiveState": "failed"},
+			}},
+			{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "iommu", Value: "pt"}},
+			{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "quiet"}},
+			{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "quiet"}},
+			{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "root", Value: `LABEL="x\y"`}},
+		},
+		Results: []checks.Result{
+			{Rule: "swap-disabled", Severity: checks.SeverityError, Status: checks.StatusFail},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatPrometheus, &buf).Serialize(report); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE eidos_sysctl_value gauge\n",
+		`eidos_sysctl_value{key="vm.swappiness"} 60` + "\n",
+		`eidos_kernel_module_loaded{module="overlay"} 1` + "\n",
+		`eidos_systemd_unit_active{unit="kubelet.service"} 0` + "\n",
+		`eidos_systemd_unit_state{unit="kubelet.service",state="failed"} 1` + "\n",
+		`eidos_boot_param_present{param="iommu",value="pt"} 1` + "\n",
+		`eidos_boot_param_present{param="root",value="LABEL=\"x\\y\""} 1` + "\n",
+		`eidos_snapshot_configurations{type="Grub"} 4` + "\n",
+		`eidos_check_status{rule="swap-disabled",severity="error",status="fail"} 1` + "\n",
+		`eidos_check_status{rule="swap-disabled",severity="error",status="pass"} 0` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "tcp_rmem") {
+		t.Error("non-numeric sysctl should be skipped")
+	}
+	if n := strings.Count(out, `param="quiet"`); n != 1 {
+		t.Errorf("expected duplicate series to be dropped, got %d", n)
+	}
+}
+
+func TestWriter_SerializePrometheusSnapshot(t *testing.T) {
+	var buf bytes.Buffer
+	snapshot := []collectors.Configuration{
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+	}
+	if err := serializers.NewWriter(serializers.FormatPrometheus, &buf).Serialize(snapshot); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "eidos_check_status") {
+		t.Error("snapshot without results should not export check metrics")
+	}
+	if strings.Contains(buf.String(), "eidos_sysctl_value") {
+		t.Error("empty metric families should be omitted")
+	}
+}
@@ -0,0 +1,237 @@
+package serializers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// maxTableColumns is the number of fields up to which a group of configurations
+// is rendered as a single table. Wider data is rendered as one section per item.
+const maxTableColumns = 8
+
+// report is the view model shared by the HTML and Markdown formats.
+type report struct {
+	Title   string
+	Summary checks.Summary
+	Results []checks.Result
+	Groups  []reportGroup
+}
+
+// reportGroup holds the configurations of one collector type.
+type reportGroup struct {
+	Type    string
+	Count   int
+	Columns []string
+	Rows    [][]string
+	Items   []reportItem
+}
+
+// reportItem is a single configuration rendered as a key/value list.
+type reportItem struct {
+	Title  string
+	Fields []reportField
+}
+
+type reportField struct {
+	Key   string
+	Value string
+}
+
+// newReport builds the report view model from a snapshot or a validation report.
+func newReport(config any) (*report, error) {
+	r := &report{Title: "Node Configuration Report"}
+
+	var configs []collectors.Configuration
+	switch c := config.(type) {
+	case []collectors.Configuration:
+		configs = c
+	case checks.Report:
+		configs = c.Configurations
+		r.Results = c.Results
+		r.Summary = c.Summary()
+		r.Title = "Node Validation Report"
+	case *checks.Report:
+		return newReport(*c)
+	default:
+		return nil, fmt.Errorf("unsupported config type %T for report format", config)
+	}
+
+	// Group by collector type, keeping the order types first appear in
+	byType := make(map[string]*reportGroup)
+	var order []string
+	for _, c := range configs {
+		g, ok := byType[c.Type]
+		if !ok {
+			g = &reportGroup{Type: c.Type}
+			byType[c.Type] = g
+			order = append(order, c.Type)
+		}
+		fields := flatten(c.Data)
+		g.Count++
+		g.Items = append(g.Items, reportItem{
+			Title:  itemTitle(fields),
+			Fields: fields,
+		})
+	}
+
+	for _, t := range order {
+		g := byType[t]
+		g.tabulate()
+		r.Groups = append(r.Groups, *g)
+	}
+
+	return r, nil
+}
+
+// tabulate converts the group's items into table rows when every item has the
+// same, small set of fields.
+func (g *reportGroup) tabulate() {
+	if len(g.Items) == 0 {
+		return
+	}
+
+	var columns []string
+	for _, f := range g.Items[0].Fields {
+		columns = append(columns, f.Key)
+	}
+	if len(columns) > maxTableColumns {
+		return
+	}
+
+	rows := make([][]string, 0, len(g.Items))
+	for _, item := range g.Items {
+		if len(item.Fields) != len(columns) {
+			return
+		}
+		row := make([]string, len(columns))
+		for i, f := range item.Fields {
+			if f.Key != columns[i] {
+				return
+			}
+			row[i] = f.Value
+		}
+		rows = append(rows, row)
+	}
+
+	g.Columns = columns
+	g.Rows = rows
+	g.Items = nil
+}
+
+// itemTitle uses the first field's value (Unit, Name, Key) as the item title.
+func itemTitle(fields []reportField) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0].Value
+}
+
+// flatten converts configuration data into an ordered list of fields. Nested
+// structs and maps use dotted keys, lists of structs are indexed ("Ports[0].State"),
+// and lists of scalars are joined.
+func flatten(data any) []reportField {
+	var res []reportField
+	flattenValue("", reflect.ValueOf(data), &res)
+	return res
+}
+
+func flattenValue(prefix string, v reflect.Value, res *[]reportField) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			*res = append(*res, reportField{Key: prefix})
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		*res = append(*res, reportField{Key: prefix})
+		return
+	}
+
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			flattenValue(join(t.Field(i).Name), v.Field(i), res)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			flattenValue(join(fmt.Sprint(k.Interface())), v.MapIndex(k), res)
+		}
+	case reflect.Slice, reflect.Array:
+		if isScalarList(v) {
+			parts := make([]string, v.Len())
+			for i := range parts {
+				parts[i] = fmt.Sprint(v.Index(i).Interface())
+			}
+			*res = append(*res, reportField{Key: prefix, Value: strings.Join(parts, ", ")})
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			flattenValue(fmt.Sprintf("%s[%d]", prefix, i), v.Index(i), res)
+		}
+	default:
+		*res = append(*res, reportField{Key: prefix, Value: fmt.Sprint(v.Interface())})
+	}
+}
+
+// isScalarList reports whether all elements of the list are scalars.
+func isScalarList(v reflect.Value) bool {
+	for i := 0; i < v.Len(); i++ {
+		e := v.Index(i)
+		for e.Kind() == reflect.Interface && !e.IsNil() {
+			e = e.Elem()
+		}
+		switch e.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer:
+			return false
+		}
+	}
+	return true
+}
+
+// checkResults returns the validation results of a report, for the formats
+// that only describe check results.
+func checkResults(config any, format Format) ([]checks.Result, error) {
+	switch c := config.(type) {
+	case checks.Report:
+		return c.Results, nil
+	case *checks.Report:
+		return c.Results, nil
+	case []checks.Result:
+		return c, nil
+	default:
+		return nil, fmt.Errorf("%s format requires validation results, got %T", format, config)
+	}
+}
+
+// checkNode returns the node validation results were computed for, if known.
+func checkNode(config any) string {
+	switch c := config.(type) {
+	case checks.Report:
+		return c.Node
+	case *checks.Report:
+		return c.Node
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,115 @@
+package serializers_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+)
+
+var reportSnapshot = []collectors.Configuration{
+	{Type: collectors.KModType, Data: collectors.KModConfig{Name: "overlay"}},
+	{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+		Unit:       "containerd.service",
+		Backend:    collectors.SystemDBackendFile,
+		Properties: map[string]any{"ActiveState": "active", "ExecStart": "/usr/bin/containerd"},
+	}},
+	{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+		Unit:       "kubelet.service",
+		Backend:    collectors.SystemDBackendFile,
+		Properties: map[string]any{"ActiveState": "inactive"},
+	}},
+	{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "iommu", Value: "pt|on"}},
+}
+
+var reportResults = checks.Report{
+	Configurations: reportSnapshot,
+	Results: []checks.Result{
+		{Rule: "kernel-modules", Severity: checks.SeverityError, Status: checks.StatusPass, Message: "loaded"},
+		{Rule: "swap-disabled", Severity: checks.SeverityError, Status: checks.StatusFail,
+			Message: "swap is active on /swap.img", Remediation: "Run 'swapoff -a'"},
+	},
+}
+
+func TestWriter_SerializeHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatHTML, &buf).Serialize(reportResults); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		"Node Validation Report",
+		"1 passed",
+		"1 failed",
+		`<tr class="fail">`,
+		"Run &#39;swapoff -a&#39;",
+		`<h2 id="KMod">KMod</h2>`,
+		"<td>overlay</td>",
+		"<summary>containerd.service</summary>",
+		"Properties.ExecStart",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML output missing %q", want)
+		}
+	}
+
+	// The report must be self-contained
+	for _, external := range []string{"<link", "<script", "src="} {
+		if strings.Contains(out, external) {
+			t.Errorf("HTML output references external asset %q", external)
+		}
+	}
+}
+
+func TestWriter_SerializeHTMLSnapshot(t *testing.T) {
+	var buf bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatHTML, &buf).Serialize(reportSnapshot); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, "Node Configuration Report") {
+		t.Error("expected snapshot report title")
+	}
+	if strings.Contains(out, "Validation Results") {
+		t.Error("snapshot without results should not have a validation section")
+	}
+}
+
+func TestWriter_SerializeMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatMarkdown, &buf).Serialize(&reportResults); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# Node Validation Report\n",
+		"**1 passed**, **1 failed**, **0 skipped**",
+		"| **FAIL** | swap-disabled | error | swap is active on /swap.img | Run 'swapoff -a' |",
+		"| PASS | kernel-modules | error | loaded |  |",
+		"## KMod (1)",
+		"| Name |",
+		"| overlay |",
+		"### containerd.service",
+		"| Properties.ActiveState | active |",
+		`| iommu | pt\|on |`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestWriter_SerializeReportUnsupported(t *testing.T) {
+	var buf bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatMarkdown, &buf).Serialize("text"); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
@@ -0,0 +1,162 @@
+package serializers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// The SARIF types cover the subset of the 2.1.0 specification eidos produces.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifText          `json:"shortDescription"`
+	Help                 *sarifText         `json:"help,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID           string                 `json:"ruleId"`
+	RuleIndex        int                    `json:"ruleIndex"`
+	Kind             string                 `json:"kind"`
+	Level            string                 `json:"level"`
+	Message          sarifText              `json:"message"`
+	Locations        []sarifLocation        `json:"locations"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// sarifLocation is required by GitHub code scanning, which rejects results
+// without a physical location.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+// serializeSARIF writes validation results as a SARIF 2.1.0 log with one rule
+// and one result per check. Results are located on the node, named by its
+// hostname, as they don't come from a source file.
+func (w *Writer) serializeSARIF(config any) error {
+	results, err := checkResults(config, FormatSARIF)
+	if err != nil {
+		return err
+	}
+
+	node := checkNode(config)
+	if node == "" {
+		node = "node"
+	}
+	location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: node},
+		Region:           sarifRegion{StartLine: 1},
+	}}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "eidos",
+			InformationURI: "https://github.com/NVIDIA/cloud-native-stack",
+			Rules:          make([]sarifRule, 0, len(results)),
+		}},
+		Results: make([]sarifResult, 0, len(results)),
+	}
+
+	for i, r := range results {
+		rule := sarifRule{
+			ID:                   r.Rule,
+			ShortDescription:     sarifText{Text: r.Description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(r.Severity)},
+		}
+		if r.Remediation != "" {
+			rule.Help = &sarifText{Text: r.Remediation}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+
+		res := sarifResult{
+			RuleID:           r.Rule,
+			RuleIndex:        i,
+			Message:          sarifText{Text: r.Message},
+			Locations:        []sarifLocation{location},
+			LogicalLocations: []sarifLogicalLocation{{Name: node, Kind: "module"}},
+		}
+		switch r.Status {
+		case checks.StatusFail:
+			res.Kind = "fail"
+			res.Level = sarifLevel(r.Severity)
+			if r.Remediation != "" {
+				res.Message.Text += ". Remediation: " + r.Remediation
+			}
+		case checks.StatusSkip:
+			res.Kind = "notApplicable"
+			res.Level = "none"
+		default:
+			res.Kind = "pass"
+			res.Level = "none"
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	encoder := json.NewEncoder(w.output)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}); err != nil {
+		return fmt.Errorf("failed to serialize to SARIF: %w", err)
+	}
+	return nil
+}
+
+// sarifLevel maps a rule severity to a SARIF level.
+func sarifLevel(s checks.Severity) string {
+	if s == checks.SeverityError {
+		return "error"
+	}
+	return "warning"
+}
@@ -0,0 +1,252 @@
+package serializers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Template format prefixes, mirroring kubectl's template output flags
+const (
+	goTemplatePrefix     = "go-template="
+	goTemplateFilePrefix = "go-template-file="
+	templateFilePrefix   = "template="
+)
+
+// ParseFormat parses an output format specification. Plain formats like "json"
+// are returned as is. "go-template=<template>" uses an inline template, while
+// "template=<file>" and "go-template-file=<file>" read the template from a file.
+// For template formats the template text is returned and validated.
+func ParseFormat(spec string) (Format, string, error) {
+	var text string
+	switch {
+	case strings.HasPrefix(spec, goTemplatePrefix):
+		text = strings.TrimPrefix(spec, goTemplatePrefix)
+	case strings.HasPrefix(spec, goTemplateFilePrefix), strings.HasPrefix(spec, templateFilePrefix):
+		_, file, _ := strings.Cut(spec, "=")
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read template file: %w", err)
+		}
+		text = string(b)
+	default:
+		return Format(spec), "", nil
+	}
+
+	if _, err := parseTemplate(text); err != nil {
+		return "", "", err
+	}
+	return FormatTemplate, text, nil
+}
+
+// WithTemplate sets the template used by the template format.
+func WithTemplate(text string) Option {
+	return func(w *Writer) {
+		w.template = text
+	}
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("output").Funcs(templateFuncs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return t, nil
+}
+
+func (w *Writer) serializeTemplate(config any) error {
+	t, err := parseTemplate(w.template)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(w.output, config); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	return nil
+}
+
+// templateFuncs returns the helper functions available to output templates.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"byType": byType,
+		"sysctl": sysctlValue,
+		"sortBy": sortBy,
+		"field":  field,
+		"toJson": toJSON,
+		"toYaml": toYAML,
+		"join":   strings.Join,
+		"upper":  strings.ToUpper,
+		"lower":  strings.ToLower,
+	}
+}
+
+// byType returns the items whose Type matches one of the given types,
+// e.g. {{ range byType "KMod" . }}
+func byType(t string, items any) ([]any, error) {
+	types := strings.Split(t, ",")
+	v, err := sliceValue(items)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]any, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i).Interface()
+		typ, _ := lookupField(item, "Type")
+		for _, want := range types {
+			if fmt.Sprint(typ) == strings.TrimSpace(want) {
+				res = append(res, item)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// sysctlValue returns the value of a sysctl by dotted name ("vm.swappiness")
+// or /proc/sys path, or an empty string if it isn't in the snapshot.
+func sysctlValue(name string, items any) (string, error) {
+	key := name
+	if !strings.HasPrefix(key, "/proc/sys/") {
+		key = "/proc/sys/" + strings.ReplaceAll(name, ".", "/")
+	}
+
+	sysctls, err := byType("Sysctl", items)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range sysctls {
+		if k, _ := lookupField(s, "Data.Key"); fmt.Sprint(k) == key {
+			v, _ := lookupField(s, "Data.Value")
+			return fmt.Sprint(v), nil
+		}
+	}
+	return "", nil
+}
+
+// sortBy returns the items sorted by the value at the dotted field path,
+// e.g. {{ range sortBy "Data.Name" (byType "KMod" .) }}. Numbers come first
+// in numeric order, followed by the other values in string order.
+func sortBy(path string, items any) ([]any, error) {
+	v, err := sliceValue(items)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]any, v.Len())
+	for i := range res {
+		res[i] = v.Index(i).Interface()
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, _ := lookupField(res[i], path)
+		b, _ := lookupField(res[j], path)
+		return lessValue(a, b)
+	})
+	return res, nil
+}
+
+// lessValue orders field values with numbers first, compared numerically so
+// sysctl values and sizes sort as 9 < 10, followed by the other values
+// compared as strings. Comparing mixed pairs either way would not be a strict
+// weak ordering: "1a" < "2" < "10" < "1a".
+func lessValue(a, b any) bool {
+	sa, sb := fmt.Sprint(a), fmt.Sprint(b)
+	fa, numA := number(sa)
+	fb, numB := number(sb)
+	switch {
+	case numA && numB:
+		return fa < fb
+	case numA != numB:
+		return numA
+	default:
+		return sa < sb
+	}
+}
+
+// number parses a finite number. NaN isn't ordered and, like Inf, is sorted
+// as a string.
+func number(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+// field returns the value at the dotted field path, or nil if it doesn't exist.
+func field(path string, item any) any {
+	v, _ := lookupField(item, path)
+	return v
+}
+
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize to JSON: %w", err)
+	}
+	return string(b), nil
+}
+
+func toYAML(v any) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return "", fmt.Errorf("failed to serialize to YAML: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// sliceValue returns items as a reflected slice.
+func sliceValue(items any) (reflect.Value, error) {
+	v := reflect.ValueOf(items)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("expected a list, got %T", items)
+	}
+	return v, nil
+}
+
+// lookupField resolves a dotted path of struct fields and map keys.
+func lookupField(item any, path string) (any, bool) {
+	v := reflect.ValueOf(item)
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			v = v.FieldByName(name)
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		default:
+			return nil, false
+		}
+		if !v.IsValid() {
+			return nil, false
+		}
+	}
+	if !v.CanInterface() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
@@ -0,0 +1,152 @@
+package serializers_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+)
+
+var templateSnapshot = []collectors.Configuration{
+	{Type: collectors.KModType, Data: collectors.KModConfig{Name: "overlay"}},
+	{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "60"}},
+	{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+	{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "iommu", Value: "pt"}},
+	{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/max_map_count", Value: "262144"}},
+	{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/overcommit_ratio", Value: "50"}},
+}
+
+func TestParseFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.tmpl")
+	if err := os.WriteFile(file, []byte("{{ len . }}"), 0o600); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	tests := []struct {
+		spec    string
+		format  serializers.Format
+		tmpl    string
+		wantErr bool
+	}{
+		{"json", serializers.FormatJSON, "", false},
+		{"go-template={{ len . }}", serializers.FormatTemplate, "{{ len . }}", false},
+		{"template=" + file, serializers.FormatTemplate, "{{ len . }}", false},
+		{"go-template-file=" + file, serializers.FormatTemplate, "{{ len . }}", false},
+		{"go-template={{ .Missing", "", "", true},
+		{"template=/does/not/exist", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			format, tmpl, err := serializers.ParseFormat(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if format != tt.format || tmpl != tt.tmpl {
+				t.Errorf("ParseFormat(%q) = (%q, %q), want (%q, %q)", tt.spec, format, tmpl, tt.format, tt.tmpl)
+			}
+		})
+	}
+}
+
+func TestWriter_SerializeTemplate_SortMixed(t *testing.T) {
+	var snapshot []collectors.Configuration
+	for _, v := range []string{"1a", "NaN", "10", "Inf", "2", "b", "-1", "1e1"} {
+		snapshot = append(snapshot, collectors.Configuration{
+			Type: collectors.SysctlType,
+			Data: collectors.SysctlConfig{Key: "/proc/sys/test/" + v, Value: v},
+		})
+	}
+
+	var buf bytes.Buffer
+	tmpl := `{{ range sortBy "Data.Value" . }}{{ .Data.Value }} {{ end }}`
+	if err := serializers.NewWriter(serializers.FormatTemplate, &buf, serializers.WithTemplate(tmpl)).Serialize(snapshot); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	// Numbers first, equal numbers keep their order, then strings
+	if want := "-1 2 10 1e1 1a Inf NaN b "; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriter_SerializeTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "byType and sortBy",
+			tmpl: `{{ range sortBy "Data.Name" (byType "KMod" .) }}{{ .Data.Name }} {{ end }}`,
+			want: "nvidia overlay ",
+		},
+		{
+			name: "sortBy numbers",
+			tmpl: `{{ range sortBy "Data.Value" (byType "Sysctl" .) }}{{ .Data.Value }} {{ end }}`,
+			want: "50 60 262144 ",
+		},
+		{
+			name: "multiple types",
+			tmpl: `{{ len (byType "KMod,Grub" .) }}`,
+			want: "3",
+		},
+		{
+			name: "sysctl by name",
+			tmpl: `{{ sysctl "vm.swappiness" . }}`,
+			want: "60",
+		},
+		{
+			name: "sysctl by path",
+			tmpl: `{{ sysctl "/proc/sys/vm/swappiness" . }}`,
+			want: "60",
+		},
+		{
+			name: "missing sysctl",
+			tmpl: `[{{ sysctl "vm.overcommit_memory" . }}]`,
+			want: "[]",
+		},
+		{
+			name: "toJson",
+			tmpl: `{{ range byType "Grub" . }}{{ toJson .Data }}{{ end }}`,
+			want: `{"Key":"iommu","Value":"pt"}`,
+		},
+		{
+			name: "toYaml",
+			tmpl: `{{ range byType "Grub" . }}{{ toYaml .Data }}{{ end }}`,
+			want: "key: iommu\nvalue: pt",
+		},
+		{
+			name: "field",
+			tmpl: `{{ range byType "Grub" . }}{{ field "Data.Value" . | upper }}{{ end }}`,
+			want: "PT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := serializers.NewWriter(serializers.FormatTemplate, &buf, serializers.WithTemplate(tt.tmpl))
+
+			if err := writer.Serialize(templateSnapshot); err != nil {
+				t.Fatalf("Serialize failed: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_SerializeTemplate_ExecError(t *testing.T) {
+	var buf bytes.Buffer
+	writer := serializers.NewWriter(serializers.FormatTemplate, &buf, serializers.WithTemplate(`{{ byType "KMod" 42 }}`))
+
+	err := writer.Serialize(templateSnapshot)
+	if err == nil || !strings.Contains(err.Error(), "failed to execute template") {
+		t.Errorf("Expected execution error, got %v", err)
+	}
+}
@@ -6,3 +6,14 @@ package serializers
 type Serializer interface {
 	Serialize(config any) error
 }
+
+// StreamSerializer is a Serializer that can write items as they are produced
+// instead of buffering the complete result first.
+type StreamSerializer interface {
+	Serializer
+	// Streaming reports whether items should be passed to SerializeItems as they
+	// become available rather than to Serialize once at the end.
+	Streaming() bool
+	// SerializeItems writes each element of the items slice as a separate record.
+	SerializeItems(items any) error
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,28 +21,56 @@ const (
 	FormatYAML Format = "yaml"
 	// FormatTable outputs data in table format
 	FormatTable Format = "table"
+	// FormatNDJSON outputs one compact JSON document per item and line
+	FormatNDJSON Format = "ndjson"
+	// FormatTemplate renders data with a user provided Go template
+	FormatTemplate Format = "template"
+	// FormatHTML outputs a self-contained, human-readable HTML report
+	FormatHTML Format = "html"
+	// FormatMarkdown outputs a human-readable Markdown report
+	FormatMarkdown Format = "markdown"
+	// FormatPrometheus outputs metrics in the Prometheus text exposition format
+	FormatPrometheus Format = "prometheus"
+	// FormatJUnit outputs validation results as a JUnit XML report
+	FormatJUnit Format = "junit"
+	// FormatSARIF outputs validation results as a SARIF 2.1.0 log
+	FormatSARIF Format = "sarif"
 )
 
 // Writer handles serialization of configuration data to various formats.
 type Writer struct {
-	format Format
-	output io.Writer
+	format   Format
+	output   io.Writer
+	template string
 }
 
+// Option configures a Writer.
+type Option func(*Writer)
+
 // NewWriter creates a new Writer with the specified format and output destination.
 // If output is nil, os.Stdout will be used.
-func NewWriter(format Format, output io.Writer) *Writer {
+func NewWriter(format Format, output io.Writer, opts ...Option) *Writer {
 	if output == nil {
 		output = os.Stdout
 	}
-	return &Writer{
+	w := &Writer{
 		format: format,
 		output: output,
 	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
 }
 
 // Serialize outputs the given configuration data in the configured format.
 func (w *Writer) Serialize(config any) error {
+	// Only JSON and YAML carry the snapshot envelope, the other formats describe
+	// the configurations
+	if s, ok := config.(*snapshot.Snapshot); ok && w.format != FormatJSON && w.format != FormatYAML {
+		config = s.Items
+	}
+
 	switch w.format {
 	case FormatJSON:
 		return w.serializeJSON(config)
@@ -48,11 +78,40 @@ func (w *Writer) Serialize(config any) error {
 		return w.serializeYAML(config)
 	case FormatTable:
 		return w.serializeTable(config)
+	case FormatNDJSON:
+		return w.serializeNDJSON(config)
+	case FormatTemplate:
+		return w.serializeTemplate(config)
+	case FormatHTML:
+		return w.serializeHTML(config)
+	case FormatMarkdown:
+		return w.serializeMarkdown(config)
+	case FormatPrometheus:
+		return w.serializePrometheus(config)
+	case FormatJUnit:
+		return w.serializeJUnit(config)
+	case FormatSARIF:
+		return w.serializeSARIF(config)
 	default:
 		return fmt.Errorf("unsupported format: %s", w.format)
 	}
 }
 
+// Streaming reports whether the writer emits items incrementally, which is the
+// case for the NDJSON format. It implements the StreamSerializer interface.
+func (w *Writer) Streaming() bool {
+	return w.format == FormatNDJSON
+}
+
+// SerializeItems writes each element of the items slice as its own record.
+// It implements the StreamSerializer interface.
+func (w *Writer) SerializeItems(items any) error {
+	if !w.Streaming() {
+		return fmt.Errorf("format %s does not support streaming", w.format)
+	}
+	return w.serializeNDJSON(items)
+}
+
 func (w *Writer) serializeJSON(config any) error {
 	encoder := json.NewEncoder(w.output)
 	encoder.SetIndent("", "  ")
@@ -71,19 +130,39 @@ func (w *Writer) serializeYAML(config any) error {
 	return nil
 }
 
+func (w *Writer) serializeNDJSON(config any) error {
+	encoder := json.NewEncoder(w.output)
+
+	// Each element of a slice is a line, anything else is a single line
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		if err := encoder.Encode(config); err != nil {
+			return fmt.Errorf("failed to serialize to NDJSON: %w", err)
+		}
+		return nil
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := encoder.Encode(v.Index(i).Interface()); err != nil {
+			return fmt.Errorf("failed to serialize to NDJSON: %w", err)
+		}
+	}
+	return nil
+}
+
 func (w *Writer) serializeTable(config any) error {
 	// Simple table implementation
 	fmt.Fprintln(w.output, "Configuration Snapshot:")
 	fmt.Fprintln(w.output, "----------------------")
 
-	// Type assertion to our expected structure
-	configs, ok := config.([]interface{})
-	if !ok {
-		return fmt.Errorf("unsupported config type for table format")
+	// Any slice, e.g. []collectors.Configuration or []interface{}
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Errorf("unsupported config type %T for table format", config)
 	}
 
-	for i, cfg := range configs {
-		fmt.Fprintf(w.output, "\n[%d] %+v\n", i+1, cfg)
+	for i := 0; i < v.Len(); i++ {
+		fmt.Fprintf(w.output, "\n[%d] %+v\n", i+1, v.Index(i).Interface())
 	}
 
 	return nil
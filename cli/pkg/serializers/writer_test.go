@@ -130,3 +130,43 @@ func TestNewWriter_DefaultsToStdout(t *testing.T) {
 		t.Fatal("Expected non-nil writer with nil output")
 	}
 }
+
+func TestWriter_SerializeNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	writer := serializers.NewWriter(serializers.FormatNDJSON, &buf)
+
+	if !writer.Streaming() {
+		t.Fatal("Expected NDJSON writer to be streaming")
+	}
+
+	if err := writer.SerializeItems([]testConfig{{Name: "test1", Value: 123}}); err != nil {
+		t.Fatalf("SerializeItems failed: %v", err)
+	}
+	if err := writer.SerializeItems([]testConfig{{Name: "test2", Value: 456}}); err != nil {
+		t.Fatalf("SerializeItems failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+
+	for i, line := range lines {
+		var result testConfig
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("Failed to unmarshal line %d: %v", i, err)
+		}
+	}
+}
+
+func TestWriter_SerializeItems_NotStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	writer := serializers.NewWriter(serializers.FormatJSON, &buf)
+
+	if writer.Streaming() {
+		t.Error("Expected JSON writer not to be streaming")
+	}
+	if err := writer.SerializeItems([]testConfig{}); err == nil {
+		t.Error("Expected error for non-streaming format")
+	}
+}
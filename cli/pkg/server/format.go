@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+)
+
+// formats are the serializer formats served over HTTP with their content
+// types. Templates are not served, they would let clients read local files.
+var formats = []struct {
+	format      serializers.Format
+	contentType string
+	mediaTypes  []string
+}{
+	{serializers.FormatJSON, "application/json", []string{"application/json"}},
+	{serializers.FormatYAML, "application/yaml", []string{"application/yaml", "application/x-yaml", "text/yaml"}},
+	{serializers.FormatNDJSON, "application/x-ndjson", []string{"application/x-ndjson", "application/jsonl"}},
+	{serializers.FormatHTML, "text/html; charset=utf-8", []string{"text/html"}},
+	{serializers.FormatMarkdown, "text/markdown; charset=utf-8", []string{"text/markdown"}},
+	{serializers.FormatTable, "text/plain; charset=utf-8", []string{"text/plain"}},
+	{serializers.FormatPrometheus, "text/plain; version=0.0.4; charset=utf-8", nil},
+}
+
+// negotiate selects the output format of a request from the format query
+// parameter or, without it, the Accept header. JSON is the default.
+func negotiate(r *http.Request) (serializers.Format, string, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		for _, f := range formats {
+			if string(f.format) == name {
+				return f.format, f.contentType, nil
+			}
+		}
+		return "", "", fmt.Errorf("unsupported format %q, must be one of %s", name, strings.Join(formatNames(), ", "))
+	}
+
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return serializers.FormatJSON, formats[0].contentType, nil
+	}
+
+	for _, mt := range acceptedMediaTypes(strings.Join(accept, ",")) {
+		if mt == "*/*" || mt == "application/*" {
+			return serializers.FormatJSON, formats[0].contentType, nil
+		}
+		for _, f := range formats {
+			if slices.Contains(f.mediaTypes, mt) {
+				return f.format, f.contentType, nil
+			}
+		}
+		if mt == "text/*" {
+			return serializers.FormatTable, "text/plain; charset=utf-8", nil
+		}
+	}
+	return "", "", fmt.Errorf("none of the accepted media types is supported, use one of %s", strings.Join(formatNames(), ", "))
+}
+
+// acceptedMediaTypes returns the media types of an Accept header by descending
+// quality, without the ones the client refuses with q=0.
+func acceptedMediaTypes(header string) []string {
+	type accepted struct {
+		mediaType string
+		q         float64
+	}
+
+	var res []accepted
+	for _, part := range strings.Split(header, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			res = append(res, accepted{mt, q})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].q > res[j].q })
+
+	types := make([]string, len(res))
+	for i, a := range res {
+		types[i] = a.mediaType
+	}
+	return types
+}
+
+func formatNames() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = string(f.format)
+	}
+	return names
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// metrics are the operational metrics of the agent, served on /metrics.
+type metrics struct {
+	mu              sync.Mutex
+	requests        map[[2]string]int // by handler and status code
+	rateLimited     int
+	snapshotCount   int
+	snapshotErrors  int
+	snapshotSeconds float64
+	lastSuccess     time.Time
+}
+
+func newMetrics() *metrics {
+	return &metrics{requests: make(map[[2]string]int)}
+}
+
+func (m *metrics) request(handler string, code int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{handler, strconv.Itoa(code)}]++
+	if code == 429 {
+		m.rateLimited++
+	}
+}
+
+func (m *metrics) snapshot(d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshotCount++
+	m.snapshotSeconds += d.Seconds()
+	if err != nil {
+		m.snapshotErrors++
+		return
+	}
+	m.lastSuccess = time.Now()
+}
+
+// write writes the metrics in the Prometheus text exposition format.
+func (m *metrics) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([][2]string, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("# HELP eidos_http_requests_total HTTP requests by handler and status code.\n")
+	printf("# TYPE eidos_http_requests_total counter\n")
+	for _, k := range keys {
+		printf("eidos_http_requests_total{handler=%q,code=%q} %d\n", k[0], k[1], m.requests[k])
+	}
+	printf("# HELP eidos_http_rate_limited_total Snapshot requests rejected by the rate limiter.\n")
+	printf("# TYPE eidos_http_rate_limited_total counter\n")
+	printf("eidos_http_rate_limited_total %d\n", m.rateLimited)
+	printf("# HELP eidos_snapshot_duration_seconds Time spent collecting snapshots.\n")
+	printf("# TYPE eidos_snapshot_duration_seconds summary\n")
+	printf("eidos_snapshot_duration_seconds_sum %s\n", strconv.FormatFloat(m.snapshotSeconds, 'g', -1, 64))
+	printf("eidos_snapshot_duration_seconds_count %d\n", m.snapshotCount)
+	printf("# HELP eidos_snapshot_errors_total Snapshots that failed to collect.\n")
+	printf("# TYPE eidos_snapshot_errors_total counter\n")
+	printf("eidos_snapshot_errors_total %d\n", m.snapshotErrors)
+	if !m.lastSuccess.IsZero() {
+		printf("# HELP eidos_snapshot_last_success_timestamp_seconds Time of the last successful snapshot.\n")
+		printf("# TYPE eidos_snapshot_last_success_timestamp_seconds gauge\n")
+		printf("eidos_snapshot_last_success_timestamp_seconds %d\n", m.lastSuccess.Unix())
+	}
+	return err
+}
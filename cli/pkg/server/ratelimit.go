@@ -0,0 +1,54 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting how often snapshots are collected.
+// The bucket holds up to burst tokens and is refilled with rate tokens per
+// second, each request takes one token.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter allowing rate requests per second on
+// average and bursts of up to burst requests. The bucket starts full.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow takes a token if one is available. Otherwise it reports how long to
+// wait until the next token is available.
+func (l *RateLimiter) Allow() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed*l.rate)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	if l.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	return false, time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
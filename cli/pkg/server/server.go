@@ -0,0 +1,319 @@
+// Package server implements a long-running agent serving node snapshots over
+// HTTP, e.g. as a Kubernetes DaemonSet.
+//
+// Endpoints:
+//
+//	GET /snapshot              snapshot of all collectors
+//	GET /snapshot/<collector>  snapshot of a single collector, e.g. /snapshot/sysctl
+//	GET /healthz               liveness probe
+//	GET /metrics               operational metrics of the agent
+//
+// The snapshot format is selected with the format query parameter or the
+// Accept header, and the filter query parameter selects configurations with a
+// query expression.
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/query"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/redact"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
+)
+
+const (
+	// DefaultTimeout bounds the collection of a snapshot.
+	DefaultTimeout = 2 * time.Minute
+
+	// shutdownTimeout bounds the time in-flight requests get to complete on
+	// shutdown.
+	shutdownTimeout = 30 * time.Second
+
+	unixPrefix = "unix://"
+)
+
+// Server serves node snapshots over HTTP.
+type Server struct {
+	Factory collectors.CollectorFactory
+	Logger  *slog.Logger
+	// RedactRules are the rules used to mask secrets in snapshots, redaction
+	// is disabled when empty.
+	RedactRules []redact.Rule
+	// StripVolatile removes timestamps, PIDs and counters from snapshots.
+	StripVolatile bool
+	// Timeout bounds the collection of a snapshot, DefaultTimeout when zero.
+	Timeout time.Duration
+	// Limiter, when set, limits the rate of snapshot requests.
+	Limiter *RateLimiter
+
+	metrics *metrics
+}
+
+// Handler returns the HTTP handler of the server.
+func (s *Server) Handler() http.Handler {
+	s.setDefaults()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /snapshot", s.instrument("snapshot", s.handleSnapshot))
+	mux.HandleFunc("GET /snapshot/{collector}", s.instrument("snapshot", s.handleSnapshot))
+	mux.HandleFunc("GET /healthz", s.instrument("healthz", s.handleHealthz))
+	mux.HandleFunc("GET /metrics", s.instrument("metrics", s.handleMetrics))
+	return mux
+}
+
+// Serve serves HTTP requests on l until ctx is canceled, then shuts down
+// gracefully, giving in-flight requests time to complete.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          slog.NewLogLogger(s.Logger.Handler(), slog.LevelWarn),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(l)
+	}()
+	s.Logger.Info("serving snapshots", slog.String("address", listenerAddr(l)))
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.Logger.Info("shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		_ = srv.Close()
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
+
+// Listen listens on a TCP address, e.g. ":8080", or on a Unix socket given as
+// "unix:///path/to/socket". A stale socket file is replaced, the socket is
+// only accessible by the owner and group.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		return l, nil
+	}
+
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return l, nil
+}
+
+func (s *Server) setDefaults() {
+	if s.Logger == nil {
+		s.Logger = slog.Default()
+	}
+	if s.Factory == nil {
+		s.Factory = collectors.NewDefaultCollectorFactory()
+	}
+	if s.Timeout == 0 {
+		s.Timeout = DefaultTimeout
+	}
+	if s.metrics == nil {
+		s.metrics = newMetrics()
+	}
+}
+
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	format, contentType, err := negotiate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+
+	var only []string
+	if name := r.PathValue("collector"); name != "" {
+		if !slices.Contains(snapshotter.CollectorNames(), name) {
+			http.Error(w, fmt.Sprintf("unknown collector %q, must be one of %s",
+				name, strings.Join(snapshotter.CollectorNames(), ", ")), http.StatusNotFound)
+			return
+		}
+		only = []string{name}
+	}
+
+	var filter func(collectors.Configuration) bool
+	if expr := r.URL.Query().Get("filter"); expr != "" {
+		q, err := query.Parse(expr)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid filter: %v", err), http.StatusBadRequest)
+			return
+		}
+		filter = q.Match
+	}
+
+	if s.Limiter != nil {
+		if ok, wait := s.Limiter.Allow(); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	// Each request gets its own redactor, the redaction report is per snapshot
+	var redactor *redact.Redactor
+	if len(s.RedactRules) > 0 {
+		if redactor, err = redact.New(s.RedactRules...); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.Timeout)
+	defer cancel()
+
+	// Streamed formats are written as collectors complete, the others are
+	// buffered so failures can still be reported with an error status
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	stream := &flushWriter{w: w, contentType: contentType}
+	if format == serializers.FormatNDJSON {
+		out = stream
+	}
+
+	ns := snapshotter.NodeSnapshotter{
+		Factory:       s.Factory,
+		Serializer:    serializers.NewWriter(format, out),
+		Logger:        s.Logger.With(slog.String("remote", r.RemoteAddr), slog.String("path", r.URL.Path)),
+		Filter:        filter,
+		Redactor:      redactor,
+		StripVolatile: s.StripVolatile,
+		Collectors:    only,
+	}
+
+	start := time.Now()
+	err = ns.Run(ctx)
+	s.metrics.snapshot(time.Since(start), err)
+	if err != nil {
+		if stream.written {
+			// The status has been sent, the truncated stream signals the failure
+			return
+		}
+		code := http.StatusInternalServerError
+		if errors.Is(err, context.DeadlineExceeded) {
+			code = http.StatusGatewayTimeout
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	if format != serializers.FormatNDJSON {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		if _, err := buf.WriteTo(w); err != nil {
+			s.Logger.Debug("failed to write response", slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.metrics.write(w); err != nil {
+		s.Logger.Debug("failed to write metrics", slog.String("error", err.Error()))
+	}
+}
+
+// instrument records the status code of each request in the metrics and logs
+// the request.
+func (s *Server) instrument(handler string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next(rec, r)
+		s.metrics.request(handler, rec.code)
+		s.Logger.Debug("request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("remote", r.RemoteAddr),
+			slog.Int("status", rec.code),
+			slog.Duration("duration", time.Since(start)))
+	}
+}
+
+// statusRecorder records the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// flushWriter writes streamed output to a response, flushing after each write
+// so clients receive records as they are produced.
+type flushWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	written     bool
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	if !f.written {
+		f.w.Header().Set("Content-Type", f.contentType)
+		f.written = true
+	}
+	n, err := f.w.Write(p)
+	if fl, ok := f.w.(http.Flusher); ok {
+		fl.Flush()
+	}
+	return n, err
+}
+
+func listenerAddr(l net.Listener) string {
+	if l.Addr().Network() == "unix" {
+		return unixPrefix + l.Addr().String()
+	}
+	return l.Addr().String()
+}
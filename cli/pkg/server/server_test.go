@@ -0,0 +1,336 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/redact"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/server"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+type staticCollector struct {
+	configs []collectors.Configuration
+	err     error
+}
+
+func (c *staticCollector) Collect(ctx context.Context) ([]collectors.Configuration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return append([]collectors.Configuration(nil), c.configs...), c.err
+}
+
+// fakeFactory returns static sysctl and kmod configurations, the other
+// collectors return nothing.
+type fakeFactory struct {
+	err error
+}
+
+func (f *fakeFactory) CreateKModCollector() collectors.Collector {
+	return &staticCollector{configs: []collectors.Configuration{
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+	}}
+}
+func (f *fakeFactory) CreateSystemDCollector() collectors.Collector {
+	return &staticCollector{configs: []collectors.Configuration{
+		{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+			Unit:       "kubelet.service",
+			Properties: map[string]any{"Environment": []any{"PASSWORD=hunter2"}},
+		}},
+	}}
+}
+func (f *fakeFactory) CreateGrubCollector() collectors.Collector { return &staticCollector{} }
+func (f *fakeFactory) CreateSysctlCollector() collectors.Collector {
+	return &staticCollector{err: f.err, configs: []collectors.Configuration{
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "0"}},
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/net/ipv4/ip_forward", Value: "1"}},
+	}}
+}
+func (f *fakeFactory) CreateNetworkCollector() collectors.Collector  { return &staticCollector{} }
+func (f *fakeFactory) CreatePackageCollector() collectors.Collector  { return &staticCollector{} }
+func (f *fakeFactory) CreateSecurityCollector() collectors.Collector { return &staticCollector{} }
+func (f *fakeFactory) CreateMountCollector() collectors.Collector    { return &staticCollector{} }
+func (f *fakeFactory) CreateGPUCollector() collectors.Collector      { return &staticCollector{} }
+
+func newTestServer(t *testing.T, s *server.Server) *httptest.Server {
+	t.Helper()
+	if s.Factory == nil {
+		s.Factory = &fakeFactory{}
+	}
+	s.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func get(t *testing.T, url string, header ...string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestServer_Snapshot(t *testing.T) {
+	ts := newTestServer(t, &server.Server{RedactRules: redact.DefaultRules()})
+
+	resp, body := get(t, ts.URL+"/snapshot")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	s, err := snapshot.Decode(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to decode snapshot: %v", err)
+	}
+	if len(s.Items) != 4 {
+		t.Errorf("got %d items, want 4", len(s.Items))
+	}
+	if s.Metadata.Redacted != 1 {
+		t.Errorf("redacted = %d, want 1", s.Metadata.Redacted)
+	}
+	if strings.Contains(body, "hunter2") {
+		t.Error("secret not redacted")
+	}
+}
+
+func TestServer_SnapshotCollector(t *testing.T) {
+	ts := newTestServer(t, &server.Server{})
+
+	resp, body := get(t, ts.URL+"/snapshot/sysctl?filter=Key=/proc/sys/vm/*")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	s, err := snapshot.Decode(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Items) != 1 || s.Items[0].Type != collectors.SysctlType {
+		t.Errorf("items = %+v, want the swappiness sysctl", s.Items)
+	}
+
+	resp, _ = get(t, ts.URL+"/snapshot/nope")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown collector status = %d, want 404", resp.StatusCode)
+	}
+
+	resp, _ = get(t, ts.URL+"/snapshot?filter=Key=~(")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid filter status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestServer_Negotiation(t *testing.T) {
+	ts := newTestServer(t, &server.Server{})
+
+	tests := []struct {
+		name        string
+		query       string
+		accept      string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{"default", "", "", 200, "application/json", `"apiVersion"`},
+		{"wildcard", "", "*/*", 200, "application/json", `"apiVersion"`},
+		{"yaml query", "?format=yaml", "", 200, "application/yaml", "apiVersion:"},
+		{"yaml accept", "", "application/yaml", 200, "application/yaml", "apiVersion:"},
+		{"quality", "", "text/html;q=0.5, text/markdown", 200, "text/markdown; charset=utf-8", "nvidia"},
+		{"html", "", "text/html", 200, "text/html; charset=utf-8", "<html"},
+		{"text", "", "text/plain", 200, "text/plain; charset=utf-8", "Configuration Snapshot"},
+		{"text wildcard", "", "text/*", 200, "text/plain; charset=utf-8", "nvidia"},
+		{"table query", "?format=table", "", 200, "text/plain; charset=utf-8", "[1]"},
+		{"prometheus", "?format=prometheus", "", 200, "text/plain; version=0.0.4; charset=utf-8", `eidos_kernel_module_loaded{module="nvidia"} 1`},
+		{"query wins", "?format=json", "application/yaml", 200, "application/json", `"apiVersion"`},
+		{"unsupported query", "?format=go-template={{.}}", "", 406, "", ""},
+		{"unsupported accept", "", "image/png", 406, "", ""},
+		{"refused", "", "application/json;q=0", 406, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header []string
+			if tt.accept != "" {
+				header = []string{"Accept", tt.accept}
+			}
+			resp, body := get(t, ts.URL+"/snapshot"+tt.query, header...)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", resp.StatusCode, tt.status, body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if !strings.Contains(body, tt.contains) {
+				t.Errorf("body does not contain %q:\n%s", tt.contains, body)
+			}
+		})
+	}
+}
+
+func TestServer_SnapshotNDJSON(t *testing.T) {
+	ts := newTestServer(t, &server.Server{})
+
+	resp, body := get(t, ts.URL+"/snapshot", "Accept", "application/x-ndjson")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var lines int
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		var c collectors.Configuration
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			t.Fatalf("invalid line %q: %v", sc.Text(), err)
+		}
+		lines++
+	}
+	if lines != 4 {
+		t.Errorf("got %d lines, want 4", lines)
+	}
+}
+
+func TestServer_SnapshotError(t *testing.T) {
+	ts := newTestServer(t, &server.Server{Factory: &fakeFactory{err: errors.New("boom")}})
+
+	resp, body := get(t, ts.URL+"/snapshot")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+	if !strings.Contains(body, "boom") {
+		t.Errorf("body = %q, want the collector error", body)
+	}
+
+	resp, body = get(t, ts.URL+"/metrics")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("metrics status = %d", resp.StatusCode)
+	}
+	for _, want := range []string{
+		`eidos_http_requests_total{handler="snapshot",code="500"} 1`,
+		"eidos_snapshot_errors_total 1",
+		"eidos_snapshot_duration_seconds_count 1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	ts := newTestServer(t, &server.Server{Limiter: server.NewRateLimiter(0.001, 2)})
+
+	for i := range 2 {
+		if resp, body := get(t, ts.URL+"/snapshot"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d status = %d, body %s", i, resp.StatusCode, body)
+		}
+	}
+
+	resp, _ := get(t, ts.URL+"/snapshot")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", resp.StatusCode)
+	}
+	if ra := resp.Header.Get("Retry-After"); ra == "" || ra == "0" {
+		t.Errorf("Retry-After = %q", ra)
+	}
+
+	// Health checks and metrics are not rate limited
+	if resp, _ := get(t, ts.URL+"/healthz"); resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz status = %d", resp.StatusCode)
+	}
+	_, body := get(t, ts.URL+"/metrics")
+	if !strings.Contains(body, "eidos_http_rate_limited_total 1") {
+		t.Errorf("metrics do not count the rejected request:\n%s", body)
+	}
+}
+
+func TestServer_Methods(t *testing.T) {
+	ts := newTestServer(t, &server.Server{})
+
+	resp, err := http.Post(ts.URL+"/snapshot", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestServe_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eidos.sock")
+	l, err := server.Listen("unix://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &server.Server{Factory: &fakeFactory{}, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, l) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://eidos/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok\n" {
+		t.Errorf("healthz = %d %q", resp.StatusCode, body)
+	}
+	client.CloseIdleConnections()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	// A stale socket is replaced
+	l, err = server.Listen("unix://" + path)
+	if err != nil {
+		t.Fatalf("failed to listen on stale socket: %v", err)
+	}
+	l.Close()
+}
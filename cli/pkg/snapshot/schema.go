@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect of the generated schema.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns the JSON Schema of the JSON snapshot document. It is generated
+// from the snapshot and collector types, so it always matches this version.
+func Schema() map[string]any {
+	defs := make(map[string]any)
+
+	variants := make([]any, 0, len(collectors.Types()))
+	for _, typ := range collectors.Types() {
+		t, _ := collectors.DataType(typ)
+		variants = append(variants, map[string]any{
+			"type":                 "object",
+			"required":             []string{"Type", "Data"},
+			"additionalProperties": false,
+			"properties": map[string]any{
+				"Type": map[string]any{"const": typ},
+				"Data": schemaFor(t, defs),
+			},
+		})
+	}
+	defs["Configuration"] = map[string]any{"oneOf": variants}
+
+	return map[string]any{
+		"$schema":              jsonSchemaDraft,
+		"title":                "eidos snapshot " + APIVersion,
+		"type":                 "object",
+		"required":             []string{"apiVersion", "kind", "items"},
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"apiVersion": map[string]any{"const": APIVersion},
+			"kind":       map[string]any{"const": Kind},
+			"metadata":   schemaFor(reflect.TypeOf(Metadata{}), defs),
+			"items": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": "#/$defs/Configuration"},
+			},
+			"signature": schemaFor(reflect.TypeOf(Signature{}), defs),
+		},
+		"$defs": defs,
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema of a Go type as encoded by encoding/json. Structs
+// are added to defs and referenced.
+func schemaFor(t reflect.Type, defs map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		// nil slices and maps are encoded as null
+		return map[string]any{"type": []string{"array", "null"}, "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			// Register before recursing in case of self references
+			defs[t.Name()] = nil
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	default:
+		// any, decoded by type in the consumer
+		return map[string]any{}
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = schemaFor(f.Type, defs)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"required":             required,
+		"additionalProperties": false,
+		"properties":           properties,
+	}
+}
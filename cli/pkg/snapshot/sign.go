@@ -0,0 +1,176 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SignatureAlgorithm is the algorithm of snapshot signatures.
+const SignatureAlgorithm = "ed25519"
+
+// Signature is a signature over the canonical JSON encoding of the snapshot
+// document without its signature field.
+type Signature struct {
+	Algorithm string `json:"algorithm" yaml:"algorithm"`
+	// KeyID is the SHA256 fingerprint of the public key, "SHA256:<base64>".
+	KeyID string `json:"keyId" yaml:"keyId"`
+	// Value is the base64 encoded signature.
+	Value string `json:"value" yaml:"value"`
+}
+
+// Sign signs the snapshot with the private key, replacing any existing signature.
+func (s *Snapshot) Sign(key ed25519.PrivateKey) error {
+	s.Signature = nil
+	b, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	payload, err := canonicalize(b)
+	if err != nil {
+		return err
+	}
+
+	s.Signature = &Signature{
+		Algorithm: SignatureAlgorithm,
+		KeyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	}
+	return nil
+}
+
+// Verify checks the signature of a JSON snapshot document against the public
+// key and returns the decoded snapshot. The document is verified as written,
+// before decoding, so values of untyped fields can't change the payload.
+func Verify(data []byte, pub ed25519.PublicKey) (*Snapshot, error) {
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("signed snapshots must be JSON documents: %w", err)
+	}
+
+	raw, ok := doc["signature"]
+	if !ok {
+		return nil, errors.New("snapshot is not signed")
+	}
+	delete(doc, "signature")
+
+	var sig Signature
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if err := json.Unmarshal(b, &sig); err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if sig.Algorithm != SignatureAlgorithm {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+	if id := KeyID(pub); sig.KeyID != id {
+		return nil, fmt.Errorf("snapshot was signed with key %s, not %s", sig.KeyID, id)
+	}
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature value: %w", err)
+	}
+
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if !ed25519.Verify(pub, payload, value) {
+		return nil, errors.New("signature verification failed, the snapshot was modified")
+	}
+
+	s, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// canonicalize re-encodes a JSON document with sorted object keys, no
+// insignificant whitespace and numbers as written.
+func canonicalize(b []byte) ([]byte, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to canonicalize snapshot: %w", err)
+	}
+	res, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize snapshot: %w", err)
+	}
+	return res, nil
+}
+
+// KeyID returns the SHA256 fingerprint of a public key.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// LoadPrivateKey reads a PEM encoded PKCS #8 ed25519 private key, as created by
+// "openssl genpkey -algorithm ed25519".
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %T, not an ed25519 private key", path, key)
+	}
+	return priv, nil
+}
+
+// LoadPublicKey reads a PEM encoded PKIX ed25519 public key, as created by
+// "openssl pkey -pubout". The public key of a private key file is accepted too.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "PRIVATE KEY" {
+		priv, err := LoadPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return priv.Public().(ed25519.PublicKey), nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %T, not an ed25519 public key", path, key)
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded key", path)
+	}
+	return block, nil
+}
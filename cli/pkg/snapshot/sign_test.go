@@ -0,0 +1,171 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+// writeKeys writes a new PEM encoded key pair and returns the file paths.
+func writeKeys(t *testing.T) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+
+	dir := t.TempDir()
+	privPath := filepath.Join(dir, "eidos.key")
+	pubPath := filepath.Join(dir, "eidos.pub")
+	if err := os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600); err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o600); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	return privPath, pubPath
+}
+
+// signedSnapshot returns a signed snapshot as written by the JSON format.
+func signedSnapshot(t *testing.T, privPath string) []byte {
+	t.Helper()
+	priv, err := snapshot.LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey failed: %v", err)
+	}
+
+	s := snapshot.New([]collectors.Configuration{
+		{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+		{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+			Unit: "kubelet.service",
+			Properties: map[string]any{
+				// Values that don't survive a round trip through float64
+				"TimeoutStopUSec": uint64(18446744073709551615),
+				"ExecStart":       [][]any{{"/usr/bin/kubelet", []string{"kubelet", "--v=2"}, false, uint64(1700000000123456)}},
+				"Description":     "kubelet <&>",
+			},
+		}},
+	})
+	if err := s.Sign(priv); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatJSON, &buf).Serialize(s); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSignVerify(t *testing.T) {
+	privPath, pubPath := writeKeys(t)
+	data := signedSnapshot(t, privPath)
+
+	for _, keyPath := range []string{pubPath, privPath} {
+		pub, err := snapshot.LoadPublicKey(keyPath)
+		if err != nil {
+			t.Fatalf("LoadPublicKey(%s) failed: %v", keyPath, err)
+		}
+		s, err := snapshot.Verify(data, pub)
+		if err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+		if s.Signature == nil || s.Signature.KeyID != snapshot.KeyID(pub) || len(s.Items) != 2 {
+			t.Errorf("unexpected verified snapshot: %+v", s)
+		}
+	}
+}
+
+func TestVerify_Failures(t *testing.T) {
+	privPath, pubPath := writeKeys(t)
+	data := signedSnapshot(t, privPath)
+	pub, err := snapshot.LoadPublicKey(pubPath)
+	if err != nil {
+		t.Fatalf("LoadPublicKey failed: %v", err)
+	}
+
+	_, otherPubPath := writeKeys(t)
+	otherPub, err := snapshot.LoadPublicKey(otherPubPath)
+	if err != nil {
+		t.Fatalf("LoadPublicKey failed: %v", err)
+	}
+
+	var unsigned bytes.Buffer
+	if err := serializers.NewWriter(serializers.FormatJSON, &unsigned).Serialize(snapshot.New(nil)); err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		key  ed25519.PublicKey
+		want string
+	}{
+		{"modified value", bytes.Replace(data, []byte(`"nvidia"`), []byte(`"nouveau"`), 1), pub, "modified"},
+		{"modified number", bytes.Replace(data, []byte("18446744073709551615"), []byte("18446744073709551614"), 1), pub, "modified"},
+		{"added field", bytes.Replace(data, []byte(`"kind"`), []byte(`"extra": 1, "kind"`), 1), pub, "modified"},
+		{"other key", data, otherPub, "signed with key"},
+		{"unsigned", unsigned.Bytes(), pub, "not signed"},
+		{"yaml", []byte("apiVersion: x\n"), pub, "JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := snapshot.Verify(tt.data, tt.key)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestVerify_Reformatted(t *testing.T) {
+	// Whitespace and key order are not part of the signed payload
+	privPath, pubPath := writeKeys(t)
+	data := signedSnapshot(t, privPath)
+	pub, err := snapshot.LoadPublicKey(pubPath)
+	if err != nil {
+		t.Fatalf("LoadPublicKey failed: %v", err)
+	}
+
+	compact := bytes.ReplaceAll(bytes.ReplaceAll(data, []byte("\n"), nil), []byte("  "), nil)
+	if _, err := snapshot.Verify(compact, pub); err != nil {
+		t.Errorf("Verify of reformatted snapshot failed: %v", err)
+	}
+}
+
+func TestLoadKey_Errors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := snapshot.LoadPrivateKey(notPEM); err == nil {
+		t.Error("expected error for non PEM private key")
+	}
+	if _, err := snapshot.LoadPublicKey(filepath.Join(dir, "missing.pub")); err == nil {
+		t.Error("expected error for missing public key")
+	}
+	_, pubPath := writeKeys(t)
+	if _, err := snapshot.LoadPrivateKey(pubPath); err == nil {
+		t.Error("expected error for public key used as private key")
+	}
+}
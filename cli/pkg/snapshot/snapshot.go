@@ -0,0 +1,178 @@
+// Package snapshot defines the versioned document eidos snapshots are written
+// as and read from.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// APIVersion is the version of the snapshot document format.
+	APIVersion = "eidos.nvidia.com/v1alpha1"
+	// Kind identifies a snapshot document.
+	Kind = "Snapshot"
+)
+
+// Snapshot is the versioned envelope around the configurations of a node.
+type Snapshot struct {
+	APIVersion string                     `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string                     `json:"kind" yaml:"kind"`
+	Metadata   Metadata                   `json:"metadata" yaml:"metadata"`
+	Items      []collectors.Configuration `json:"items" yaml:"items"`
+	// Signature is set on signed snapshots, see Sign and Verify.
+	Signature *Signature `json:"signature,omitempty" yaml:"signature,omitempty"`
+}
+
+// Metadata describes where and when a snapshot was taken. Created is empty in
+// snapshots stripped of volatile fields. Redacted is the number of values
+// masked because they looked like secrets. Labels identify the snapshot, e.g.
+// the node it was taken on in a cluster snapshot.
+type Metadata struct {
+	Hostname string            `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Created  time.Time         `json:"created,omitzero" yaml:"created,omitempty"`
+	Redacted int               `json:"redacted,omitempty" yaml:"redacted,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// New wraps configurations collected on this node in a snapshot.
+func New(items []collectors.Configuration) *Snapshot {
+	hostname, _ := os.Hostname()
+	return &Snapshot{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata: Metadata{
+			Hostname: hostname,
+			Created:  time.Now().UTC().Truncate(time.Second),
+		},
+		Items: items,
+	}
+}
+
+// StripVolatile removes the creation time and the volatile fields of the
+// configurations, see collectors.StripVolatile, so snapshots of an unchanged
+// node are identical.
+func (s *Snapshot) StripVolatile() {
+	s.Metadata.Created = time.Time{}
+	for i, c := range s.Items {
+		s.Items[i] = collectors.StripVolatile(c)
+	}
+}
+
+// SetItems replaces the configurations of the snapshot, e.g. with a subset
+// selected by a query. The signature doesn't cover the new items and is
+// removed, sign the snapshot again to keep it verifiable.
+func (s *Snapshot) SetItems(items []collectors.Configuration) {
+	s.Items = items
+	s.Signature = nil
+}
+
+// Decode reads a snapshot written in the JSON, NDJSON or YAML format. Plain
+// lists of configurations, as written before the versioned format, are
+// accepted too. Configuration data is decoded into the collector's types.
+func Decode(r io.Reader) (*Snapshot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("empty snapshot")
+	}
+
+	switch data[0] {
+	case '[':
+		var items []collectors.Configuration
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON snapshot: %w", err)
+		}
+		return wrap(items), nil
+	case '{':
+		return decodeJSON(data)
+	default:
+		return decodeYAML(data)
+	}
+}
+
+// decodeJSON decodes a snapshot document or NDJSON configurations.
+func decodeJSON(data []byte) (*Snapshot, error) {
+	var probe struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var first json.RawMessage
+	if err := dec.Decode(&first); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON snapshot: %w", err)
+	}
+	if err := json.Unmarshal(first, &probe); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON snapshot: %w", err)
+	}
+
+	if probe.APIVersion != "" {
+		var s Snapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON snapshot: %w", err)
+		}
+		return &s, s.validate()
+	}
+
+	// NDJSON, one configuration per line. Collectors are streamed as they
+	// complete, the items are sorted like in a snapshot document.
+	var items []collectors.Configuration
+	dec = json.NewDecoder(bytes.NewReader(data))
+	for {
+		var c collectors.Configuration
+		if err := dec.Decode(&c); err != nil {
+			if errors.Is(err, io.EOF) {
+				collectors.Sort(items)
+				return wrap(items), nil
+			}
+			return nil, fmt.Errorf("failed to decode NDJSON snapshot: %w", err)
+		}
+		items = append(items, c)
+	}
+}
+
+func decodeYAML(data []byte) (*Snapshot, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to decode YAML snapshot: %w", err)
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 && node.Content[0].Kind == yaml.SequenceNode {
+		var items []collectors.Configuration
+		if err := node.Decode(&items); err != nil {
+			return nil, fmt.Errorf("failed to decode YAML snapshot: %w", err)
+		}
+		return wrap(items), nil
+	}
+
+	var s Snapshot
+	if err := node.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode YAML snapshot: %w", err)
+	}
+	return &s, s.validate()
+}
+
+// wrap puts configurations read from a plain list into an envelope.
+func wrap(items []collectors.Configuration) *Snapshot {
+	return &Snapshot{APIVersion: APIVersion, Kind: Kind, Items: items}
+}
+
+func (s *Snapshot) validate() error {
+	if s.APIVersion != APIVersion {
+		return fmt.Errorf("unsupported snapshot apiVersion %q, expected %q", s.APIVersion, APIVersion)
+	}
+	if s.Kind != Kind {
+		return fmt.Errorf("unsupported snapshot kind %q, expected %q", s.Kind, Kind)
+	}
+	return nil
+}
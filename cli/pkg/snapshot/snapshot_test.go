@@ -0,0 +1,197 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+)
+
+var items = []collectors.Configuration{
+	{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+	{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+		Unit:       "containerd.service",
+		Backend:    collectors.SystemDBackendFile,
+		Properties: map[string]any{"ActiveState": "active"},
+	}},
+	{Type: collectors.RDMAType, Data: collectors.RDMADeviceConfig{
+		Name:  "mlx5_0",
+		Ports: []collectors.RDMAPortConfig{{Port: 1, State: "ACTIVE", Rate: "200 Gb/sec (4X HDR)"}},
+	}},
+	{Type: collectors.SwapType, Data: collectors.SwapConfig{Source: collectors.SwapSourceProc, Device: "/swap.img", SizeKB: 1024}},
+}
+
+func TestDecode_RoundTrip(t *testing.T) {
+	for _, format := range []serializers.Format{serializers.FormatJSON, serializers.FormatYAML} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := serializers.NewWriter(format, &buf).Serialize(snapshot.New(items)); err != nil {
+				t.Fatalf("Serialize failed: %v", err)
+			}
+
+			s, err := snapshot.Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if s.APIVersion != snapshot.APIVersion || s.Kind != snapshot.Kind {
+				t.Errorf("unexpected envelope %s/%s", s.APIVersion, s.Kind)
+			}
+			if s.Metadata.Hostname == "" || s.Metadata.Created.IsZero() {
+				t.Errorf("expected metadata, got %+v", s.Metadata)
+			}
+			assertTyped(t, s.Items)
+		})
+	}
+}
+
+func TestDecode_Lists(t *testing.T) {
+	var jsonList, ndjson, yamlList bytes.Buffer
+	for format, buf := range map[serializers.Format]*bytes.Buffer{
+		serializers.FormatJSON:   &jsonList,
+		serializers.FormatNDJSON: &ndjson,
+		serializers.FormatYAML:   &yamlList,
+	} {
+		if err := serializers.NewWriter(format, buf).Serialize(items); err != nil {
+			t.Fatalf("Serialize %s failed: %v", format, err)
+		}
+	}
+
+	for name, buf := range map[string]*bytes.Buffer{"json": &jsonList, "ndjson": &ndjson, "yaml": &yamlList} {
+		t.Run(name, func(t *testing.T) {
+			s, err := snapshot.Decode(buf)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if s.APIVersion != snapshot.APIVersion {
+				t.Errorf("expected list to be wrapped, got apiVersion %q", s.APIVersion)
+			}
+			assertTyped(t, s.Items)
+		})
+	}
+}
+
+func TestDecode_StripVolatile(t *testing.T) {
+	exec := [][]any{{"/usr/bin/containerd", []string{"/usr/bin/containerd"}, false,
+		uint64(1700000000), uint64(5), uint64(0), uint64(0), uint32(1234), int32(0), int32(0)}}
+	unit := collectors.Configuration{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+		Unit:       "containerd.service",
+		Properties: map[string]any{"ExecStart": exec, "Restart": "always"},
+	}}
+
+	for _, format := range []serializers.Format{serializers.FormatJSON, serializers.FormatYAML} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := serializers.NewWriter(format, &buf).Serialize(snapshot.New([]collectors.Configuration{unit})); err != nil {
+				t.Fatalf("Serialize failed: %v", err)
+			}
+			s, err := snapshot.Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+
+			s.StripVolatile()
+
+			got, _ := json.Marshal(s.Items[0].Data.(collectors.SystemDConfig).Properties["ExecStart"])
+			if want := `[["/usr/bin/containerd",["/usr/bin/containerd"],false,0,0,0,0,0,0,0]]`; string(got) != want {
+				t.Errorf("ExecStart = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+// assertTyped checks that the items are decoded to their types, in any order
+// as NDJSON items are sorted on decode.
+func assertTyped(t *testing.T, got []collectors.Configuration) {
+	t.Helper()
+	if len(got) != len(items) {
+		t.Fatalf("expected %d items, got %d", len(items), len(got))
+	}
+	byType := make(map[string]any)
+	for _, c := range got {
+		byType[c.Type] = c.Data
+	}
+	if d, ok := byType[collectors.KModType].(collectors.KModConfig); !ok || d.Name != "nvidia" {
+		t.Errorf("expected KModConfig, got %#v", byType[collectors.KModType])
+	}
+	if d, ok := byType[collectors.SystemDType].(collectors.SystemDConfig); !ok || d.Properties["ActiveState"] != "active" {
+		t.Errorf("expected SystemDConfig, got %#v", byType[collectors.SystemDType])
+	}
+	if d, ok := byType[collectors.RDMAType].(collectors.RDMADeviceConfig); !ok || len(d.Ports) != 1 || d.Ports[0].Rate != "200 Gb/sec (4X HDR)" {
+		t.Errorf("expected RDMADeviceConfig, got %#v", byType[collectors.RDMAType])
+	}
+	if d, ok := byType[collectors.SwapType].(collectors.SwapConfig); !ok || d.SizeKB != 1024 {
+		t.Errorf("expected SwapConfig, got %#v", byType[collectors.SwapType])
+	}
+}
+
+func TestDecode_UnknownType(t *testing.T) {
+	s, err := snapshot.Decode(strings.NewReader(`[{"Type":"Future","Data":{"Name":"x"}}]`))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if d, ok := s.Items[0].Data.(map[string]any); !ok || d["Name"] != "x" {
+		t.Errorf("expected generic data for unknown type, got %#v", s.Items[0].Data)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	for name, input := range map[string]string{
+		"empty":      "",
+		"apiVersion": `{"apiVersion":"eidos.nvidia.com/v2","kind":"Snapshot","items":[]}`,
+		"kind":       "apiVersion: eidos.nvidia.com/v1alpha1\nkind: Report\nitems: []\n",
+		"data":       `[{"Type":"KMod","Data":{"Name":1}}]`,
+	} {
+		if _, err := snapshot.Decode(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	b, err := json.Marshal(snapshot.Schema())
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %v", err)
+	}
+
+	var schema struct {
+		Required   []string
+		Properties map[string]map[string]any
+		Defs       map[string]struct {
+			OneOf      []map[string]any `json:"oneOf"`
+			Required   []string
+			Properties map[string]map[string]any
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatalf("failed to unmarshal schema: %v", err)
+	}
+
+	if schema.Properties["apiVersion"]["const"] != snapshot.APIVersion {
+		t.Errorf("unexpected apiVersion schema: %v", schema.Properties["apiVersion"])
+	}
+	if n := len(schema.Defs["Configuration"].OneOf); n != len(collectors.Types()) {
+		t.Errorf("expected one variant per type (%d), got %d", len(collectors.Types()), n)
+	}
+
+	for _, typ := range collectors.Types() {
+		dt, _ := collectors.DataType(typ)
+		if _, ok := schema.Defs[dt.Name()]; !ok {
+			t.Errorf("missing definition for %s", dt.Name())
+		}
+	}
+
+	port := schema.Defs["RDMAPortConfig"]
+	if port.Properties["Port"]["type"] != "integer" || port.Properties["State"]["type"] != "string" {
+		t.Errorf("unexpected RDMAPortConfig properties: %v", port.Properties)
+	}
+	if created := schema.Defs["Metadata"].Properties["created"]; created["format"] != "date-time" {
+		t.Errorf("expected date-time for created, got %v", created)
+	}
+	if len(schema.Defs["Metadata"].Required) != 0 {
+		t.Errorf("expected no required metadata, got %v", schema.Defs["Metadata"].Required)
+	}
+}
@@ -2,12 +2,18 @@ package snapshotter
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/redact"
 	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -16,106 +22,235 @@ type NodeSnapshotter struct {
 	Factory    collectors.CollectorFactory
 	Serializer serializers.Serializer
 	Logger     *slog.Logger
+	// Filter, when set, selects the configurations included in the snapshot.
+	// It is applied before serialization.
+	Filter func(collectors.Configuration) bool
+	// Redactor, when set, masks secrets before serialization.
+	Redactor *redact.Redactor
+	// StripVolatile removes timestamps, PIDs and counters from the snapshot so
+	// snapshots of an unchanged node are identical.
+	StripVolatile bool
+	// SignKey, when set, signs the snapshot. Signatures are only written by
+	// the JSON format.
+	SignKey ed25519.PrivateKey
+	// Collectors, when set, restricts the snapshot to the named collectors,
+	// see CollectorNames.
+	Collectors []string
 }
 
 // Run collects configuration from the current node and outputs it to stdout.
 // It implements the Snapshotter interface.
 func (n *NodeSnapshotter) Run(ctx context.Context) error {
-	if n.Logger == nil {
-		n.Logger = slog.Default()
+	n.setDefaults()
+
+	if n.Serializer == nil {
+		n.Serializer = serializers.NewWriter(serializers.FormatJSON, nil)
 	}
-	if n.Factory == nil {
-		n.Factory = collectors.NewDefaultCollectorFactory()
+
+	// Streaming serializers write each collector's results as soon as they're
+	// available, so the complete snapshot is never held in memory
+	if s, ok := n.Serializer.(serializers.StreamSerializer); ok && s.Streaming() {
+		if n.SignKey != nil {
+			return errors.New("streamed snapshots can't be signed")
+		}
+		return n.collect(ctx, func(_ string, configs []collectors.Configuration) error {
+			if err := s.SerializeItems(configs); err != nil {
+				n.Logger.Error("failed to serialize", slog.String("error", err.Error()))
+				return fmt.Errorf("failed to serialize: %w", err)
+			}
+			return nil
+		})
 	}
 
-	n.Logger.Info("starting node snapshot")
+	configs, err := n.Collect(ctx)
+	if err != nil {
+		return err
+	}
+
+	s := snapshot.New(configs)
+	if n.Redactor != nil {
+		s.Metadata.Redacted = n.Redactor.Report().Total
+	}
+	if n.StripVolatile {
+		s.StripVolatile()
+	}
+	if n.SignKey != nil {
+		if err := s.Sign(n.SignKey); err != nil {
+			return fmt.Errorf("failed to sign snapshot: %w", err)
+		}
+	}
+
+	// Serialize output
+	if err := n.Serializer.Serialize(s); err != nil {
+		n.Logger.Error("failed to serialize", slog.String("error", err.Error()))
+		return fmt.Errorf("failed to serialize: %w", err)
+	}
+
+	return nil
+}
+
+// Collect runs all collectors concurrently and returns the combined configuration
+// sorted by type and ID, without serializing it.
+func (n *NodeSnapshotter) Collect(ctx context.Context) ([]collectors.Configuration, error) {
+	n.setDefaults()
 
 	// Pre-allocate with estimated capacity
-	var mu sync.Mutex
 	snapshot := make([]collectors.Configuration, 0, 670)
 
-	g, ctx := errgroup.WithContext(ctx)
-
-	// Collect kernel modules concurrently
-	g.Go(func() error {
-		n.Logger.Debug("collecting kernel modules")
-		km := n.Factory.CreateKModCollector()
-		kMod, err := km.Collect(ctx)
-		if err != nil {
-			n.Logger.Error("failed to collect kmod", slog.String("error", err.Error()))
-			return fmt.Errorf("failed to collect kMod info: %w", err)
-		}
-		mu.Lock()
-		snapshot = append(snapshot, kMod...)
-		mu.Unlock()
-		n.Logger.Debug("collected kernel modules", slog.Int("count", len(kMod)))
+	err := n.collect(ctx, func(_ string, configs []collectors.Configuration) error {
+		snapshot = append(snapshot, configs...)
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	// Collect systemd concurrently
-	g.Go(func() error {
-		n.Logger.Debug("collecting systemd services")
-		sd := n.Factory.CreateSystemDCollector()
-		systemd, err := sd.Collect(ctx)
-		if err != nil {
-			n.Logger.Error("failed to collect systemd", slog.String("error", err.Error()))
-			return fmt.Errorf("failed to collect systemd info: %w", err)
-		}
-		mu.Lock()
-		snapshot = append(snapshot, systemd...)
-		mu.Unlock()
-		n.Logger.Debug("collected systemd services", slog.Int("count", len(systemd)))
-		return nil
-	})
+	collectors.Sort(snapshot)
+	return snapshot, nil
+}
 
-	// Collect grub concurrently
-	g.Go(func() error {
-		n.Logger.Debug("collecting grub configuration")
-		g := n.Factory.CreateGrubCollector()
-		grub, err := g.Collect(ctx)
-		if err != nil {
-			n.Logger.Error("failed to collect grub", slog.String("error", err.Error()))
-			return fmt.Errorf("failed to collect grub info: %w", err)
-		}
-		mu.Lock()
-		snapshot = append(snapshot, grub...)
-		mu.Unlock()
-		n.Logger.Debug("collected grub parameters", slog.Int("count", len(grub)))
-		return nil
-	})
+// CollectByCollector runs all collectors concurrently like Collect, but returns
+// the configuration of each collector separately, by collector name. It allows
+// callers to re-collect and replace the results of a single collector.
+func (n *NodeSnapshotter) CollectByCollector(ctx context.Context) (map[string][]collectors.Configuration, error) {
+	n.setDefaults()
 
-	// Collect sysctl concurrently
-	g.Go(func() error {
-		n.Logger.Debug("collecting sysctl configuration")
-		s := n.Factory.CreateSysctlCollector()
-		sysctl, err := s.Collect(ctx)
-		if err != nil {
-			n.Logger.Error("failed to collect sysctl", slog.String("error", err.Error()))
-			return fmt.Errorf("failed to collect sysctl info: %w", err)
-		}
-		mu.Lock()
-		snapshot = append(snapshot, sysctl...)
-		mu.Unlock()
-		n.Logger.Debug("collected sysctl parameters", slog.Int("count", len(sysctl)))
+	res := make(map[string][]collectors.Configuration)
+	err := n.collect(ctx, func(name string, configs []collectors.Configuration) error {
+		res[name] = configs
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
 
-	// Wait for all collectors to complete
-	if err := g.Wait(); err != nil {
+func (n *NodeSnapshotter) setDefaults() {
+	if n.Logger == nil {
+		n.Logger = slog.Default()
+	}
+	if n.Factory == nil {
+		n.Factory = collectors.NewDefaultCollectorFactory()
+	}
+}
+
+// collect runs all collectors concurrently and passes each collector's name and
+// results, sorted by type and ID, to emit as soon as the collector completes, so
+// a slow collector doesn't hold back the others. The order of the collectors
+// thus varies between runs, consumers needing a stable order sort the results.
+// Calls to emit are serialized.
+func (n *NodeSnapshotter) collect(ctx context.Context, emit func(string, []collectors.Configuration) error) error {
+	n.Logger.Info("starting node snapshot")
+
+	specs, err := n.collectors()
+	if err != nil {
 		return err
 	}
 
-	n.Logger.Info("snapshot collection complete", slog.Int("total_configs", len(snapshot)))
+	var mu sync.Mutex
+	var total int
 
-	// Serialize output
-	if n.Serializer == nil {
-		n.Serializer = serializers.NewWriter(serializers.FormatJSON, nil)
+	g, ctx := errgroup.WithContext(ctx)
+
+	// Run all collectors concurrently
+	for _, c := range specs {
+		g.Go(func() error {
+			n.Logger.Debug("collecting", slog.String("collector", c.name))
+			configs, err := c.create(n.Factory).Collect(ctx)
+			if err != nil {
+				n.Logger.Error("failed to collect",
+					slog.String("collector", c.name),
+					slog.String("error", err.Error()))
+				return fmt.Errorf("failed to collect %s info: %w", c.name, err)
+			}
+			if n.Filter != nil {
+				configs = slices.DeleteFunc(configs, func(c collectors.Configuration) bool {
+					return !n.Filter(c)
+				})
+			}
+			if n.Redactor != nil {
+				for i, c := range configs {
+					configs[i] = n.Redactor.Redact(c)
+				}
+			}
+			if n.StripVolatile {
+				for i, c := range configs {
+					configs[i] = collectors.StripVolatile(c)
+				}
+			}
+			collectors.Sort(configs)
+			n.Logger.Debug("collected",
+				slog.String("collector", c.name),
+				slog.Int("count", len(configs)))
+
+			mu.Lock()
+			defer mu.Unlock()
+			total += len(configs)
+			return emit(c.name, configs)
+		})
 	}
 
-	if err := n.Serializer.Serialize(snapshot); err != nil {
-		n.Logger.Error("failed to serialize", slog.String("error", err.Error()))
-		return fmt.Errorf("failed to serialize: %w", err)
+	// Wait for all collectors to complete
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	if n.Redactor != nil {
+		if rep := n.Redactor.Report(); rep.Total > 0 {
+			n.Logger.Info("redacted secrets", slog.String("values", rep.String()))
+		}
 	}
 
+	n.Logger.Info("snapshot collection complete", slog.Int("total_configs", total))
 	return nil
 }
+
+// collectorSpec names a collector constructor of the factory.
+type collectorSpec struct {
+	name   string
+	create func(collectors.CollectorFactory) collectors.Collector
+}
+
+// collectorSpecs are the collectors that make up a node snapshot.
+var collectorSpecs = []collectorSpec{
+	{name: "kmod", create: collectors.CollectorFactory.CreateKModCollector},
+	{name: "systemd", create: collectors.CollectorFactory.CreateSystemDCollector},
+	{name: "grub", create: collectors.CollectorFactory.CreateGrubCollector},
+	{name: "sysctl", create: collectors.CollectorFactory.CreateSysctlCollector},
+	{name: "network", create: collectors.CollectorFactory.CreateNetworkCollector},
+	{name: "packages", create: collectors.CollectorFactory.CreatePackageCollector},
+	{name: "security", create: collectors.CollectorFactory.CreateSecurityCollector},
+	{name: "mounts", create: collectors.CollectorFactory.CreateMountCollector},
+	{name: "gpu", create: collectors.CollectorFactory.CreateGPUCollector},
+}
+
+// CollectorNames returns the names of the collectors of a node snapshot.
+func CollectorNames() []string {
+	names := make([]string, len(collectorSpecs))
+	for i, c := range collectorSpecs {
+		names[i] = c.name
+	}
+	return names
+}
+
+// collectors returns the collectors selected by n.Collectors, all collectors
+// if none are selected.
+func (n *NodeSnapshotter) collectors() ([]collectorSpec, error) {
+	if len(n.Collectors) == 0 {
+		return collectorSpecs, nil
+	}
+	for _, name := range n.Collectors {
+		if !slices.ContainsFunc(collectorSpecs, func(c collectorSpec) bool { return c.name == name }) {
+			return nil, fmt.Errorf("unknown collector %q, must be one of %s",
+				name, strings.Join(CollectorNames(), ", "))
+		}
+	}
+	var specs []collectorSpec
+	for _, c := range collectorSpecs {
+		if slices.Contains(n.Collectors, c.name) {
+			specs = append(specs, c)
+		}
+	}
+	return specs, nil
+}
@@ -0,0 +1,302 @@
+package snapshotter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/serializers"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
+)
+
+// staticCollector returns fixed configurations or an error, optionally after a delay.
+type staticCollector struct {
+	configs []collectors.Configuration
+	err     error
+	delay   time.Duration
+}
+
+func (c *staticCollector) Collect(ctx context.Context) ([]collectors.Configuration, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(c.delay):
+	}
+	return slices.Clone(c.configs), c.err
+}
+
+// fakeFactory returns a static collector per configuration type.
+type fakeFactory struct {
+	collectors map[string]collectors.Collector
+}
+
+func (f *fakeFactory) get(t string) collectors.Collector {
+	if c, ok := f.collectors[t]; ok {
+		return c
+	}
+	return &staticCollector{}
+}
+
+func (f *fakeFactory) CreateKModCollector() collectors.Collector {
+	return f.get(collectors.KModType)
+}
+func (f *fakeFactory) CreateSystemDCollector() collectors.Collector {
+	return f.get(collectors.SystemDType)
+}
+func (f *fakeFactory) CreateGrubCollector() collectors.Collector {
+	return f.get(collectors.GrubType)
+}
+func (f *fakeFactory) CreateSysctlCollector() collectors.Collector {
+	return f.get(collectors.SysctlType)
+}
+func (f *fakeFactory) CreateNetworkCollector() collectors.Collector {
+	return f.get(collectors.NetworkType)
+}
+func (f *fakeFactory) CreatePackageCollector() collectors.Collector {
+	return f.get(collectors.PackageType)
+}
+func (f *fakeFactory) CreateSecurityCollector() collectors.Collector {
+	return f.get(collectors.SecurityType)
+}
+func (f *fakeFactory) CreateMountCollector() collectors.Collector {
+	return f.get(collectors.MountType)
+}
+
+func (f *fakeFactory) CreateGPUCollector() collectors.Collector {
+	return f.get(collectors.GPUType)
+}
+
+func newFakeFactory() *fakeFactory {
+	return &fakeFactory{
+		collectors: map[string]collectors.Collector{
+			collectors.KModType: &staticCollector{configs: []collectors.Configuration{
+				{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+				{Type: collectors.KModType, Data: collectors.KModConfig{Name: "overlay"}},
+			}},
+			collectors.GrubType: &staticCollector{configs: []collectors.Configuration{
+				{Type: collectors.GrubType, Data: collectors.GrubConfig{Key: "iommu", Value: "pt"}},
+			}},
+			collectors.SysctlType: &staticCollector{configs: []collectors.Configuration{
+				{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: "60"}},
+			}},
+		},
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestNodeSnapshotter_Run(t *testing.T) {
+	var buf bytes.Buffer
+	ns := snapshotter.NodeSnapshotter{
+		Factory:    newFakeFactory(),
+		Serializer: serializers.NewWriter(serializers.FormatJSON, &buf),
+		Logger:     discardLogger(),
+	}
+
+	if err := ns.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	s, err := snapshot.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if s.APIVersion != snapshot.APIVersion || s.Kind != snapshot.Kind {
+		t.Errorf("Unexpected envelope %s/%s", s.APIVersion, s.Kind)
+	}
+	if s.Metadata.Created.IsZero() {
+		t.Error("Expected creation time in metadata")
+	}
+	if len(s.Items) != 4 {
+		t.Errorf("Expected 4 configurations, got %d", len(s.Items))
+	}
+}
+
+func TestNodeSnapshotter_Run_Streaming(t *testing.T) {
+	var buf bytes.Buffer
+	ns := snapshotter.NodeSnapshotter{
+		Factory:    newFakeFactory(),
+		Serializer: serializers.NewWriter(serializers.FormatNDJSON, &buf),
+		Logger:     discardLogger(),
+	}
+
+	if err := ns.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var cfg map[string]any
+		if err := json.Unmarshal([]byte(line), &cfg); err != nil {
+			t.Fatalf("Failed to unmarshal line %q: %v", line, err)
+		}
+		if cfg["Type"] == "" {
+			t.Errorf("Expected type in line %q", line)
+		}
+	}
+}
+
+func TestNodeSnapshotter_Run_CollectorError(t *testing.T) {
+	factory := newFakeFactory()
+	errBoom := errors.New("boom")
+	factory.collectors[collectors.GrubType] = &staticCollector{err: errBoom}
+
+	var buf bytes.Buffer
+	ns := snapshotter.NodeSnapshotter{
+		Factory:    factory,
+		Serializer: serializers.NewWriter(serializers.FormatJSON, &buf),
+		Logger:     discardLogger(),
+	}
+
+	err := ns.Run(context.Background())
+	if !errors.Is(err, errBoom) {
+		t.Errorf("Expected collector error, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output on error, got %q", buf.String())
+	}
+}
+
+func TestNodeSnapshotter_Run_Filter(t *testing.T) {
+	var buf bytes.Buffer
+	ns := snapshotter.NodeSnapshotter{
+		Factory:    newFakeFactory(),
+		Serializer: serializers.NewWriter(serializers.FormatNDJSON, &buf),
+		Logger:     discardLogger(),
+		Filter: func(c collectors.Configuration) bool {
+			return c.Type == collectors.KModType
+		},
+	}
+
+	if err := ns.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"Type":"KMod"`) {
+			t.Errorf("Unexpected configuration %q", line)
+		}
+	}
+}
+
+func TestNodeSnapshotter_Collect_Collectors(t *testing.T) {
+	ns := snapshotter.NodeSnapshotter{
+		Factory:    newFakeFactory(),
+		Logger:     discardLogger(),
+		Collectors: []string{"sysctl", "grub"},
+	}
+
+	configs, err := ns.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(configs) != 2 || configs[0].Type != collectors.GrubType || configs[1].Type != collectors.SysctlType {
+		t.Errorf("Expected grub and sysctl configurations, got %+v", configs)
+	}
+
+	ns.Collectors = []string{"nope"}
+	if _, err := ns.Collect(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown collector") {
+		t.Errorf("Expected unknown collector error, got %v", err)
+	}
+
+	if names := snapshotter.CollectorNames(); len(names) != 9 || names[0] != "kmod" {
+		t.Errorf("Unexpected collector names %v", names)
+	}
+}
+
+func TestNodeSnapshotter_Run_Deterministic(t *testing.T) {
+	factory := newFakeFactory()
+	// The first collector completes last and returns unsorted results
+	factory.collectors[collectors.KModType] = &staticCollector{
+		delay: 20 * time.Millisecond,
+		configs: []collectors.Configuration{
+			{Type: collectors.KModType, Data: collectors.KModConfig{Name: "overlay"}},
+			{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}},
+		},
+	}
+	factory.collectors[collectors.SystemDType] = &staticCollector{configs: []collectors.Configuration{
+		{Type: collectors.SystemDType, Data: collectors.SystemDConfig{
+			Unit:       "kubelet.service",
+			Properties: map[string]any{"MainPID": uint32(1234), "ActiveEnterTimestamp": uint64(1), "Restart": "always"},
+		}},
+	}}
+
+	run := func(format serializers.Format) string {
+		var buf bytes.Buffer
+		ns := snapshotter.NodeSnapshotter{
+			Factory:       factory,
+			Serializer:    serializers.NewWriter(format, &buf),
+			Logger:        discardLogger(),
+			StripVolatile: true,
+		}
+		if err := ns.Run(context.Background()); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	// Streamed results are written as each collector completes, the slow
+	// first collector last, sorted within each collector
+	ndjson := run(serializers.FormatNDJSON)
+	lines := strings.Split(strings.TrimSpace(ndjson), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, got %d: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[3], "nvidia") || !strings.Contains(lines[4], "overlay") {
+		t.Errorf("Expected the slow kmod collector last, got %q", lines)
+	}
+	// and sorted when decoded
+	decoded, err := snapshot.Decode(strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("Failed to decode NDJSON: %v", err)
+	}
+	var ids []string
+	for _, c := range decoded.Items {
+		ids = append(ids, c.Type+"/"+c.ID())
+	}
+	if got := strings.Join(ids, ","); got != "Grub/iommu,KMod/nvidia,KMod/overlay,Sysctl//proc/sys/vm/swappiness,SystemD/kubelet.service" {
+		t.Errorf("Unexpected decoded order %s", got)
+	}
+
+	// Complete snapshots are sorted by type and ID and stripped of volatile fields
+	out := run(serializers.FormatJSON)
+	if out != run(serializers.FormatJSON) {
+		t.Error("Expected identical snapshots")
+	}
+	s, err := snapshot.Decode(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if !s.Metadata.Created.IsZero() {
+		t.Error("Expected no creation time")
+	}
+	var types []string
+	for _, c := range s.Items {
+		types = append(types, c.Type+"/"+c.ID())
+	}
+	if got := strings.Join(types, ","); got != "Grub/iommu,KMod/nvidia,KMod/overlay,Sysctl//proc/sys/vm/swappiness,SystemD/kubelet.service" {
+		t.Errorf("Unexpected order %s", got)
+	}
+	props := s.Items[4].Data.(collectors.SystemDConfig).Properties
+	if _, ok := props["MainPID"]; ok || props["Restart"] != "always" {
+		t.Errorf("Expected volatile properties to be stripped, got %v", props)
+	}
+}
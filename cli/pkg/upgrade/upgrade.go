@@ -0,0 +1,388 @@
+// Package upgrade plans the upgrade of a node between Cloud Native Stack
+// releases from the release matrix.
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cns"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/values"
+)
+
+// components are the components of the plan, in order.
+var components = []string{
+	cns.ComponentKubernetes,
+	cns.ComponentContainerd,
+	cns.ComponentCRIO,
+	cns.ComponentCalico,
+	cns.ComponentHelm,
+	cns.ComponentGPUOperator,
+	cns.ComponentNetworkOperator,
+	cns.ComponentDriver,
+}
+
+// detectedComponents are the values keys of the installed components, see
+// values.Detect.
+var detectedComponents = map[string]string{
+	"k8s_version":        cns.ComponentKubernetes,
+	"containerd_version": cns.ComponentContainerd,
+	"crio_version":       cns.ComponentCRIO,
+	"helm_version":       cns.ComponentHelm,
+	"gpu_driver_version": cns.ComponentDriver,
+}
+
+// Component is the version change of a component. Installed is set when From
+// is the version installed on the node rather than the one of the release.
+type Component struct {
+	Name      string `json:"name" yaml:"name"`
+	From      string `json:"from" yaml:"from"`
+	To        string `json:"to" yaml:"to"`
+	Installed bool   `json:"installed,omitempty" yaml:"installed,omitempty"`
+}
+
+// Change returns "upgrade", "downgrade", "added", "removed" or "" for
+// unchanged components.
+func (c Component) Change() string {
+	switch {
+	case c.From == c.To:
+		return ""
+	case c.From == "":
+		return "added"
+	case c.To == "":
+		return "removed"
+	case cns.CompareVersions(c.To, c.From) > 0:
+		return "upgrade"
+	default:
+		return "downgrade"
+	}
+}
+
+// Hop is a Kubernetes minor version upgrade, to the version of Release.
+type Hop struct {
+	Kubernetes string `json:"kubernetes" yaml:"kubernetes"`
+	Release    string `json:"release" yaml:"release"`
+}
+
+// OS is the operating system compatibility of the upgrade. Node is the
+// operating system of the node as far as it can be told from its packages,
+// Supported reports whether To supports it.
+type OS struct {
+	From      []string `json:"from" yaml:"from"`
+	To        []string `json:"to" yaml:"to"`
+	Node      string   `json:"node,omitempty" yaml:"node,omitempty"`
+	Supported *bool    `json:"supported,omitempty" yaml:"supported,omitempty"`
+}
+
+// Plan is the upgrade plan of a node between releases.
+type Plan struct {
+	From       string      `json:"from" yaml:"from"`
+	To         string      `json:"to" yaml:"to"`
+	Node       string      `json:"node,omitempty" yaml:"node,omitempty"`
+	Components []Component `json:"components" yaml:"components"`
+	// Hops are the Kubernetes minor versions to upgrade to in turn, kubeadm
+	// only upgrades one minor version at a time.
+	Hops []Hop `json:"hops" yaml:"hops"`
+	OS   OS    `json:"os" yaml:"os"`
+	// Prerequisites are the failed node prerequisites, nil without a snapshot.
+	Prerequisites []checks.Result `json:"prerequisites,omitempty" yaml:"prerequisites,omitempty"`
+}
+
+// Node is the node an upgrade is planned for.
+type Node struct {
+	Name    string
+	Configs []collectors.Configuration
+}
+
+// New plans the upgrade from the release from to the release to.
+func New(from, to string) (*Plan, error) {
+	return plan(from, to, nil)
+}
+
+// NewForNode plans the upgrade of a node to the release to. The release of
+// the node is the one of its Kubernetes version, the versions of the
+// installed components are the ones of its packages and its configuration is
+// checked against the node prerequisites.
+func NewForNode(node Node, to string) (*Plan, error) {
+	installed := installedVersions(node.Configs)
+	k8s := installed[cns.ComponentKubernetes]
+	if k8s == "" {
+		return nil, errors.New("the release of the node can't be determined without a kubelet package, use the release version")
+	}
+	from, err := releaseOf(k8s)
+	if err != nil {
+		return nil, err
+	}
+	return plan(from, to, &node)
+}
+
+// installedVersions returns the component versions of the node packages.
+func installedVersions(configs []collectors.Configuration) map[string]string {
+	versions := make(map[string]string)
+	for _, v := range values.Detect(configs) {
+		if c, ok := detectedComponents[v.Key]; ok {
+			if s, ok := v.Value.(string); ok {
+				versions[c] = s
+			}
+		}
+	}
+	return versions
+}
+
+// releaseOf returns the release of a Kubernetes version, the newest release
+// of its minor version if none has the exact version.
+func releaseOf(k8s string) (string, error) {
+	releases, err := cns.Releases()
+	if err != nil {
+		return "", err
+	}
+	var closest string
+	for _, r := range releases {
+		server := r.Server()
+		if server == nil {
+			continue
+		}
+		v := server.Component(cns.ComponentKubernetes)
+		if v == k8s {
+			return r.Version, nil
+		}
+		if minor(v) == minor(k8s) && (closest == "" || cns.CompareVersions(r.Version, closest) > 0) {
+			closest = r.Version
+		}
+	}
+	if closest == "" {
+		return "", fmt.Errorf("no Cloud Native Stack release has Kubernetes %s", minor(k8s))
+	}
+	return closest, nil
+}
+
+func plan(from, to string, node *Node) (*Plan, error) {
+	fromRelease, err := cns.Lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	toRelease, err := cns.Lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	if cns.CompareVersions(to, from) < 0 {
+		return nil, fmt.Errorf("downgrading from %s to %s isn't supported", from, to)
+	}
+	fromServer, toServer := fromRelease.Server(), toRelease.Server()
+	if fromServer == nil || toServer == nil {
+		return nil, fmt.Errorf("upgrades are only planned for the %s platform", cns.ServerPlatform)
+	}
+
+	p := &Plan{From: from, To: to, Hops: []Hop{}}
+	var installed map[string]string
+	if node != nil {
+		p.Node = node.Name
+		installed = installedVersions(node.Configs)
+	}
+
+	for _, name := range components {
+		c := Component{Name: name, From: fromServer.Component(name), To: toServer.Component(name)}
+		if v, ok := installed[name]; ok {
+			c.From, c.Installed = v, true
+		}
+		if c.From != "" || c.To != "" {
+			p.Components = append(p.Components, c)
+		}
+	}
+
+	k8s := fromServer.Component(cns.ComponentKubernetes)
+	if v, ok := installed[cns.ComponentKubernetes]; ok {
+		k8s = v
+	}
+	if p.Hops, err = hops(k8s, toServer.Component(cns.ComponentKubernetes), to); err != nil {
+		return nil, err
+	}
+
+	p.OS = OS{From: fromServer.OSes(), To: toServer.OSes()}
+	if node != nil {
+		p.OS.Node = nodeOS(node.Configs)
+		if p.OS.Node != "" {
+			supported := slices.ContainsFunc(p.OS.To, func(os string) bool { return strings.HasPrefix(os, p.OS.Node) })
+			p.OS.Supported = &supported
+		}
+
+		p.Prerequisites = []checks.Result{}
+		for _, r := range checks.Run(checks.DefaultRules(), node.Configs) {
+			if r.Status == checks.StatusFail {
+				p.Prerequisites = append(p.Prerequisites, r)
+			}
+		}
+	}
+	return p, nil
+}
+
+// hops returns the Kubernetes minor versions between from and to, each the
+// newest version of the minor in the release matrix, the last one being to.
+func hops(from, to, toRelease string) ([]Hop, error) {
+	fromMajor, fromMinor, err := parseMinor(from)
+	if err != nil {
+		return nil, err
+	}
+	toMajor, toMinor, err := parseMinor(to)
+	if err != nil {
+		return nil, err
+	}
+	if fromMajor != toMajor || fromMinor > toMinor {
+		return nil, fmt.Errorf("can't upgrade Kubernetes %s to %s", from, to)
+	}
+
+	releases, err := cns.Releases()
+	if err != nil {
+		return nil, err
+	}
+	res := []Hop{}
+	for m := fromMinor + 1; m < toMinor; m++ {
+		want := fmt.Sprintf("%d.%d", toMajor, m)
+		hop := Hop{Kubernetes: want}
+		for _, r := range releases {
+			server := r.Server()
+			if server == nil {
+				continue
+			}
+			if v := server.Component(cns.ComponentKubernetes); minor(v) == want &&
+				(hop.Release == "" || cns.CompareVersions(v, hop.Kubernetes) > 0) {
+				hop = Hop{Kubernetes: v, Release: r.Version}
+			}
+		}
+		res = append(res, hop)
+	}
+	if fromMinor < toMinor || cns.CompareVersions(from, to) < 0 {
+		res = append(res, Hop{Kubernetes: to, Release: toRelease})
+	}
+	return res, nil
+}
+
+func parseMinor(v string) (major, minor int, err error) {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) >= 2 {
+		if major, err = strconv.Atoi(parts[0]); err == nil {
+			if minor, err = strconv.Atoi(parts[1]); err == nil {
+				return major, minor, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid Kubernetes version %q", v)
+}
+
+func minor(v string) string {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return v
+	}
+	return parts[0] + "." + parts[1]
+}
+
+var (
+	ubuntuRelease = regexp.MustCompile(`ubuntu[0-9.]*[~.]([0-9]{2}\.[0-9]{2})|~([0-9]{2}\.[0-9]{2})`)
+	rhelRelease   = regexp.MustCompile(`\.el([0-9]+)`)
+)
+
+// nodeOS returns the operating system of the node from the distribution
+// suffixes of its package versions, e.g. "Ubuntu 24.04" for
+// 1.7.24-0ubuntu1~24.04.2, or "" if none has one.
+func nodeOS(configs []collectors.Configuration) string {
+	for _, c := range configs {
+		pkg, ok := c.Data.(collectors.PackageConfig)
+		if !ok {
+			continue
+		}
+		if m := ubuntuRelease.FindStringSubmatch(pkg.Version); m != nil {
+			return "Ubuntu " + m[1] + m[2]
+		}
+		if m := rhelRelease.FindStringSubmatch(pkg.Version); m != nil {
+			return "RedHat Linux " + m[1]
+		}
+	}
+	return ""
+}
+
+// WriteText writes the plan as a human-readable report.
+func (p *Plan) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Upgrade from Cloud Native Stack %s to %s", p.From, p.To)
+	if p.Node != "" {
+		fmt.Fprintf(&b, " of %s", p.Node)
+	}
+	b.WriteString("\n\n")
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tFROM\tTO\tCHANGE")
+	for _, c := range p.Components {
+		from, change := c.From, c.Change()
+		if from == "" {
+			from = "-"
+		} else if c.Installed {
+			from += " (installed)"
+		}
+		to := c.To
+		if to == "" {
+			to = "-"
+		}
+		if change == "" {
+			change = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, from, to, change)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	switch len(p.Hops) {
+	case 0:
+		b.WriteString("\nKubernetes: no upgrade\n")
+	default:
+		fmt.Fprintf(&b, "\nKubernetes: %d upgrades, one minor version at a time\n", len(p.Hops))
+		for i, h := range p.Hops {
+			release := ""
+			if h.Release != "" {
+				release = " (Cloud Native Stack " + h.Release + ")"
+			}
+			fmt.Fprintf(&b, "  %d. %s%s\n", i+1, h.Kubernetes, release)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nOperating system: %s supports %s", p.To, strings.Join(p.OS.To, ", "))
+	if !slices.Equal(p.OS.From, p.OS.To) {
+		fmt.Fprintf(&b, ", %s supports %s", p.From, strings.Join(p.OS.From, ", "))
+	}
+	b.WriteString("\n")
+	switch {
+	case p.OS.Supported == nil && p.Node != "":
+		b.WriteString("  the operating system of the node can't be told from its packages\n")
+	case p.OS.Supported == nil:
+	case *p.OS.Supported:
+		fmt.Fprintf(&b, "  the node runs %s, it's supported\n", p.OS.Node)
+	default:
+		fmt.Fprintf(&b, "  the node runs %s, upgrade it to a supported operating system first\n", p.OS.Node)
+	}
+
+	switch {
+	case p.Prerequisites == nil:
+		b.WriteString("\nNode prerequisites: not checked without a snapshot\n")
+	case len(p.Prerequisites) == 0:
+		b.WriteString("\nNode prerequisites: all met\n")
+	default:
+		fmt.Fprintf(&b, "\nNode prerequisites: %d not met\n", len(p.Prerequisites))
+		for _, r := range p.Prerequisites {
+			fmt.Fprintf(&b, "  %s (%s): %s\n    %s\n", r.Rule, r.Severity, r.Message, r.Remediation)
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package upgrade_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cns"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/upgrade"
+)
+
+func TestNew(t *testing.T) {
+	p, err := upgrade.New("14.2", "16.0")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	changes := make(map[string]string)
+	for _, c := range p.Components {
+		changes[c.Name] = c.From + ">" + c.To + ":" + c.Change()
+	}
+	if got := changes[cns.ComponentKubernetes]; got != "1.31.10>1.33.2:upgrade" {
+		t.Errorf("Kubernetes = %s", got)
+	}
+	if got := changes[cns.ComponentContainerd]; got != "2.1.3>2.1.3:" {
+		t.Errorf("containerd = %s", got)
+	}
+
+	if len(p.Hops) != 2 || p.Hops[0] != (upgrade.Hop{Kubernetes: "1.32.6", Release: "15.1"}) ||
+		p.Hops[1] != (upgrade.Hop{Kubernetes: "1.33.2", Release: "16.0"}) {
+		t.Errorf("hops = %+v", p.Hops)
+	}
+	if len(p.OS.From) != 1 || p.OS.From[0] != "Ubuntu 22.04 LTS" || p.OS.To[0] != "Ubuntu 24.04 LTS" {
+		t.Errorf("OS = %+v", p.OS)
+	}
+	if p.Prerequisites != nil {
+		t.Errorf("prerequisites without a snapshot = %+v", p.Prerequisites)
+	}
+
+	if _, err := upgrade.New("16.0", "14.2"); err == nil {
+		t.Error("New() of a downgrade succeeded")
+	}
+}
+
+func TestNewForNode(t *testing.T) {
+	pkg := func(name, version string) collectors.Configuration {
+		return collectors.Configuration{Type: collectors.PackageType, Data: collectors.PackageConfig{Name: name, Version: version, Held: true}}
+	}
+	node := upgrade.Node{Name: "gpu-01", Configs: []collectors.Configuration{
+		pkg("kubelet", "1.32.6-1.1"),
+		pkg("kubeadm", "1.32.6-1.1"),
+		pkg("kubectl", "1.32.6-1.1"),
+		pkg("containerd", "1.7.24-0ubuntu1~24.04.2"),
+	}}
+	p, err := upgrade.NewForNode(node, "16.0")
+	if err != nil {
+		t.Fatalf("NewForNode() error = %v", err)
+	}
+	if p.From != "15.1" || p.Node != "gpu-01" {
+		t.Errorf("plan = %+v", p)
+	}
+	if c := p.Components[1]; c.Name != cns.ComponentContainerd || c.From != "1.7.24" || !c.Installed {
+		t.Errorf("containerd = %+v", c)
+	}
+	if len(p.Hops) != 1 || p.Hops[0].Kubernetes != "1.33.2" {
+		t.Errorf("hops = %+v", p.Hops)
+	}
+	if p.OS.Node != "Ubuntu 24.04" || p.OS.Supported == nil || !*p.OS.Supported {
+		t.Errorf("OS = %+v", p.OS)
+	}
+	if p.Prerequisites == nil {
+		t.Error("prerequisites weren't checked")
+	}
+
+	var b strings.Builder
+	if err := p.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Upgrade from Cloud Native Stack 15.1 to 16.0 of gpu-01",
+		"containerd                1.7.24 (installed)",
+		"  1. 1.33.2 (Cloud Native Stack 16.0)",
+		"the node runs Ubuntu 24.04, it's supported",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("plan missing %q:\n%s", want, b.String())
+		}
+	}
+
+	if _, err := upgrade.NewForNode(upgrade.Node{Name: "empty"}, "16.0"); err == nil {
+		t.Error("NewForNode() without kubelet succeeded")
+	}
+}
@@ -0,0 +1,284 @@
+package values
+
+import (
+	"path"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+)
+
+// driverPackages are the package name patterns of the NVIDIA data center
+// driver, open kernel module flavors have "open" in their name.
+var driverPackages = []string{
+	"nvidia-driver", "nvidia-driver-*", "nvidia-open", "nvidia-headless-*",
+	"nvidia-dkms-*", "kmod-nvidia-*", "cuda-drivers",
+}
+
+// Detect derives the values of a node from the configurations of its
+// snapshot: the container runtime, the component versions of the installed
+// packages and release binaries, the driver version and flavor, the MIG mode of the GPUs, the
+// NVIDIA CDI specs, the GDS and secure boot state.
+func Detect(configs []collectors.Configuration) []Value {
+	d := detector{configs: configs}
+	var values []Value
+
+	kubelet, k8s := d.pkg("kubelet")
+	if k8s {
+		values = append(values,
+			Value{Key: "microk8s", Value: false},
+			Value{Key: "install_k8s", Value: true},
+		)
+	} else {
+		values = append(values, Value{Key: "install_k8s", Note: "no kubelet package is installed"})
+	}
+
+	runtime := d.runtime()
+	if runtime == "" {
+		values = append(values, Value{Key: "container_runtime", Note: "no containerd, cri-o or cri-dockerd package or running containerd service"})
+	} else {
+		values = append(values, Value{Key: "container_runtime", Value: runtime})
+	}
+	switch runtime {
+	case "containerd", "":
+		values = append(values,
+			d.version("containerd_version", "containerd.io", "containerd"),
+			d.version("runc_version", "runc"))
+	case "cri-o":
+		values = append(values, d.version("crio_version", "cri-o"))
+	case "cri-dockerd":
+		values = append(values, d.version("cri_dockerd_version", "cri-dockerd"))
+	}
+	values = append(values,
+		d.version("cni_plugins_version", "cni-plugins"),
+		d.version("nvidia_container_toolkit_version", "nvidia-container-toolkit"),
+		d.version("k8s_version", "kubelet"),
+		d.version("helm_version", "helm"),
+	)
+	if k8s {
+		values = append(values, kubernetesValues(Version(kubelet.Version))...)
+	}
+
+	values = append(values, d.driver()...)
+	values = append(values,
+		d.mig(),
+		d.cdi(),
+		d.gds(),
+		d.secureBoot(),
+	)
+	return values
+}
+
+// Version returns the upstream version of a package version, without the
+// epoch and the distribution revision, e.g. 1.33.2 for 1.33.2-1.1.
+func Version(v string) string {
+	if i := strings.Index(v, ":"); i >= 0 {
+		v = v[i+1:]
+	}
+	if i := strings.IndexAny(v, "-~+"); i >= 0 {
+		v = v[:i]
+	}
+	return v
+}
+
+// minor returns the major.minor part of a version.
+func minor(v string) string {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return v
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// kubernetesValues returns the values depending on the Kubernetes version:
+// the package repository keys and the CNS release.
+func kubernetesValues(version string) []Value {
+	m := minor(version)
+	values := []Value{
+		{Key: "k8s_apt_key", Value: "https://pkgs.k8s.io/core:/stable:/v" + m + "/deb/Release.key"},
+		{Key: "k8s_gpg_key", Value: "https://pkgs.k8s.io/core:/stable:/v" + m + "/rpm/repodata/repomd.xml.key"},
+	}
+	if want := minor(Default("k8s_version")); m != want {
+		values = append(values, Value{
+			Key:  "cns_version",
+			Note: "Kubernetes " + m + " isn't the " + want + " of this release, set the release of the node",
+		})
+	}
+	return values
+}
+
+type detector struct {
+	configs []collectors.Configuration
+}
+
+// pkg returns the first installed package matching one of patterns, in the
+// order of patterns. Binaries installed from release tarballs, as the CNS
+// playbooks do, come first: they are in /usr/local and ahead in the PATH.
+func (d detector) pkg(patterns ...string) (collectors.PackageConfig, bool) {
+	var found *collectors.PackageConfig
+	for _, p := range patterns {
+		for _, c := range d.configs {
+			pkg, ok := c.Data.(collectors.PackageConfig)
+			if !ok {
+				continue
+			}
+			if match, _ := path.Match(p, pkg.Name); !match {
+				continue
+			}
+			if pkg.Manager == collectors.PackageManagerBinary {
+				return pkg, true
+			}
+			if found == nil {
+				found = &pkg
+			}
+		}
+	}
+	if found == nil {
+		return collectors.PackageConfig{}, false
+	}
+	return *found, true
+}
+
+func (d detector) version(key string, patterns ...string) Value {
+	pkg, ok := d.pkg(patterns...)
+	if !ok {
+		return Value{Key: key, Note: "no " + strings.Join(patterns, " or ") + " package or release binary is installed"}
+	}
+	return Value{Key: key, Value: Version(pkg.Version)}
+}
+
+func (d detector) kmod(name string) bool {
+	for _, c := range d.configs {
+		if m, ok := c.Data.(collectors.KModConfig); ok && m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (d detector) unitActive(unit string) bool {
+	for _, c := range d.configs {
+		if u, ok := c.Data.(collectors.SystemDConfig); ok && u.Unit == unit {
+			return u.Properties["ActiveState"] == "active"
+		}
+	}
+	return false
+}
+
+func (d detector) security(key string) string {
+	for _, c := range d.configs {
+		if s, ok := c.Data.(collectors.SecurityConfig); ok && s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// gpu returns the value of a GPU setting and whether the snapshot has it.
+func (d detector) gpu(key string) (string, bool) {
+	for _, c := range d.configs {
+		if g, ok := c.Data.(collectors.GPUConfig); ok && g.Key == key {
+			return g.Value, true
+		}
+	}
+	return "", false
+}
+
+// runtime returns the container runtime of the node, cri-dockerd is checked
+// before containerd as Docker depends on containerd.
+func (d detector) runtime() string {
+	if _, ok := d.pkg("cri-o"); ok {
+		return "cri-o"
+	}
+	if _, ok := d.pkg("cri-dockerd"); ok {
+		return "cri-dockerd"
+	}
+	if _, ok := d.pkg("containerd.io", "containerd"); ok || d.unitActive("containerd.service") {
+		return "containerd"
+	}
+	return ""
+}
+
+// driver returns the driver version and flavor of the installed driver
+// packages.
+func (d detector) driver() []Value {
+	var driver []collectors.PackageConfig
+	for _, p := range driverPackages {
+		for _, c := range d.configs {
+			if pkg, ok := c.Data.(collectors.PackageConfig); ok {
+				if match, _ := path.Match(p, pkg.Name); match {
+					driver = append(driver, pkg)
+				}
+			}
+		}
+	}
+	if len(driver) == 0 {
+		note := "no NVIDIA driver package is installed"
+		if d.kmod("nvidia") {
+			note = "the loaded driver isn't installed from a package, e.g. by the GPU Operator"
+		}
+		return []Value{
+			{Key: "gpu_driver_version", Note: note},
+			{Key: "use_open_kernel_module", Note: note},
+		}
+	}
+
+	open := false
+	for _, pkg := range driver {
+		if strings.Contains(pkg.Name, "open") {
+			open = true
+		}
+	}
+	return []Value{
+		{Key: "gpu_driver_version", Value: Version(driver[0].Version)},
+		{Key: "use_open_kernel_module", Value: open},
+	}
+}
+
+// mig returns whether MIG is enabled on any GPU.
+func (d detector) mig() Value {
+	mode, ok := d.gpu(collectors.GPUKeyMIGMode)
+	switch {
+	case !ok:
+		return Value{Key: "enable_mig", Note: "the MIG mode of the GPUs isn't part of the snapshot"}
+	case mode == collectors.GPUMIGEnabled:
+		return Value{Key: "enable_mig", Value: true}
+	case mode == collectors.GPUMIGDisabled:
+		return Value{Key: "enable_mig", Value: false}
+	default:
+		return Value{Key: "enable_mig", Note: "the NVIDIA driver isn't loaded, the MIG mode is unknown"}
+	}
+}
+
+// cdi returns whether CDI is used, from the NVIDIA CDI specs generated by the
+// GPU Operator or the NVIDIA Container Toolkit.
+func (d detector) cdi() Value {
+	specs, ok := d.gpu(collectors.GPUKeyCDISpecs)
+	if !ok {
+		return Value{Key: "enable_cdi", Note: "the CDI specs of the node aren't part of the snapshot"}
+	}
+	return Value{Key: "enable_cdi", Value: specs != ""}
+}
+
+// gds returns whether GPUDirect Storage is enabled, from the nvidia_fs kernel
+// module.
+func (d detector) gds() Value {
+	switch {
+	case d.kmod("nvidia_fs"):
+		return Value{Key: "enable_gds", Value: true}
+	case d.kmod("nvidia"):
+		return Value{Key: "enable_gds", Value: false}
+	default:
+		return Value{Key: "enable_gds", Note: "the nvidia kernel module isn't loaded"}
+	}
+}
+
+func (d detector) secureBoot() Value {
+	switch state := d.security(collectors.SecurityKeySecureBoot); state {
+	case collectors.SecurityEnabled:
+		return Value{Key: "enable_secure_boot", Value: true}
+	case collectors.SecurityDisabled, collectors.SecurityUnsupported:
+		return Value{Key: "enable_secure_boot", Value: false}
+	default:
+		return Value{Key: "enable_secure_boot", Note: "the Secure Boot state is unknown"}
+	}
+}
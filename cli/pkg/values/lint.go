@@ -0,0 +1,347 @@
+package values
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/cns"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is an issue of a values file. Line is 0 for keys missing from the
+// file.
+type Problem struct {
+	Line     int             `json:"line" yaml:"line"`
+	Key      string          `json:"key" yaml:"key"`
+	Severity checks.Severity `json:"severity" yaml:"severity"`
+	Message  string          `json:"message" yaml:"message"`
+}
+
+// String formats the problem as "severity: key: message".
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Severity, p.Key, p.Message)
+}
+
+// kind is the type of the value of a key.
+type kind int
+
+const (
+	kindAny kind = iota
+	kindBool
+	kindString
+	kindNumber
+	kindList
+)
+
+// field describes a key of the values file.
+type field struct {
+	kind    kind
+	version bool
+	enum    []string
+}
+
+// enums are the allowed values of keys, from the comments of the values files
+// and the settings of the GPU Operator.
+var enums = map[string][]string{
+	"container_runtime": {"containerd", "cri-o", "cri-dockerd"},
+	"mig_strategy":      {"none", "single", "mixed"},
+}
+
+// dependencies are the settings requiring other settings to be enabled.
+var dependencies = []struct {
+	key, requires string
+	severity      checks.Severity
+}{
+	{"enable_gds", "use_open_kernel_module", checks.SeverityError},
+	{"enable_network_operator", "enable_rdma", checks.SeverityWarning},
+	{"deploy_ofed", "enable_network_operator", checks.SeverityWarning},
+}
+
+// releaseComponents are the keys checked against the release matrix, when is
+// the setting enabling the component, if any.
+var releaseComponents = []struct {
+	key, component, when string
+}{
+	{"containerd_version", cns.ComponentContainerd, ""},
+	{"crio_version", cns.ComponentCRIO, ""},
+	{"k8s_version", cns.ComponentKubernetes, ""},
+	{"calico_version", cns.ComponentCalico, ""},
+	{"helm_version", cns.ComponentHelm, ""},
+	{"gpu_operator_version", cns.ComponentGPUOperator, "enable_gpu_operator"},
+	{"gpu_driver_version", cns.ComponentDriver, "enable_gpu_operator"},
+	{"network_operator_version", cns.ComponentNetworkOperator, "enable_network_operator"},
+}
+
+var versionPattern = regexp.MustCompile(`^v?[0-9]+(\.[0-9]+)+$`)
+
+// isBool reports whether a plain scalar is a boolean for Ansible, which reads
+// YAML 1.1.
+func isBool(s string) bool {
+	switch strings.ToLower(s) {
+	case "yes", "no", "true", "false", "on", "off":
+		return true
+	}
+	return false
+}
+
+func boolValue(s string) bool {
+	switch strings.ToLower(s) {
+	case "yes", "true", "on":
+		return true
+	}
+	return false
+}
+
+func isPlain(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0
+}
+
+// schema derives the keys of values files and their types from Template,
+// the keys are returned in the order of Template.
+func schema() (map[string]field, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(Template, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse values template: %w", err)
+	}
+	m := doc.Content[0]
+	fields := make(map[string]field, len(m.Content)/2)
+	var keys []string
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, v := m.Content[i].Value, m.Content[i+1]
+		f := field{enum: enums[key]}
+		switch {
+		case v.Kind == yaml.SequenceNode:
+			f.kind = kindList
+		case v.Kind != yaml.ScalarNode || v.ShortTag() == "!!null":
+			f.kind = kindAny
+		case isPlain(v) && isBool(v.Value):
+			f.kind = kindBool
+		case v.ShortTag() == "!!int" || v.ShortTag() == "!!float":
+			f.kind = kindNumber
+		default:
+			f.kind = kindString
+			f.version = versionPattern.MatchString(v.Value)
+		}
+		fields[key] = f
+		keys = append(keys, key)
+	}
+	return fields, keys, nil
+}
+
+// Lint validates a values file against the keys and types of the values
+// files of the playbooks, the allowed values and dependencies of settings,
+// and checks the component versions against the release matrix for its
+// cns_version. An error is returned if data isn't a YAML mapping.
+func Lint(data []byte) ([]Problem, error) {
+	fields, keys, err := schema()
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("values must be a YAML mapping of settings")
+	}
+
+	l := &linter{values: make(map[string]*yaml.Node)}
+	m := doc.Content[0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k, v := m.Content[i], m.Content[i+1]
+		key := k.Value
+		if _, ok := l.values[key]; ok {
+			l.add(k.Line, key, checks.SeverityError, "duplicate key, the last value is used")
+		}
+		l.values[key] = v
+
+		f, ok := fields[key]
+		if !ok {
+			msg := "unknown key"
+			if s := suggest(key, keys); s != "" {
+				msg += fmt.Sprintf(", did you mean %s?", s)
+			}
+			l.add(k.Line, key, checks.SeverityError, msg)
+			continue
+		}
+		l.checkType(key, f, v)
+	}
+	for _, key := range keys {
+		if _, ok := l.values[key]; !ok {
+			l.add(0, key, checks.SeverityError, "missing key")
+		}
+	}
+
+	for _, d := range dependencies {
+		if l.bool(d.key) && !l.bool(d.requires) {
+			l.add(l.line(d.key), d.key, d.severity, fmt.Sprintf("requires %s to be enabled", d.requires))
+		}
+	}
+	if l.bool("proxy") && l.str("http_proxy") == "" && l.str("https_proxy") == "" {
+		l.add(l.line("proxy"), "proxy", checks.SeverityError, "requires http_proxy or https_proxy to be set")
+	}
+	if l.bool("enable_mig") && l.str("mig_profile") == "all-disabled" {
+		l.add(l.line("mig_profile"), "mig_profile", checks.SeverityWarning, "all-disabled doesn't enable MIG on any GPU")
+	}
+	if k8s := l.str("k8s_version"); k8s != "" {
+		for _, key := range []string{"k8s_apt_key", "k8s_gpg_key"} {
+			if v := l.str(key); v != "" && !strings.Contains(v, "/v"+minor(k8s)+"/") {
+				l.add(l.line(key), key, checks.SeverityWarning, fmt.Sprintf("doesn't match Kubernetes %s", minor(k8s)))
+			}
+		}
+	}
+	l.checkRelease()
+
+	slices.SortStableFunc(l.problems, func(a, b Problem) int { return a.Line - b.Line })
+	return l.problems, nil
+}
+
+type linter struct {
+	values   map[string]*yaml.Node
+	problems []Problem
+}
+
+func (l *linter) add(line int, key string, severity checks.Severity, msg string) {
+	l.problems = append(l.problems, Problem{Line: line, Key: key, Severity: severity, Message: msg})
+}
+
+func (l *linter) line(key string) int {
+	if v, ok := l.values[key]; ok {
+		return v.Line
+	}
+	return 0
+}
+
+// bool returns whether a setting is enabled.
+func (l *linter) bool(key string) bool {
+	v, ok := l.values[key]
+	return ok && isPlain(v) && boolValue(v.Value)
+}
+
+// str returns the value of a scalar setting.
+func (l *linter) str(key string) string {
+	if v, ok := l.values[key]; ok && v.Kind == yaml.ScalarNode && v.ShortTag() != "!!null" {
+		return v.Value
+	}
+	return ""
+}
+
+func (l *linter) checkType(key string, f field, v *yaml.Node) {
+	if f.kind == kindAny {
+		return
+	}
+	if f.kind == kindList {
+		if v.Kind != yaml.SequenceNode {
+			l.add(v.Line, key, checks.SeverityError, "must be a list")
+		}
+		return
+	}
+	if v.Kind != yaml.ScalarNode {
+		l.add(v.Line, key, checks.SeverityError, "must be a single value, not a list or mapping")
+		return
+	}
+
+	switch f.kind {
+	case kindBool:
+		if !isPlain(v) || !isBool(v.Value) {
+			l.add(v.Line, key, checks.SeverityError, fmt.Sprintf("must be yes or no, not %s", display(v)))
+		}
+	case kindNumber:
+		if _, err := strconv.ParseFloat(v.Value, 64); err != nil || !isPlain(v) {
+			l.add(v.Line, key, checks.SeverityError, fmt.Sprintf("must be a number, not %s", display(v)))
+		}
+	case kindString:
+		switch {
+		case isPlain(v) && isBool(v.Value):
+			l.add(v.Line, key, checks.SeverityError, fmt.Sprintf("%s is read as a boolean, quote it", v.Value))
+			return
+		case f.version && (v.ShortTag() == "!!int" || v.ShortTag() == "!!float"):
+			l.add(v.Line, key, checks.SeverityWarning, fmt.Sprintf("%s is read as a number, quote it", v.Value))
+		case f.version && !versionPattern.MatchString(v.Value):
+			l.add(v.Line, key, checks.SeverityError, fmt.Sprintf("%s isn't a version", display(v)))
+		}
+		if f.enum != nil && !slices.Contains(f.enum, v.Value) {
+			l.add(v.Line, key, checks.SeverityError, fmt.Sprintf("%s isn't one of %s", display(v), strings.Join(f.enum, ", ")))
+		}
+	}
+}
+
+// checkRelease checks the component versions against the release matrix.
+func (l *linter) checkRelease() {
+	version := l.str("cns_version")
+	if version == "" {
+		return
+	}
+	release, err := cns.Lookup(version)
+	if err != nil {
+		l.add(l.line("cns_version"), "cns_version", checks.SeverityError,
+			fmt.Sprintf("%s isn't a release of the release matrix", version))
+		return
+	}
+	server := release.Server()
+	if server == nil {
+		return
+	}
+
+	runtime := l.str("container_runtime")
+	for _, c := range releaseComponents {
+		want, got := server.Component(c.component), strings.TrimPrefix(l.str(c.key), "v")
+		if want == "" || got == "" || got == want || (c.when != "" && !l.bool(c.when)) {
+			continue
+		}
+		// Only the version of the runtime in use matters
+		if (c.key == "crio_version" && runtime != "cri-o") || (c.key == "containerd_version" && runtime == "cri-o") {
+			continue
+		}
+		severity := checks.SeverityWarning
+		if c.key == "k8s_version" && minor(got) != minor(want) {
+			severity = checks.SeverityError
+		}
+		l.add(l.line(c.key), c.key, severity,
+			fmt.Sprintf("Cloud Native Stack %s is validated with %s, not %s", version, want, got))
+	}
+}
+
+// display returns a scalar as written in the file.
+func display(v *yaml.Node) string {
+	if isPlain(v) {
+		return v.Value
+	}
+	return strconv.Quote(v.Value)
+}
+
+// suggest returns the key closest to a misspelled key, if any is close.
+func suggest(key string, keys []string) string {
+	best, dist := "", 3
+	for _, k := range keys {
+		if d := levenshtein(key, k); d < dist {
+			best, dist = k, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,81 @@
+package values_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/checks"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/values"
+)
+
+func TestLint_ShippedValues(t *testing.T) {
+	files, _ := filepath.Glob("../../../docs/playbooks/cns_values*.yaml")
+	if len(files) == 0 {
+		t.Skip("playbooks not available")
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		problems, err := values.Lint(b)
+		if err != nil {
+			t.Fatalf("%s: Lint() error = %v", f, err)
+		}
+		for _, p := range problems {
+			if p.Severity == checks.SeverityError {
+				t.Errorf("%s:%d: %s", f, p.Line, p)
+			}
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	data := string(values.Template)
+	for old, new := range map[string]string{
+		`container_runtime: "containerd"`: `container_runtime: "docker"`,
+		"enable_gds: no":                  "enable_gds: yes",
+		"enable_cdi: no":                  `enable_cdi: "no"`,
+		"mig_strategy: single":            "mig_stratgy: single",
+		`k8s_version: "1.33.2"`:           `k8s_version: "1.32.6"`,
+		`kserve_version: "0.15"`:          "kserve_version: 0.15",
+	} {
+		if !strings.Contains(data, old) {
+			t.Fatalf("template has no %q", old)
+		}
+		data = strings.Replace(data, old, new, 1)
+	}
+
+	problems, err := values.Lint([]byte(data))
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	for _, want := range []string{
+		`error: container_runtime: "docker" isn't one of containerd, cri-o, cri-dockerd`,
+		"error: k8s_version: Cloud Native Stack 16.0 is validated with 1.33.2, not 1.32.6",
+		"warning: kserve_version: 0.15 is read as a number, quote it",
+		"error: mig_stratgy: unknown key, did you mean mig_strategy?",
+		`error: enable_cdi: must be yes or no, not "no"`,
+		"error: enable_gds: requires use_open_kernel_module to be enabled",
+		"warning: k8s_apt_key: doesn't match Kubernetes 1.32",
+		"error: mig_strategy: missing key",
+	} {
+		found := false
+		for _, g := range got {
+			found = found || g == want
+		}
+		if !found {
+			t.Errorf("missing problem %q in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+
+	if _, err := values.Lint([]byte("- a\n- b\n")); err == nil {
+		t.Error("Lint() of a list succeeded")
+	}
+}
@@ -0,0 +1,122 @@
+// Package values generates the Ansible values files of the Cloud Native Stack
+// playbooks, e.g. docs/playbooks/cns_values_16.0.yaml, from node snapshots.
+package values
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:generate cp ../../../docs/playbooks/cns_values_16.0.yaml cns_values.yaml
+
+// Template is the values file of the latest release, generated values files
+// follow its keys, comments and defaults.
+//
+//go:embed cns_values.yaml
+var Template []byte
+
+// Value is the value of a key of the values file, a string or a bool. Value is
+// nil when it couldn't be determined, the default of Template is then kept
+// and Note explains why.
+type Value struct {
+	Key   string `json:"key" yaml:"key"`
+	Value any    `json:"value" yaml:"value"`
+	Note  string `json:"note,omitempty" yaml:"note,omitempty"`
+}
+
+// entry is a top-level key of Template.
+type entry struct {
+	line  int // 0-based
+	value *yaml.Node
+}
+
+// parseTemplate returns the top-level keys of Template.
+func parseTemplate() (map[string]entry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(Template, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse values template: %w", err)
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("values template isn't a mapping")
+	}
+	m := doc.Content[0]
+	entries := make(map[string]entry, len(m.Content)/2)
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		entries[m.Content[i].Value] = entry{line: m.Content[i].Line - 1, value: m.Content[i+1]}
+	}
+	return entries, nil
+}
+
+// Default returns the value of key in Template, empty if it's missing or
+// isn't a scalar.
+func Default(key string) string {
+	entries, err := parseTemplate()
+	if err != nil {
+		return ""
+	}
+	e, ok := entries[key]
+	if !ok || e.value.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return e.value.Value
+}
+
+// Write writes Template with the given values, preceded by a header naming
+// the source of the values. Keys whose value couldn't be determined keep
+// their default and are flagged with an "eidos:" comment.
+func Write(w io.Writer, source string, values []Value) error {
+	entries, err := parseTemplate()
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(Template), "\n"), "\n")
+	for _, v := range values {
+		e, ok := entries[v.Key]
+		if !ok {
+			return fmt.Errorf("unknown values key %q", v.Key)
+		}
+		if e.value.Kind != yaml.ScalarNode || e.value.Line != e.line+1 {
+			return fmt.Errorf("values key %q isn't a scalar", v.Key)
+		}
+
+		value := e.value.Value
+		switch x := v.Value.(type) {
+		case nil:
+		case bool:
+			value = "no"
+			if x {
+				value = "yes"
+			}
+		case string:
+			value = x
+		default:
+			return fmt.Errorf("unsupported value %v of %q", v.Value, v.Key)
+		}
+		if e.value.Style == yaml.DoubleQuotedStyle {
+			value = strconv.Quote(value)
+		}
+
+		line := v.Key + ": " + value
+		if v.Value == nil {
+			line += " # eidos: could not be determined"
+			if v.Note != "" {
+				line += ", " + v.Note
+			}
+		}
+		lines[e.line] = line
+	}
+
+	header := fmt.Sprintf("# Generated by eidos export ansible-values from %s.\n"+
+		"# Values marked with \"eidos:\" could not be determined from the node and are\n"+
+		"# the defaults of Cloud Native Stack %s, as are the settings that aren't\n"+
+		"# detected, e.g. the operators to install.\n\n", source, Default("cns_version"))
+	if _, err := io.WriteString(w, header+strings.Join(lines, "\n")+"\n"); err != nil {
+		return fmt.Errorf("failed to write values: %w", err)
+	}
+	return nil
+}
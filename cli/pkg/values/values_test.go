@@ -0,0 +1,187 @@
+package values_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/values"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTemplate(t *testing.T) {
+	b, err := os.ReadFile("../../../docs/playbooks/cns_values_16.0.yaml")
+	if err != nil {
+		t.Skipf("playbooks not available: %v", err)
+	}
+	if !bytes.Equal(b, values.Template) {
+		t.Error("cns_values.yaml is out of date, run go generate ./pkg/values")
+	}
+}
+
+func pkg(name, version string) collectors.Configuration {
+	return collectors.Configuration{Type: collectors.PackageType, Data: collectors.PackageConfig{Name: name, Version: version, Arch: "amd64"}}
+}
+
+func kmod(name string) collectors.Configuration {
+	return collectors.Configuration{Type: collectors.KModType, Data: collectors.KModConfig{Name: name}}
+}
+
+func TestDetect(t *testing.T) {
+	configs := []collectors.Configuration{
+		pkg("kubelet", "1.31.10-1.1"),
+		pkg("containerd.io", "1.7.27-1"),
+		pkg("nvidia-container-toolkit", "1.17.8-1"),
+		pkg("nvidia-driver-580-open", "580.82.07-0ubuntu1"),
+		pkg("libnvidia-compute-580", "580.82.07-0ubuntu1"),
+		kmod("nvidia"),
+		kmod("nvidia_fs"),
+		{Type: "Security", Data: collectors.SecurityConfig{Key: collectors.SecurityKeySecureBoot, Value: collectors.SecurityEnabled}},
+		{Type: "GPU", Data: collectors.GPUConfig{Key: collectors.GPUKeyMIGMode, Value: collectors.GPUMIGUnknown}},
+		{Type: "GPU", Data: collectors.GPUConfig{Key: collectors.GPUKeyCDISpecs, Value: "/var/run/cdi/nvidia-container-toolkit.json"}},
+	}
+	got := make(map[string]values.Value)
+	for _, v := range values.Detect(configs) {
+		got[v.Key] = v
+	}
+
+	for key, want := range map[string]any{
+		"install_k8s":                      true,
+		"container_runtime":                "containerd",
+		"containerd_version":               "1.7.27",
+		"nvidia_container_toolkit_version": "1.17.8",
+		"k8s_version":                      "1.31.10",
+		"k8s_apt_key":                      "https://pkgs.k8s.io/core:/stable:/v1.31/deb/Release.key",
+		"gpu_driver_version":               "580.82.07",
+		"use_open_kernel_module":           true,
+		"enable_gds":                       true,
+		"enable_secure_boot":               true,
+		"runc_version":                     nil,
+		"enable_mig":                       nil,
+		"enable_cdi":                       true,
+		"cns_version":                      nil,
+	} {
+		v, ok := got[key]
+		if !ok || v.Value != want {
+			t.Errorf("%s = %+v, want %v", key, v, want)
+		}
+		if want == nil && v.Note == "" {
+			t.Errorf("%s has no note", key)
+		}
+	}
+	if _, ok := got["crio_version"]; ok {
+		t.Error("crio_version detected on a containerd node")
+	}
+}
+
+func TestDetect_ReleaseBinaries(t *testing.T) {
+	binary := func(name, version string) collectors.Configuration {
+		return collectors.Configuration{Type: collectors.PackageType, Data: collectors.PackageConfig{
+			Name: name, Version: version, Arch: "amd64", Manager: collectors.PackageManagerBinary,
+		}}
+	}
+	// A node set up by the CNS playbooks: kubelet pulls in the runc and
+	// kubernetes-cni packages, the release binaries in /usr/local and
+	// /opt/cni/bin are the ones in use
+	configs := []collectors.Configuration{
+		pkg("kubelet", "1.31.10-1.1"),
+		pkg("kubernetes-cni", "1.4.0-1.1"),
+		pkg("runc", "1.1.12-0ubuntu3"),
+		binary("containerd", "1.7.27"),
+		binary("runc", "1.2.6"),
+		binary("cni-plugins", "1.6.2"),
+		binary("helm", "3.17.3"),
+	}
+	got := make(map[string]values.Value)
+	for _, v := range values.Detect(configs) {
+		got[v.Key] = v
+	}
+
+	for key, want := range map[string]any{
+		"container_runtime":   "containerd",
+		"containerd_version":  "1.7.27",
+		"runc_version":        "1.2.6",
+		"cni_plugins_version": "1.6.2",
+		"helm_version":        "3.17.3",
+	} {
+		if v := got[key]; v.Value != want {
+			t.Errorf("%s = %+v, want %v", key, v, want)
+		}
+	}
+}
+
+func TestDetect_GPU(t *testing.T) {
+	gpu := func(key, value string) collectors.Configuration {
+		return collectors.Configuration{Type: collectors.GPUType, Data: collectors.GPUConfig{Key: key, Value: value}}
+	}
+
+	tests := []struct {
+		name     string
+		configs  []collectors.Configuration
+		mig, cdi any
+		migNoted bool
+		cdiNoted bool
+	}{
+		{"enabled", []collectors.Configuration{
+			gpu(collectors.GPUKeyMIGMode, collectors.GPUMIGEnabled),
+			gpu(collectors.GPUKeyCDISpecs, "/etc/cdi/nvidia.yaml"),
+		}, true, true, false, false},
+		{"disabled", []collectors.Configuration{
+			gpu(collectors.GPUKeyMIGMode, collectors.GPUMIGDisabled),
+			gpu(collectors.GPUKeyCDISpecs, ""),
+		}, false, false, false, false},
+		{"not collected", nil, nil, nil, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]values.Value)
+			for _, v := range values.Detect(tt.configs) {
+				got[v.Key] = v
+			}
+			if v := got["enable_mig"]; v.Value != tt.mig || (v.Note != "") != tt.migNoted {
+				t.Errorf("enable_mig = %+v, want %v", v, tt.mig)
+			}
+			if v := got["enable_cdi"]; v.Value != tt.cdi || (v.Note != "") != tt.cdiNoted {
+				t.Errorf("enable_cdi = %+v, want %v", v, tt.cdi)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	err := values.Write(&b, "gpu-01", []values.Value{
+		{Key: "container_runtime", Value: "cri-o"},
+		{Key: "use_open_kernel_module", Value: true},
+		{Key: "enable_mig", Note: "not collected"},
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# Generated by eidos export ansible-values from gpu-01.",
+		"\ncontainer_runtime: \"cri-o\"\n",
+		"\nuse_open_kernel_module: yes\n",
+		"\nenable_mig: no # eidos: could not be determined, not collected\n",
+		"\n# To use GDS, use_open_kernel_module needs to be enabled\nenable_gds: no\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("values missing %q", want)
+		}
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("generated values aren't valid YAML: %v", err)
+	}
+	if doc["container_runtime"] != "cri-o" || doc["cns_version"] != 16.0 {
+		t.Errorf("values = %v", doc)
+	}
+
+	if err := values.Write(&b, "gpu-01", []values.Value{{Key: "typo", Value: true}}); err == nil {
+		t.Error("Write() of an unknown key succeeded")
+	}
+}
@@ -0,0 +1,224 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Event reports a change that may affect the results of a collector.
+type Event struct {
+	// Collector is the name of the affected collector, see
+	// snapshotter.CollectorNames.
+	Collector string
+	// Source describes the change, e.g. "file /etc/sysctl.d/99-k8s.conf".
+	Source string
+}
+
+// Source produces events until ctx is canceled.
+type Source interface {
+	Run(ctx context.Context, events chan<- Event) error
+}
+
+// FileWatch maps a file or directory to the collector reading it.
+type FileWatch struct {
+	Path      string
+	Collector string
+}
+
+// DefaultFileWatches are the configuration files and directories watched for
+// changes by default.
+var DefaultFileWatches = []FileWatch{
+	{Path: "/etc/sysctl.conf", Collector: "sysctl"},
+	{Path: "/etc/sysctl.d", Collector: "sysctl"},
+	{Path: "/etc/modprobe.d", Collector: "kmod"},
+	{Path: "/etc/modules-load.d", Collector: "kmod"},
+	{Path: "/etc/default/grub", Collector: "grub"},
+	{Path: "/etc/default/grub.d", Collector: "grub"},
+	{Path: "/etc/containerd", Collector: "mounts"},
+	{Path: "/etc/fstab", Collector: "mounts"},
+	{Path: "/etc/systemd/system", Collector: "systemd"},
+	{Path: "/etc/cdi", Collector: "gpu"},
+}
+
+// FileSource reports changes of watched files and directories with inotify.
+// Directories are watched along with their parent, so they are picked up
+// when created later and files replaced by editors aren't missed, and with
+// their subdirectories, e.g. unit drop-in directories, as inotify watches
+// aren't recursive.
+type FileSource struct {
+	Watches []FileWatch
+	// HostRoot is the host root filesystem prefix, e.g. /host in a container.
+	HostRoot string
+	Logger   *slog.Logger
+}
+
+// Run watches the files until ctx is canceled.
+func (s *FileSource) Run(ctx context.Context, events chan<- Event) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer w.Close()
+
+	watched := make(map[string]bool)
+	watch := func(dir string) error {
+		if watched[dir] {
+			return nil
+		}
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			return nil
+		}
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		watched[dir] = true
+		logger.Debug("watching", slog.String("path", dir))
+		return nil
+	}
+	// watchTree watches dir and its subdirectories, symlinks aren't followed
+	// and unreadable directories are skipped
+	watchTree := func(dir string) error {
+		return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			return watch(p)
+		})
+	}
+
+	for _, fw := range s.Watches {
+		p := s.path(fw.Path)
+		if err := watch(filepath.Dir(p)); err != nil {
+			return err
+		}
+		if err := watchTree(p); err != nil {
+			return err
+		}
+	}
+	if len(watched) == 0 {
+		return errors.New("none of the watched paths exist")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			// Queue overflows lose events, the periodic snapshot catches up
+			logger.Warn("file watch error", slog.String("error", err.Error()))
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			for _, fw := range s.Watches {
+				p := s.path(fw.Path)
+				if ev.Name != p && !strings.HasPrefix(ev.Name, p+string(filepath.Separator)) {
+					continue
+				}
+				// Directories created after start, the watched one or below it,
+				// are watched from now on. Removed ones are watched again when
+				// they are recreated.
+				switch {
+				case ev.Has(fsnotify.Create):
+					if err := watchTree(ev.Name); err != nil {
+						logger.Warn("failed to watch", slog.String("path", ev.Name), slog.String("error", err.Error()))
+					}
+				case ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename):
+					for dir := range watched {
+						if dir == ev.Name || strings.HasPrefix(dir, ev.Name+string(filepath.Separator)) {
+							_ = w.Remove(dir)
+							delete(watched, dir)
+						}
+					}
+				}
+				name := strings.TrimPrefix(ev.Name, filepath.Clean(s.HostRoot))
+				if !send(ctx, events, Event{Collector: fw.Collector, Source: "file " + name}) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (s *FileSource) path(p string) string {
+	if s.HostRoot == "" {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(s.HostRoot, p)
+}
+
+// UnitSource reports property changes of systemd units, e.g. restarts, from
+// the D-Bus PropertiesChanged signals.
+type UnitSource struct {
+	Units  []string
+	Logger *slog.Logger
+}
+
+// Run subscribes to the unit signals until ctx is canceled.
+func (s *UnitSource) Run(ctx context.Context, events chan<- Event) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	conn, err := dbus.NewSystemdConnectionContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd: %w", err)
+	}
+	defer conn.Close()
+
+	updates := make(chan *dbus.PropertiesUpdate, 256)
+	errs := make(chan error, 16)
+	conn.SetPropertiesSubscriber(updates, errs)
+	if err := conn.Subscribe(); err != nil {
+		return fmt.Errorf("failed to subscribe to systemd signals: %w", err)
+	}
+	defer func() { _ = conn.Unsubscribe() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			logger.Debug("systemd signal error", slog.String("error", err.Error()))
+		case u := <-updates:
+			if !slices.Contains(s.Units, u.UnitName) {
+				continue
+			}
+			if !send(ctx, events, Event{Collector: "systemd", Source: "unit " + u.UnitName}) {
+				return nil
+			}
+		}
+	}
+}
+
+// send sends an event, it returns false if ctx was canceled first.
+func send(ctx context.Context, events chan<- Event, ev Event) bool {
+	select {
+	case events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,201 @@
+// Package watch snapshots a node periodically and on configuration events,
+// and records the changes in a history store.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/history"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshot"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
+)
+
+const (
+	// DefaultInterval is the time between snapshots.
+	DefaultInterval = 5 * time.Minute
+
+	// DefaultDebounce is the time events are collected before the affected
+	// collectors run, so a burst of events results in a single snapshot.
+	DefaultDebounce = 2 * time.Second
+)
+
+// Watcher takes a snapshot on an interval and adds it to a history store.
+// With event sources, only the collectors affected by an event run again
+// and the interval becomes a periodic full resync.
+type Watcher struct {
+	// Snapshotter collects the snapshots. Volatile fields are always stripped,
+	// they would make every snapshot a change.
+	Snapshotter *snapshotter.NodeSnapshotter
+	Store       *history.Store
+	Logger      *slog.Logger
+	// Interval is the time between snapshots, DefaultInterval when zero.
+	Interval time.Duration
+	// Sources, when set, report changes that trigger re-collection of the
+	// affected collectors between intervals.
+	Sources []Source
+	// Debounce is the time events are collected before the affected
+	// collectors run, DefaultDebounce when zero.
+	Debounce time.Duration
+	// OnChange, when set, is called for each stored entry.
+	OnChange func(*history.Entry)
+
+	// results are the latest results by collector, the current snapshot
+	results map[string][]collectors.Configuration
+}
+
+// Run takes a snapshot immediately and then on every interval and after
+// events until ctx is canceled. Failed snapshots are logged and retried on
+// the next interval. Failing event sources are logged and leave the watcher
+// polling.
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.Logger == nil {
+		w.Logger = slog.Default()
+	}
+	if w.Interval <= 0 {
+		w.Interval = DefaultInterval
+	}
+	if w.Debounce <= 0 {
+		w.Debounce = DefaultDebounce
+	}
+	w.Snapshotter.StripVolatile = true
+
+	w.Logger.Info("watching node configuration",
+		slog.String("history", w.Store.Dir()),
+		slog.Duration("interval", w.Interval),
+		slog.Int("event_sources", len(w.Sources)))
+
+	events := make(chan Event, 64)
+	for _, s := range w.Sources {
+		go func() {
+			if err := s.Run(ctx, events); err != nil && ctx.Err() == nil {
+				w.Logger.Warn("event source failed, changes are detected on the interval only",
+					slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	// pending are the collectors affected by events since the last snapshot,
+	// with the first event of each as trigger
+	pending := make(map[string]string)
+	var debounce <-chan time.Time
+
+	resync := true
+	for {
+		if resync {
+			if _, err := w.Snapshot(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				w.Logger.Error("failed to snapshot", slog.String("error", err.Error()))
+			}
+			resync = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			resync = true
+			clear(pending)
+			debounce = nil
+		case ev := <-events:
+			w.Logger.Debug("configuration event", slog.String("collector", ev.Collector), slog.String("source", ev.Source))
+			if _, ok := pending[ev.Collector]; !ok {
+				pending[ev.Collector] = ev.Source
+			}
+			if debounce == nil {
+				debounce = time.After(w.Debounce)
+			}
+		case <-debounce:
+			debounce = nil
+			if _, err := w.Recollect(ctx, pending); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				w.Logger.Error("failed to snapshot", slog.String("error", err.Error()))
+			}
+			clear(pending)
+		}
+	}
+}
+
+// Snapshot takes a full snapshot and adds it to the store. It returns the
+// stored entry, nil if nothing changed since the last snapshot.
+func (w *Watcher) Snapshot(ctx context.Context) (*history.Entry, error) {
+	results, err := w.Snapshotter.CollectByCollector(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.results = results
+	return w.store("")
+}
+
+// Recollect runs the given collectors again and adds the resulting snapshot
+// to the store. Triggers maps the collector names to what caused them to
+// run. Without a previous snapshot, all collectors run.
+func (w *Watcher) Recollect(ctx context.Context, triggers map[string]string) (*history.Entry, error) {
+	if w.results == nil {
+		return w.Snapshot(ctx)
+	}
+
+	names := slices.Sorted(maps.Keys(triggers))
+	ns := *w.Snapshotter
+	ns.Collectors = names
+	results, err := ns.CollectByCollector(ctx)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(w.results, results)
+
+	sources := make([]string, 0, len(names))
+	for _, name := range names {
+		sources = append(sources, triggers[name])
+	}
+	return w.store(strings.Join(sources, ", "))
+}
+
+// store adds the current snapshot to the store.
+func (w *Watcher) store(trigger string) (*history.Entry, error) {
+	var configs []collectors.Configuration
+	for _, name := range snapshotter.CollectorNames() {
+		configs = append(configs, w.results[name]...)
+	}
+	collectors.Sort(configs)
+
+	entry, err := w.Store.Add(snapshot.New(configs), trigger)
+	if err != nil {
+		return entry, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	if entry == nil {
+		w.Logger.Debug("no configuration changes", slog.String("trigger", trigger))
+		return nil, nil
+	}
+
+	if entry.Previous == "" {
+		w.Logger.Info("stored initial snapshot", slog.String("id", entry.ID), slog.Int("items", entry.Items))
+	} else {
+		w.Logger.Info("configuration changed",
+			slog.String("id", entry.ID),
+			slog.String("previous", entry.Previous),
+			slog.String("trigger", trigger),
+			slog.Int("changes", len(entry.Changes)))
+		for _, c := range entry.Changes {
+			w.Logger.Info("change", slog.String("kind", string(c.Kind)),
+				slog.String("type", c.Type), slog.String("id", c.ID))
+		}
+	}
+	if w.OnChange != nil {
+		w.OnChange(entry)
+	}
+	return entry, nil
+}
@@ -0,0 +1,381 @@
+package watch_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/collectors"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/history"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/snapshotter"
+	"github.com/NVIDIA/cloud-native-stack/cli/pkg/watch"
+)
+
+// sysctlCollector returns the swappiness sysctl with a value that can be
+// changed between snapshots.
+type sysctlCollector struct {
+	mu    sync.Mutex
+	value string
+}
+
+func (c *sysctlCollector) set(v string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value = v
+}
+
+func (c *sysctlCollector) Collect(context.Context) ([]collectors.Configuration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return []collectors.Configuration{
+		{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: "/proc/sys/vm/swappiness", Value: c.value}},
+	}, nil
+}
+
+// volatileSysctlCollector returns an unchanged sysctl tree with the entries
+// the kernel changes on every read, and counts the reads.
+type volatileSysctlCollector struct {
+	reads atomic.Int32
+}
+
+func (c *volatileSysctlCollector) Collect(context.Context) ([]collectors.Configuration, error) {
+	n := c.reads.Add(1)
+	sysctl := func(key, value string) collectors.Configuration {
+		return collectors.Configuration{Type: collectors.SysctlType, Data: collectors.SysctlConfig{Key: key, Value: value}}
+	}
+	return []collectors.Configuration{
+		sysctl("/proc/sys/vm/swappiness", "60"),
+		sysctl("/proc/sys/kernel/random/uuid", fmt.Sprintf("5f0c3a52-0000-4000-8000-%012d", n)),
+		sysctl("/proc/sys/kernel/random/entropy_avail", fmt.Sprint(256+n)),
+		sysctl("/proc/sys/kernel/ns_last_pid", fmt.Sprint(4000+n)),
+		sysctl("/proc/sys/fs/file-nr", fmt.Sprintf("%d\t0\t9223372036854775807", 3000+n)),
+		sysctl("/proc/sys/fs/dentry-state", fmt.Sprintf("%d\t%d\t45\t0\t0\t0", 90000+n, 80000+n)),
+	}, nil
+}
+
+type emptyCollector struct{}
+
+func (emptyCollector) Collect(context.Context) ([]collectors.Configuration, error) { return nil, nil }
+
+type fakeFactory struct {
+	sysctl collectors.Collector
+}
+
+func (f *fakeFactory) CreateKModCollector() collectors.Collector     { return emptyCollector{} }
+func (f *fakeFactory) CreateSystemDCollector() collectors.Collector  { return emptyCollector{} }
+func (f *fakeFactory) CreateGrubCollector() collectors.Collector     { return emptyCollector{} }
+func (f *fakeFactory) CreateSysctlCollector() collectors.Collector   { return f.sysctl }
+func (f *fakeFactory) CreateNetworkCollector() collectors.Collector  { return emptyCollector{} }
+func (f *fakeFactory) CreatePackageCollector() collectors.Collector  { return emptyCollector{} }
+func (f *fakeFactory) CreateSecurityCollector() collectors.Collector { return emptyCollector{} }
+func (f *fakeFactory) CreateMountCollector() collectors.Collector    { return emptyCollector{} }
+func (f *fakeFactory) CreateGPUCollector() collectors.Collector      { return emptyCollector{} }
+
+func TestWatcher_Run(t *testing.T) {
+	store, err := history.Open(t.TempDir(), history.Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sysctl := &sysctlCollector{value: "60"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stored := make(chan *history.Entry, 10)
+	w := &watch.Watcher{
+		Snapshotter: &snapshotter.NodeSnapshotter{Factory: &fakeFactory{sysctl: sysctl}, Logger: logger},
+		Store:       store,
+		Logger:      logger,
+		Interval:    10 * time.Millisecond,
+		OnChange:    func(e *history.Entry) { stored <- e },
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	next := func() *history.Entry {
+		t.Helper()
+		select {
+		case e := <-stored:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no entry stored")
+			return nil
+		}
+	}
+
+	if e := next(); e.Previous != "" {
+		t.Errorf("initial entry = %+v", e)
+	}
+
+	sysctl.set("0")
+	e := next()
+	if len(e.Changes) != 1 || e.Changes[0].Fields[0].New != "0" {
+		t.Errorf("changes = %+v", e.Changes)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+
+	// Ticks without changes don't add entries
+	entries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d entries, want 2", len(entries))
+	}
+}
+
+func TestWatcher_RunVolatileSysctls(t *testing.T) {
+	store, err := history.Open(t.TempDir(), history.Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sysctl := &volatileSysctlCollector{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := &watch.Watcher{
+		Snapshotter: &snapshotter.NodeSnapshotter{Factory: &fakeFactory{sysctl: sysctl}, Logger: logger},
+		Store:       store,
+		Logger:      logger,
+		Interval:    10 * time.Millisecond,
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	// Wait for the initial snapshot and a resync over the unchanged tree
+	deadline := time.After(5 * time.Second)
+	for sysctl.reads.Load() < 3 {
+		select {
+		case <-deadline:
+			t.Fatal("watcher didn't resync")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d entries, want 1: volatile sysctls were stored as changes", len(entries))
+	}
+}
+
+// chanSource forwards events sent to its channel.
+type chanSource chan watch.Event
+
+func (s chanSource) Run(ctx context.Context, events chan<- watch.Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-s:
+			events <- ev
+		}
+	}
+}
+
+// countingCollector counts its runs.
+type countingCollector struct {
+	mu   sync.Mutex
+	runs int
+}
+
+func (c *countingCollector) Collect(context.Context) ([]collectors.Configuration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs++
+	return []collectors.Configuration{{Type: collectors.KModType, Data: collectors.KModConfig{Name: "nvidia"}}}, nil
+}
+
+type countingFactory struct {
+	fakeFactory
+	kmod *countingCollector
+}
+
+func (f *countingFactory) CreateKModCollector() collectors.Collector { return f.kmod }
+
+func TestWatcher_Events(t *testing.T) {
+	store, err := history.Open(t.TempDir(), history.Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sysctl := &sysctlCollector{value: "60"}
+	kmod := &countingCollector{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := make(chanSource)
+	stored := make(chan *history.Entry, 10)
+	w := &watch.Watcher{
+		Snapshotter: &snapshotter.NodeSnapshotter{
+			Factory: &countingFactory{fakeFactory: fakeFactory{sysctl: sysctl}, kmod: kmod},
+			Logger:  logger,
+		},
+		Store:    store,
+		Logger:   logger,
+		Interval: time.Hour,
+		Debounce: 20 * time.Millisecond,
+		Sources:  []watch.Source{source},
+		OnChange: func(e *history.Entry) { stored <- e },
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	select {
+	case <-stored:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no initial entry stored")
+	}
+
+	// A burst of events results in one snapshot of the affected collector
+	sysctl.set("0")
+	source <- watch.Event{Collector: "sysctl", Source: "file /etc/sysctl.d/99-k8s.conf"}
+	source <- watch.Event{Collector: "sysctl", Source: "file /etc/sysctl.d/99-other.conf"}
+
+	select {
+	case e := <-stored:
+		if e.Trigger != "file /etc/sysctl.d/99-k8s.conf" {
+			t.Errorf("trigger = %q", e.Trigger)
+		}
+		if len(e.Changes) != 1 || e.Changes[0].Type != collectors.SysctlType {
+			t.Errorf("changes = %+v", e.Changes)
+		}
+		// The unchanged kmod configuration is kept from the initial snapshot
+		if e.Items != 2 {
+			t.Errorf("items = %d, want 2", e.Items)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no entry stored for event")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+
+	kmod.mu.Lock()
+	defer kmod.mu.Unlock()
+	if kmod.runs != 1 {
+		t.Errorf("kmod collector ran %d times, want 1", kmod.runs)
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "etc", "sysctl.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "etc", "systemd", "system", "containerd.service.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := &watch.FileSource{
+		HostRoot: root,
+		Watches: []watch.FileWatch{
+			{Path: "/etc/sysctl.d", Collector: "sysctl"},
+			{Path: "/etc/modprobe.d", Collector: "kmod"},
+			{Path: "/etc/systemd/system", Collector: "systemd"},
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	events := make(chan watch.Event, 16)
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx, events) }()
+
+	expect := func(collector, source string) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case ev := <-events:
+				if ev.Collector == collector && ev.Source == source {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("no %s event for %s", collector, source)
+			}
+		}
+	}
+
+	// Wait for the watches to be set up by retrying the first change
+	write := func(path string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, path), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for ready := false; !ready; {
+		write("etc/sysctl.d/99-k8s.conf")
+		select {
+		case ev := <-events:
+			ready = ev.Source == "file /etc/sysctl.d/99-k8s.conf"
+		case <-time.After(50 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("file source did not report changes")
+			}
+		}
+	}
+
+	// Directories created after start are picked up
+	if err := os.MkdirAll(filepath.Join(root, "etc", "modprobe.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	expect("kmod", "file /etc/modprobe.d")
+	time.Sleep(50 * time.Millisecond)
+	write("etc/modprobe.d/nouveau.conf")
+	expect("kmod", "file /etc/modprobe.d/nouveau.conf")
+
+	// Subdirectories are watched, existing ones and those created later
+	if err := os.Mkdir(filepath.Join(root, "etc", "sysctl.d", "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	expect("sysctl", "file /etc/sysctl.d/nested")
+	time.Sleep(50 * time.Millisecond)
+	write("etc/sysctl.d/nested/20-net.conf")
+	expect("sysctl", "file /etc/sysctl.d/nested/20-net.conf")
+	write("etc/systemd/system/containerd.service.d/10-proxy.conf")
+	expect("systemd", "file /etc/systemd/system/containerd.service.d/10-proxy.conf")
+
+	// Unrelated files are ignored
+	write("etc/hosts")
+	select {
+	case ev := <-events:
+		if ev.Source == "file /etc/hosts" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}